@@ -14,6 +14,7 @@ import (
 	"golang.org/x/sync/errgroup"
 
 	"github.com/Clink-n-Clank/Brokkr/component/background"
+	"github.com/Clink-n-Clank/Brokkr/component/background/leader"
 )
 
 type (
@@ -49,6 +50,21 @@ func AddBackgroundTasks(bt ...background.Process) Options {
 	}
 }
 
+// AddLeaderBackgroundTasks that will be created and executed only while elector holds leadership, elector is added as background task
+func AddLeaderBackgroundTasks(e *leader.Elector, f ...leader.ProcessFactory) Options {
+	return func(c *Brokkr) {
+		e.AddProcesses(f...)
+
+		for _, t := range c.backgroundTasks {
+			if t == background.Process(e) {
+				return
+			}
+		}
+
+		c.backgroundTasks = append(c.backgroundTasks, e)
+	}
+}
+
 // NewBrokkr framework instance
 func NewBrokkr(opts ...Options) (b *Brokkr) {
 	b = &Brokkr{
@@ -138,6 +154,17 @@ func (c *Brokkr) Stop() error {
 	return nil
 }
 
+// GetBackgroundTask registered in Brokkr by its name
+func (c *Brokkr) GetBackgroundTask(name string) (background.Process, bool) {
+	for _, t := range c.backgroundTasks {
+		if t.GetName() == name {
+			return t, true
+		}
+	}
+
+	return nil, false
+}
+
 // createChildContext from parent
 func (c Brokkr) createChildContext(k contextOfBrokkr, v string) context.Context {
 	return context.WithValue(c.mainContext, k, v)
@@ -9,6 +9,8 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/Clink-n-Clank/Brokkr/component/background"
+	"github.com/Clink-n-Clank/Brokkr/component/background/leader"
+	"github.com/Clink-n-Clank/Brokkr/component/behavior/lease"
 	"github.com/Clink-n-Clank/Brokkr/component/execution"
 )
 
@@ -74,6 +76,33 @@ func TestBrokkr_BackgroundTaskExecution(t *testing.T) {
 	}
 }
 
+func TestBrokkr_GetBackgroundTask(t *testing.T) {
+	tBgTask := testBackgroundTask{sv: background.TaskSeverityMinor}
+	c := NewBrokkr(AddBackgroundTasks(tBgTask))
+
+	found, isFound := c.GetBackgroundTask("test")
+	assert.True(t, isFound)
+	assert.Equal(t, tBgTask, found)
+
+	_, isFound = c.GetBackgroundTask("unknown")
+	assert.False(t, isFound)
+}
+
+func TestBrokkr_LeaderBackgroundTasks(t *testing.T) {
+	e := leader.NewElector("test-elector", lease.NewMemoryLocker())
+	newTask := func() background.Process { return testBackgroundTask{sv: background.TaskSeverityMinor} }
+	c := NewBrokkr(
+		AddLeaderBackgroundTasks(e, newTask),
+		AddLeaderBackgroundTasks(e, newTask),
+	)
+
+	assert.Len(t, c.backgroundTasks, 1)
+
+	found, isFound := c.GetBackgroundTask("test-elector")
+	assert.True(t, isFound)
+	assert.Equal(t, e, found)
+}
+
 type testBackgroundTask struct {
 	sv background.ProcessSeverity
 }
@@ -0,0 +1,251 @@
+package accesslog
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	brokkrgrpc "github.com/Clink-n-Clank/Brokkr/component/background/grpc"
+	"github.com/Clink-n-Clank/Brokkr/component/background/route"
+	"github.com/Clink-n-Clank/Brokkr/component/redact"
+)
+
+type (
+	// Entry of access log, it's written as one JSON line
+	Entry struct {
+		Time         time.Time           `json:"time"`
+		Method       string              `json:"method"`
+		Peer         string              `json:"peer,omitempty"`
+		Metadata     map[string][]string `json:"metadata,omitempty"`
+		Code         string              `json:"code"`
+		Error        string              `json:"error,omitempty"`
+		LatencyMS    float64             `json:"latency_ms"`
+		RequestSize  int                 `json:"request_size"`
+		ResponseSize int                 `json:"response_size"`
+		// Request and Response payloads, they are set only for unary calls when payloads are enabled
+		Request  json.RawMessage `json:"request,omitempty"`
+		Response json.RawMessage `json:"response,omitempty"`
+	}
+
+	// sampling rate of routes matched by filter
+	sampling struct {
+		matcher route.Matcher
+		rate    float64
+	}
+
+	// Logger writes access log entries of gRPC calls
+	Logger struct {
+		mu  sync.Mutex
+		out io.Writer
+
+		metadataKeys []string
+		isPayload    bool
+		redactor     redactor
+		samplings    []sampling
+		sample       func() float64
+		now          func() time.Time
+	}
+
+	// Option for access log configuration
+	Option func(l *Logger)
+)
+
+// SetMetadataKeys subset of metadata that is logged, metadata is not logged by default
+func SetMetadataKeys(keys ...string) Option {
+	return func(l *Logger) {
+		for _, k := range keys {
+			l.metadataKeys = append(l.metadataKeys, strings.ToLower(k))
+		}
+	}
+}
+
+// SetRedactedMetadata keys which values are replaced, "authorization" and "cookie" are redacted by default
+func SetRedactedMetadata(keys ...string) Option {
+	return func(l *Logger) {
+		for _, k := range keys {
+			l.redactor.metadataKeys[strings.ToLower(k)] = struct{}{}
+		}
+	}
+}
+
+// SetPayloads logging of unary requests and responses as JSON
+func SetPayloads(isEnabled bool) Option {
+	return func(l *Logger) {
+		l.isPayload = isEnabled
+	}
+}
+
+// SetRedactedFields of payloads by proto name like "password" or by path like "user.password",
+// string fields are replaced and other fields are removed
+func SetRedactedFields(fields ...string) Option {
+	return func(l *Logger) {
+		for _, f := range fields {
+			l.redactor.fields[f] = struct{}{}
+		}
+	}
+}
+
+// SetRedactedPatterns of regular expressions that are replaced in payloads, panics on invalid expression
+func SetRedactedPatterns(exprs ...string) Option {
+	return func(l *Logger) {
+		l.redactor.patterns = append(l.redactor.patterns, redact.MustCompilePatterns(exprs...)...)
+	}
+}
+
+// SetSampling rate from 0 to 1 of successful calls matched by filter, first registered filter wins,
+// failed calls are always logged, panics on invalid filter
+func SetSampling(filter string, rate float64) Option {
+	return func(l *Logger) {
+		l.samplings = append(l.samplings, sampling{matcher: route.MustCompile(filter), rate: rate})
+	}
+}
+
+// NewLogger of access log that writes entries to out
+func NewLogger(out io.Writer, opts ...Option) *Logger {
+	l := &Logger{
+		out: out,
+		redactor: redactor{
+			metadataKeys: map[string]struct{}{"authorization": {}, "cookie": {}},
+			fields:       map[string]struct{}{},
+		},
+		sample: rand.Float64,
+		now:    time.Now,
+	}
+
+	for _, o := range opts {
+		o(l)
+	}
+
+	return l
+}
+
+// Middleware that logs unary calls
+func (l *Logger) Middleware() brokkrgrpc.Middleware {
+	return func(handler brokkrgrpc.RequestHandler) brokkrgrpc.RequestHandler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			startedAt := l.now()
+			resp, err := handler(ctx, req)
+
+			e := l.newEntry(ctx, startedAt, err)
+			if !l.isSampled(e) {
+				return resp, err
+			}
+
+			e.RequestSize, e.ResponseSize = size(req), size(resp)
+			if l.isPayload {
+				e.Request, e.Response = l.redactor.payload(req), l.redactor.payload(resp)
+			}
+
+			l.write(e)
+
+			return resp, err
+		}
+	}
+}
+
+// StreamMiddleware that logs streams when they are finished, sizes are sums of all messages
+func (l *Logger) StreamMiddleware() brokkrgrpc.StreamMiddleware {
+	return func(handler brokkrgrpc.StreamHandler) brokkrgrpc.StreamHandler {
+		return func(srv interface{}, stream grpc.ServerStream) error {
+			startedAt := l.now()
+
+			var recvSize, sendSize int
+			err := brokkrgrpc.InterceptStreamMessages(
+				func(_ context.Context, msg interface{}) error {
+					recvSize += size(msg)
+					return nil
+				},
+				func(_ context.Context, msg interface{}) error {
+					sendSize += size(msg)
+					return nil
+				},
+			)(handler)(srv, stream)
+
+			if e := l.newEntry(stream.Context(), startedAt, err); l.isSampled(e) {
+				e.RequestSize, e.ResponseSize = recvSize, sendSize
+				l.write(e)
+			}
+
+			return err
+		}
+	}
+}
+
+// newEntry of finished call
+func (l *Logger) newEntry(ctx context.Context, startedAt time.Time, err error) Entry {
+	ctxMeta, _ := brokkrgrpc.GetContextMetadata(ctx)
+	s := status.Convert(err)
+
+	e := Entry{
+		Time:      startedAt,
+		Method:    ctxMeta.FullMethod,
+		Code:      s.Code().String(),
+		LatencyMS: float64(l.now().Sub(startedAt)) / float64(time.Millisecond),
+	}
+
+	if err != nil {
+		e.Error = s.Message()
+	}
+
+	if ctxMeta.Peer != nil {
+		e.Peer = ctxMeta.Peer.Address
+	}
+
+	for _, key := range l.metadataKeys {
+		if values := ctxMeta.Meta.Get(key); len(values) > 0 {
+			if e.Metadata == nil {
+				e.Metadata = map[string][]string{}
+			}
+
+			e.Metadata[key] = l.redactor.metadataValues(key, values)
+		}
+	}
+
+	return e
+}
+
+// isSampled entry of the call, failed calls are always sampled
+func (l *Logger) isSampled(e Entry) bool {
+	if e.Code != codes.OK.String() {
+		return true
+	}
+
+	for _, s := range l.samplings {
+		if s.matcher.Match(e.Method) {
+			return s.rate >= 1 || l.sample() < s.rate
+		}
+	}
+
+	return true
+}
+
+// write entry as JSON line
+func (l *Logger) write(e Entry) {
+	raw, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	_, _ = l.out.Write(append(raw, '\n'))
+}
+
+// size of proto message in wire format
+func size(msg interface{}) int {
+	if m, isProto := msg.(proto.Message); isProto && m != nil {
+		return proto.Size(m)
+	}
+
+	return 0
+}
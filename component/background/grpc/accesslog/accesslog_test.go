@@ -0,0 +1,145 @@
+package accesslog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	brokkrgrpc "github.com/Clink-n-Clank/Brokkr/component/background/grpc"
+)
+
+type testingServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s testingServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s testingServerStream) RecvMsg(m interface{}) error {
+	m.(*errdetails.ErrorInfo).Reason = "RECEIVED"
+	return nil
+}
+
+func (s testingServerStream) SendMsg(interface{}) error {
+	return nil
+}
+
+func testingContext(fullMethod string) context.Context {
+	return brokkrgrpc.NewMiddlewareComposer().ExtendContext(context.Background(), brokkrgrpc.RequestContextMetadata{
+		FullMethod: fullMethod,
+		Meta:       metadata.Pairs("authorization", "Bearer secret", "x-request-id", "r-1", "x-tenant", "acme"),
+		Peer:       &brokkrgrpc.PeerIdentity{Address: "10.0.0.1:5000"},
+	})
+}
+
+func readEntries(t *testing.T, buf *bytes.Buffer) []Entry {
+	var entries []Entry
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+
+		var e Entry
+		assert.NoError(t, json.Unmarshal([]byte(line), &e))
+		entries = append(entries, e)
+	}
+
+	return entries
+}
+
+func TestLogger_Middleware(t *testing.T) {
+	buf := &bytes.Buffer{}
+	l := NewLogger(buf,
+		SetMetadataKeys("Authorization", "x-request-id"),
+		SetPayloads(true),
+		SetRedactedFields("domain", "field_violations.description"),
+		SetRedactedPatterns(`\d{4}-\d{4}-\d{4}-\d{4}`),
+	)
+
+	req := &errdetails.BadRequest{FieldViolations: []*errdetails.BadRequest_FieldViolation{
+		{Field: "card", Description: "card 1234-5678-9012-3456 is expired"},
+	}}
+	resp := &errdetails.ErrorInfo{Reason: "card 1234-5678-9012-3456", Domain: "payments.example.com"}
+
+	_, err := l.Middleware()(func(ctx context.Context, r interface{}) (interface{}, error) {
+		return resp, nil
+	})(testingContext("/svc.Payments/Pay"), req)
+	assert.NoError(t, err)
+
+	entries := readEntries(t, buf)
+	assert.Len(t, entries, 1)
+
+	e := entries[0]
+	assert.Equal(t, "/svc.Payments/Pay", e.Method)
+	assert.Equal(t, "10.0.0.1:5000", e.Peer)
+	assert.Equal(t, "OK", e.Code)
+	assert.Equal(t, map[string][]string{"authorization": {"<sensitive>"}, "x-request-id": {"r-1"}}, e.Metadata)
+	assert.Greater(t, e.RequestSize, 0)
+	assert.Greater(t, e.ResponseSize, 0)
+	assert.JSONEq(t, `{"field_violations":[{"field":"card","description":"<sensitive>"}]}`, string(e.Request))
+	assert.JSONEq(t, `{"reason":"card <sensitive>","domain":"<sensitive>"}`, string(e.Response))
+
+	// Original messages are not changed
+	assert.Equal(t, "payments.example.com", resp.Domain)
+}
+
+func TestLogger_Sampling(t *testing.T) {
+	buf := &bytes.Buffer{}
+	l := NewLogger(buf, SetSampling("/svc.Hot/*", 0), SetSampling("*", 1))
+
+	call := func(fullMethod string, err error) {
+		_, _ = l.Middleware()(func(ctx context.Context, r interface{}) (interface{}, error) {
+			return nil, err
+		})(testingContext(fullMethod), nil)
+	}
+
+	call("/svc.Hot/Get", nil)
+	call("/svc.Hot/Get", status.Error(codes.Internal, "boom"))
+	call("/svc.Cold/Get", nil)
+
+	entries := readEntries(t, buf)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "Internal", entries[0].Code)
+	assert.Equal(t, "boom", entries[0].Error)
+	assert.Nil(t, entries[0].Request)
+	assert.Equal(t, "/svc.Cold/Get", entries[1].Method)
+}
+
+func TestLogger_StreamMiddleware(t *testing.T) {
+	buf := &bytes.Buffer{}
+	now := time.Unix(1700000000, 0)
+	l := NewLogger(buf)
+	l.now = func() time.Time {
+		now = now.Add(5 * time.Millisecond)
+		return now
+	}
+
+	err := l.StreamMiddleware()(func(srv interface{}, stream grpc.ServerStream) error {
+		msg := &errdetails.ErrorInfo{}
+		_ = stream.RecvMsg(msg)
+		_ = stream.SendMsg(msg)
+
+		return status.Error(codes.Canceled, "client is gone")
+	})(nil, testingServerStream{ctx: testingContext("/svc.Orders/Watch")})
+	assert.Equal(t, codes.Canceled, status.Code(err))
+
+	entries := readEntries(t, buf)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "Canceled", entries[0].Code)
+	assert.Equal(t, float64(5), entries[0].LatencyMS)
+	assert.Equal(t, entries[0].RequestSize, entries[0].ResponseSize)
+	assert.Greater(t, entries[0].RequestSize, 0)
+	assert.Nil(t, entries[0].Metadata)
+}
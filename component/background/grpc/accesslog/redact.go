@@ -0,0 +1,106 @@
+package accesslog
+
+import (
+	"encoding/json"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/Clink-n-Clank/Brokkr/component/redact"
+)
+
+// redactor of metadata and payloads
+type redactor struct {
+	metadataKeys map[string]struct{}
+	fields       map[string]struct{}
+	patterns     redact.Patterns
+}
+
+// metadataValues of the key, sensitive keys are redacted
+func (r *redactor) metadataValues(key string, values []string) []string {
+	if _, isSensitive := r.metadataKeys[key]; !isSensitive {
+		return values
+	}
+
+	redacted := make([]string, len(values))
+	for i := range values {
+		redacted[i] = redact.Value
+	}
+
+	return redacted
+}
+
+// payload of the message as JSON, sensitive fields and patterns are redacted
+func (r *redactor) payload(msg interface{}) json.RawMessage {
+	m, isProto := msg.(proto.Message)
+	if !isProto || m == nil {
+		return nil
+	}
+
+	if len(r.fields) > 0 {
+		m = proto.Clone(m)
+		r.redactMessage(m.ProtoReflect(), "")
+	}
+
+	raw, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(m)
+	if err != nil {
+		return nil
+	}
+
+	s := r.patterns.String(string(raw))
+
+	// Patterns could break JSON, so payload is kept as string in that case
+	if !json.Valid([]byte(s)) {
+		quoted, _ := json.Marshal(s)
+		return quoted
+	}
+
+	return json.RawMessage(s)
+}
+
+// redactMessage fields matched by name or by path like "user.password", strings are replaced and other fields are cleared
+func (r *redactor) redactMessage(m protoreflect.Message, prefix string) {
+	var sensitive []protoreflect.FieldDescriptor
+
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		path := prefix + string(fd.Name())
+
+		if r.isSensitiveField(string(fd.Name()), path) {
+			sensitive = append(sensitive, fd)
+			return true
+		}
+
+		switch {
+		case fd.IsList() && fd.Message() != nil:
+			for i := 0; i < v.List().Len(); i++ {
+				r.redactMessage(v.List().Get(i).Message(), path+".")
+			}
+		case fd.IsMap() && fd.MapValue().Message() != nil:
+			v.Map().Range(func(_ protoreflect.MapKey, mv protoreflect.Value) bool {
+				r.redactMessage(mv.Message(), path+".")
+				return true
+			})
+		case !fd.IsList() && !fd.IsMap() && fd.Message() != nil:
+			r.redactMessage(v.Message(), path+".")
+		}
+
+		return true
+	})
+
+	// Message is not changed during iteration
+	for _, fd := range sensitive {
+		if fd.Kind() == protoreflect.StringKind && !fd.IsList() && !fd.IsMap() {
+			m.Set(fd, protoreflect.ValueOfString(redact.Value))
+		} else {
+			m.Clear(fd)
+		}
+	}
+}
+
+func (r *redactor) isSensitiveField(name, path string) bool {
+	_, isPathSensitive := r.fields[path]
+	_, isNameSensitive := r.fields[name]
+
+	return isPathSensitive || isNameSensitive
+}
@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+)
+
+// ErrInvalidAPIKey is returned when API key is not known
+var ErrInvalidAPIKey = errors.New("invalid API key")
+
+type (
+	// APIKeyValidator authenticates requests by static API keys from metadata
+	APIKeyValidator struct {
+		header string
+		// keys hashed to compare them in constant time, value is subject of the key
+		keys map[[sha256.Size]byte]string
+	}
+
+	// APIKeyOption for validator configuration
+	APIKeyOption func(v *APIKeyValidator)
+)
+
+// SetAPIKeyHeader metadata key with API key, x-api-key is used by default
+func SetAPIKeyHeader(header string) APIKeyOption {
+	return func(v *APIKeyValidator) {
+		v.header = header
+	}
+}
+
+// NewAPIKeyValidator with keys where value is subject of the principal, for example name of the client
+func NewAPIKeyValidator(keys map[string]string, opts ...APIKeyOption) *APIKeyValidator {
+	v := &APIKeyValidator{
+		header: "x-api-key",
+		keys:   make(map[[sha256.Size]byte]string, len(keys)),
+	}
+
+	for _, o := range opts {
+		o(v)
+	}
+
+	for key, subject := range keys {
+		v.keys[sha256.Sum256([]byte(key))] = subject
+	}
+
+	return v
+}
+
+// Authenticate request by API key
+func (v *APIKeyValidator) Authenticate(ctx context.Context) (Principal, error) {
+	key, err := credentialsFromMetadata(ctx, v.header, "")
+	if err != nil {
+		return Principal{}, err
+	}
+
+	subject, isValid := v.Validate(key)
+	if !isValid {
+		return Principal{}, ErrInvalidAPIKey
+	}
+
+	return Principal{Subject: subject, Method: MethodAPIKey}, nil
+}
+
+// Validate API key, returns subject of the key
+func (v *APIKeyValidator) Validate(key string) (string, bool) {
+	hash := sha256.Sum256([]byte(key))
+
+	// All keys are compared, so time doesn't depend on position of the key
+	var subject string
+	var isValid bool
+	for known, s := range v.keys {
+		if subtle.ConstantTimeCompare(hash[:], known[:]) == 1 {
+			subject, isValid = s, true
+		}
+	}
+
+	return subject, isValid
+}
@@ -0,0 +1,39 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	brokkrgrpc "github.com/Clink-n-Clank/Brokkr/component/background/grpc"
+)
+
+func TestAPIKeyMiddleware(t *testing.T) {
+	v := NewAPIKeyValidator(map[string]string{"secret-1": "billing", "secret-2": "reports"}, SetAPIKeyHeader("x-key"))
+
+	var principal Principal
+	handler := Middleware(v)(func(ctx context.Context, req interface{}) (interface{}, error) {
+		principal, _ = PrincipalFromContext(ctx)
+		return req, nil
+	})
+
+	mc := brokkrgrpc.NewMiddlewareComposer()
+	ctx := mc.ExtendContext(context.Background(), brokkrgrpc.RequestContextMetadata{Meta: metadata.Pairs("x-key", "secret-2")})
+
+	_, err := handler(ctx, "req")
+	assert.NoError(t, err)
+	assert.Equal(t, Principal{Subject: "reports", Method: MethodAPIKey}, principal)
+
+	ctx = mc.ExtendContext(context.Background(), brokkrgrpc.RequestContextMetadata{Meta: metadata.Pairs("x-key", "unknown")})
+	_, err = handler(ctx, "req")
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	assert.Contains(t, err.Error(), ErrInvalidAPIKey.Error())
+
+	_, err = handler(context.Background(), "req")
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	assert.Contains(t, err.Error(), ErrMissingCredentials.Error())
+}
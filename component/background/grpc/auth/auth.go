@@ -0,0 +1,112 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	brokkrgrpc "github.com/Clink-n-Clank/Brokkr/component/background/grpc"
+	brokkrctx "github.com/Clink-n-Clank/Brokkr/component/context"
+)
+
+var (
+	// ErrMissingCredentials is returned when request has no credentials in metadata
+	ErrMissingCredentials = errors.New("missing credentials")
+)
+
+const (
+	// MethodJWT of authentication with JSON Web Token
+	MethodJWT = "jwt"
+	// MethodAPIKey of authentication with static API key
+	MethodAPIKey = "api_key"
+)
+
+type (
+	// Principal that is authenticated by middleware
+	Principal struct {
+		// Subject identity of the principal, sub claim of JWT or name of API key
+		Subject string
+		// Method of authentication
+		Method string
+		// Claims of JWT, empty for API keys
+		Claims Claims
+	}
+
+	// Authenticator verifies credentials of the request and returns authenticated principal
+	Authenticator interface {
+		Authenticate(ctx context.Context) (Principal, error)
+	}
+
+	principalContextKey struct{}
+)
+
+// WithPrincipal extends context with authenticated principal
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return brokkrctx.ExtendedContextWithMetadata(ctx, principalContextKey{}, p)
+}
+
+// PrincipalFromContext that was authenticated by middleware
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	return brokkrctx.GetContextMetadata[Principal](ctx, principalContextKey{})
+}
+
+// Middleware that authenticates unary request, failed authentication returns Unauthenticated status
+func Middleware(a Authenticator) brokkrgrpc.Middleware {
+	return func(handler brokkrgrpc.RequestHandler) brokkrgrpc.RequestHandler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			p, err := a.Authenticate(ctx)
+			if err != nil {
+				return nil, status.Error(codes.Unauthenticated, err.Error())
+			}
+
+			return handler(WithPrincipal(ctx, p), req)
+		}
+	}
+}
+
+// StreamMiddleware that authenticates streaming request, failed authentication returns Unauthenticated status
+func StreamMiddleware(a Authenticator) brokkrgrpc.StreamMiddleware {
+	return func(handler brokkrgrpc.StreamHandler) brokkrgrpc.StreamHandler {
+		return func(srv interface{}, stream grpc.ServerStream) error {
+			p, err := a.Authenticate(stream.Context())
+			if err != nil {
+				return status.Error(codes.Unauthenticated, err.Error())
+			}
+
+			return handler(srv, brokkrgrpc.WrapServerStream(WithPrincipal(stream.Context(), p), stream))
+		}
+	}
+}
+
+// credentialsFromMetadata by key, scheme prefix like "Bearer" is removed if set
+func credentialsFromMetadata(ctx context.Context, key, scheme string) (string, error) {
+	md, isExist := metadata.FromIncomingContext(ctx)
+	if ctxMeta, isCtxMetaExist := brokkrgrpc.GetContextMetadata(ctx); isCtxMetaExist && ctxMeta.Meta != nil {
+		md, isExist = ctxMeta.Meta, true
+	}
+
+	if !isExist {
+		return "", ErrMissingCredentials
+	}
+
+	values := md.Get(key)
+	if len(values) == 0 || values[0] == "" {
+		return "", ErrMissingCredentials
+	}
+
+	if scheme == "" {
+		return values[0], nil
+	}
+
+	prefix, value, hasPrefix := strings.Cut(values[0], " ")
+	if !hasPrefix || !strings.EqualFold(prefix, scheme) || value == "" {
+		return "", ErrMissingCredentials
+	}
+
+	return value, nil
+}
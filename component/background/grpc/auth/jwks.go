@@ -0,0 +1,136 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+)
+
+// ErrInvalidJWKS is returned when JWKS file can't be parsed
+var ErrInvalidJWKS = errors.New("invalid JWKS")
+
+type (
+	// jwks JSON Web Key Set from RFC 7517
+	jwks struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+
+	// jsonWebKey with fields of RSA, EC and symmetric keys
+	jsonWebKey struct {
+		Kty string `json:"kty"`
+		Kid string `json:"kid"`
+		Alg string `json:"alg"`
+		Use string `json:"use"`
+		// RSA public key
+		N string `json:"n"`
+		E string `json:"e"`
+		// EC public key
+		Crv string `json:"crv"`
+		X   string `json:"x"`
+		Y   string `json:"y"`
+		// Symmetric key
+		K string `json:"k"`
+	}
+
+	// verificationKey parsed from JWKS, key is *rsa.PublicKey, *ecdsa.PublicKey or []byte
+	verificationKey struct {
+		alg string
+		key any
+	}
+)
+
+// loadJWKSFile of verification keys by key ID
+func loadJWKSFile(path string) (map[string]verificationKey, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidJWKS, err)
+	}
+
+	var set jwks
+	if err = json.Unmarshal(raw, &set); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidJWKS, err)
+	}
+
+	keys := make(map[string]verificationKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+
+		vk, err := k.verificationKey()
+		if err != nil {
+			return nil, fmt.Errorf("%w: key %q: %w", ErrInvalidJWKS, k.Kid, err)
+		}
+
+		if k.Alg != "" && k.Alg != vk.alg {
+			return nil, fmt.Errorf("%w: key %q: unsupported algorithm %s", ErrInvalidJWKS, k.Kid, k.Alg)
+		}
+
+		keys[k.Kid] = vk
+	}
+
+	return keys, nil
+}
+
+// verificationKey with algorithm that matches type of the key
+func (k jsonWebKey) verificationKey() (verificationKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return verificationKey{}, err
+		}
+
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return verificationKey{}, err
+		}
+
+		return verificationKey{alg: algRS256, key: &rsa.PublicKey{N: n, E: int(e.Int64())}}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return verificationKey{}, fmt.Errorf("unsupported curve %s", k.Crv)
+		}
+
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return verificationKey{}, err
+		}
+
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return verificationKey{}, err
+		}
+
+		pub := &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}
+		if !pub.Curve.IsOnCurve(x, y) {
+			return verificationKey{}, errors.New("point is not on curve")
+		}
+
+		return verificationKey{alg: algES256, key: pub}, nil
+	case "oct":
+		secret, err := base64.RawURLEncoding.DecodeString(k.K)
+		if err != nil {
+			return verificationKey{}, err
+		}
+
+		return verificationKey{alg: algHS256, key: secret}, nil
+	default:
+		return verificationKey{}, fmt.Errorf("unsupported key type %s", k.Kty)
+	}
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return new(big.Int).SetBytes(b), nil
+}
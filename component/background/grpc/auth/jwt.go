@@ -0,0 +1,341 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	algHS256 = "HS256"
+	algRS256 = "RS256"
+	algES256 = "ES256"
+)
+
+var (
+	// ErrTokenMalformed is returned when token can't be decoded
+	ErrTokenMalformed = errors.New("token is malformed")
+	// ErrTokenSignature is returned when signature of the token is invalid
+	ErrTokenSignature = errors.New("token signature is invalid")
+	// ErrTokenUnknownKey is returned when token is signed with key that is not in JWKS
+	ErrTokenUnknownKey = errors.New("token is signed with unknown key")
+	// ErrTokenExpired is returned when exp claim is in the past
+	ErrTokenExpired = errors.New("token is expired")
+	// ErrTokenNotYetValid is returned when nbf claim is in the future
+	ErrTokenNotYetValid = errors.New("token is not valid yet")
+	// ErrTokenAudience is returned when aud claim doesn't contain expected audience
+	ErrTokenAudience = errors.New("token audience is invalid")
+	// ErrTokenIssuer is returned when iss claim is not expected issuer
+	ErrTokenIssuer = errors.New("token issuer is invalid")
+)
+
+type (
+	// Claims of verified JWT
+	Claims map[string]any
+
+	// JWTVerifier authenticates requests by JWT signed with keys from local JWKS file
+	JWTVerifier struct {
+		jwksPath  string
+		header    string
+		scheme    string
+		audiences []string
+		issuer    string
+		leeway    time.Duration
+		now       func() time.Time
+
+		keys    map[string]verificationKey
+		keysMux sync.RWMutex
+	}
+
+	// JWTOption for verifier configuration
+	JWTOption func(v *JWTVerifier)
+
+	// jwtHeader of the token
+	jwtHeader struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+)
+
+// SetAudience that must be in aud claim, any of given audiences is accepted
+func SetAudience(aud ...string) JWTOption {
+	return func(v *JWTVerifier) {
+		v.audiences = aud
+	}
+}
+
+// SetIssuer that must be in iss claim
+func SetIssuer(iss string) JWTOption {
+	return func(v *JWTVerifier) {
+		v.issuer = iss
+	}
+}
+
+// SetLeeway for exp and nbf checks to tolerate clock skew
+func SetLeeway(d time.Duration) JWTOption {
+	return func(v *JWTVerifier) {
+		v.leeway = d
+	}
+}
+
+// SetTokenHeader metadata key and scheme of the token, "authorization" and "Bearer" are used by default
+func SetTokenHeader(header, scheme string) JWTOption {
+	return func(v *JWTVerifier) {
+		v.header = header
+		v.scheme = scheme
+	}
+}
+
+// SetClock used for exp and nbf checks
+func SetClock(now func() time.Time) JWTOption {
+	return func(v *JWTVerifier) {
+		v.now = now
+	}
+}
+
+// NewJWTVerifier with keys from local JWKS file, HS256, RS256 and ES256 algorithms are supported
+func NewJWTVerifier(jwksPath string, opts ...JWTOption) (*JWTVerifier, error) {
+	v := &JWTVerifier{
+		jwksPath: jwksPath,
+		header:   "authorization",
+		scheme:   "Bearer",
+		now:      time.Now,
+	}
+
+	for _, o := range opts {
+		o(v)
+	}
+
+	if err := v.ReloadKeys(); err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// ReloadKeys from JWKS file, previous keys are kept if file is invalid
+func (v *JWTVerifier) ReloadKeys() error {
+	keys, err := loadJWKSFile(v.jwksPath)
+	if err != nil {
+		return err
+	}
+
+	v.keysMux.Lock()
+	v.keys = keys
+	v.keysMux.Unlock()
+
+	return nil
+}
+
+// Authenticate request by JWT from metadata
+func (v *JWTVerifier) Authenticate(ctx context.Context) (Principal, error) {
+	token, err := credentialsFromMetadata(ctx, v.header, v.scheme)
+	if err != nil {
+		return Principal{}, err
+	}
+
+	claims, err := v.Verify(token)
+	if err != nil {
+		return Principal{}, err
+	}
+
+	return Principal{Subject: claims.Subject(), Method: MethodJWT, Claims: claims}, nil
+}
+
+// Verify signature and registered claims of the token
+func (v *JWTVerifier) Verify(token string) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrTokenMalformed
+	}
+
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, err
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrTokenMalformed, err)
+	}
+
+	key, err := v.key(header)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = verifySignature(key, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, err
+	}
+
+	var claims Claims
+	if err = decodeSegment(parts[1], &claims); err != nil {
+		return nil, err
+	}
+
+	if err = v.verifyClaims(claims); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+// key of the token, algorithm must match type of the key to prevent algorithm confusion
+func (v *JWTVerifier) key(header jwtHeader) (verificationKey, error) {
+	v.keysMux.RLock()
+	defer v.keysMux.RUnlock()
+
+	key, isExist := v.keys[header.Kid]
+	if !isExist && header.Kid == "" && len(v.keys) == 1 {
+		for _, k := range v.keys {
+			key, isExist = k, true
+		}
+	}
+
+	if !isExist {
+		return verificationKey{}, fmt.Errorf("%w: %q", ErrTokenUnknownKey, header.Kid)
+	}
+
+	if key.alg != header.Alg {
+		return verificationKey{}, fmt.Errorf("%w: algorithm %q is not allowed for key", ErrTokenSignature, header.Alg)
+	}
+
+	return key, nil
+}
+
+// verifyClaims exp, nbf, aud and iss
+func (v *JWTVerifier) verifyClaims(claims Claims) error {
+	now := v.now()
+
+	if exp, isExist := claims.numericDate("exp"); isExist && !now.Before(exp.Add(v.leeway)) {
+		return ErrTokenExpired
+	}
+
+	if nbf, isExist := claims.numericDate("nbf"); isExist && now.Add(v.leeway).Before(nbf) {
+		return ErrTokenNotYetValid
+	}
+
+	if v.issuer != "" && claims.Issuer() != v.issuer {
+		return ErrTokenIssuer
+	}
+
+	if len(v.audiences) == 0 {
+		return nil
+	}
+
+	for _, aud := range claims.Audience() {
+		for _, expected := range v.audiences {
+			if aud == expected {
+				return nil
+			}
+		}
+	}
+
+	return ErrTokenAudience
+}
+
+// Subject of the token from sub claim
+func (c Claims) Subject() string {
+	return c.String("sub")
+}
+
+// Issuer of the token from iss claim
+func (c Claims) Issuer() string {
+	return c.String("iss")
+}
+
+// Audience of the token from aud claim, it could be a string or an array of strings
+func (c Claims) Audience() []string {
+	switch aud := c["aud"].(type) {
+	case string:
+		return []string{aud}
+	case []any:
+		list := make([]string, 0, len(aud))
+		for _, a := range aud {
+			if s, isString := a.(string); isString {
+				list = append(list, s)
+			}
+		}
+
+		return list
+	default:
+		return nil
+	}
+}
+
+// String value of the claim, empty if claim is not a string
+func (c Claims) String(name string) string {
+	s, _ := c[name].(string)
+	return s
+}
+
+// numericDate claim like exp or nbf
+func (c Claims) numericDate(name string) (time.Time, bool) {
+	n, isNumber := c[name].(json.Number)
+	if !isNumber {
+		return time.Time{}, false
+	}
+
+	f, err := n.Float64()
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return time.Unix(0, int64(f*float64(time.Second))), true
+}
+
+// decodeSegment of the token from base64url JSON, numbers are kept as json.Number
+func decodeSegment(segment string, v any) error {
+	raw, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrTokenMalformed, err)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	if err = dec.Decode(v); err != nil {
+		return fmt.Errorf("%w: %w", ErrTokenMalformed, err)
+	}
+
+	return nil
+}
+
+// verifySignature of signing input with key
+func verifySignature(key verificationKey, signingInput string, signature []byte) error {
+	hash := sha256.Sum256([]byte(signingInput))
+
+	var isValid bool
+	switch k := key.key.(type) {
+	case []byte:
+		mac := hmac.New(sha256.New, k)
+		mac.Write([]byte(signingInput))
+		isValid = hmac.Equal(signature, mac.Sum(nil))
+	case *rsa.PublicKey:
+		isValid = rsa.VerifyPKCS1v15(k, crypto.SHA256, hash[:], signature) == nil
+	case *ecdsa.PublicKey:
+		// ES256 signature is r and s concatenated, 32 bytes each
+		if len(signature) == 64 {
+			r := new(big.Int).SetBytes(signature[:32])
+			s := new(big.Int).SetBytes(signature[32:])
+			isValid = ecdsa.Verify(k, hash[:], r, s)
+		}
+	}
+
+	if !isValid {
+		return ErrTokenSignature
+	}
+
+	return nil
+}
@@ -0,0 +1,194 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type testingKeys struct {
+	rsa    *rsa.PrivateKey
+	ec     *ecdsa.PrivateKey
+	secret []byte
+}
+
+func TestJWTVerifierAlgorithms(t *testing.T) {
+	keys, jwksPath := newTestingJWKS(t)
+	v, err := NewJWTVerifier(jwksPath)
+	assert.NoError(t, err)
+
+	claims := map[string]any{"sub": "unit", "exp": time.Now().Add(time.Hour).Unix()}
+	for _, kid := range []string{"rsa", "ec", "hmac"} {
+		verified, err := v.Verify(keys.sign(t, kid, claims))
+		assert.NoError(t, err, kid)
+		assert.Equal(t, "unit", verified.Subject())
+	}
+
+	token := keys.sign(t, "rsa", claims)
+	_, err = v.Verify(token[:len(token)-4] + "AAAA")
+	assert.ErrorIs(t, err, ErrTokenSignature)
+
+	_, err = v.Verify(keys.sign(t, "unknown", claims))
+	assert.ErrorIs(t, err, ErrTokenUnknownKey)
+
+	_, err = v.Verify("not-a-token")
+	assert.ErrorIs(t, err, ErrTokenMalformed)
+}
+
+func TestJWTVerifierRejectsAlgorithmConfusion(t *testing.T) {
+	keys, jwksPath := newTestingJWKS(t)
+	v, err := NewJWTVerifier(jwksPath)
+	assert.NoError(t, err)
+
+	// HMAC signature with kid of RSA key must not be accepted
+	token := testingToken(t, map[string]any{"alg": "HS256", "kid": "rsa"}, map[string]any{"sub": "unit"}, func(input string) []byte {
+		mac := hmac.New(sha256.New, keys.secret)
+		mac.Write([]byte(input))
+		return mac.Sum(nil)
+	})
+
+	_, err = v.Verify(token)
+	assert.ErrorIs(t, err, ErrTokenSignature)
+}
+
+func TestJWTVerifierClaims(t *testing.T) {
+	keys, jwksPath := newTestingJWKS(t)
+	now := time.Now()
+
+	v, err := NewJWTVerifier(jwksPath, SetAudience("api"), SetIssuer("brokkr"), SetLeeway(time.Second), SetClock(func() time.Time { return now }))
+	assert.NoError(t, err)
+
+	valid := map[string]any{"sub": "unit", "iss": "brokkr", "aud": []string{"other", "api"}, "exp": now.Add(time.Minute).Unix()}
+	_, err = v.Verify(keys.sign(t, "ec", valid))
+	assert.NoError(t, err)
+
+	tests := []struct {
+		claims map[string]any
+		err    error
+	}{
+		{map[string]any{"iss": "brokkr", "aud": "api", "exp": now.Add(-time.Minute).Unix()}, ErrTokenExpired},
+		{map[string]any{"iss": "brokkr", "aud": "api", "nbf": now.Add(time.Minute).Unix()}, ErrTokenNotYetValid},
+		{map[string]any{"iss": "brokkr", "aud": "web"}, ErrTokenAudience},
+		{map[string]any{"iss": "other", "aud": "api"}, ErrTokenIssuer},
+	}
+
+	for _, tt := range tests {
+		_, err = v.Verify(keys.sign(t, "ec", tt.claims))
+		assert.ErrorIs(t, err, tt.err)
+	}
+}
+
+func TestJWTMiddleware(t *testing.T) {
+	keys, jwksPath := newTestingJWKS(t)
+	v, err := NewJWTVerifier(jwksPath)
+	assert.NoError(t, err)
+
+	var principal Principal
+	handler := Middleware(v)(func(ctx context.Context, req interface{}) (interface{}, error) {
+		principal, _ = PrincipalFromContext(ctx)
+		return req, nil
+	})
+
+	token := keys.sign(t, "rsa", map[string]any{"sub": "unit", "role": "admin"})
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+token))
+
+	_, err = handler(ctx, "req")
+	assert.NoError(t, err)
+	assert.Equal(t, "unit", principal.Subject)
+	assert.Equal(t, MethodJWT, principal.Method)
+	assert.Equal(t, "admin", principal.Claims.String("role"))
+
+	_, err = handler(context.Background(), "req")
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	ctx = metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Basic "+token))
+	_, err = handler(ctx, "req")
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestNewJWTVerifierWithInvalidJWKS(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	assert.NoError(t, os.WriteFile(path, []byte(`{"keys":[{"kty":"EC","crv":"P-521"}]}`), 0o600))
+
+	_, err := NewJWTVerifier(path)
+	assert.ErrorIs(t, err, ErrInvalidJWKS)
+}
+
+func newTestingJWKS(t *testing.T) (*testingKeys, string) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	keys := &testingKeys{rsa: rsaKey, ec: ecKey, secret: []byte("unit-secret")}
+	b64 := base64.RawURLEncoding.EncodeToString
+
+	set := map[string]any{"keys": []map[string]string{
+		{"kty": "RSA", "kid": "rsa", "alg": "RS256", "n": b64(rsaKey.N.Bytes()), "e": b64(big.NewInt(int64(rsaKey.E)).Bytes())},
+		{"kty": "EC", "kid": "ec", "crv": "P-256", "x": b64(ecKey.X.FillBytes(make([]byte, 32))), "y": b64(ecKey.Y.FillBytes(make([]byte, 32)))},
+		{"kty": "oct", "kid": "hmac", "k": b64(keys.secret)},
+	}}
+
+	raw, err := json.Marshal(set)
+	assert.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	assert.NoError(t, os.WriteFile(path, raw, 0o600))
+
+	return keys, path
+}
+
+func (k *testingKeys) sign(t *testing.T, kid string, claims map[string]any) string {
+	switch kid {
+	case "ec":
+		return testingToken(t, map[string]any{"alg": "ES256", "kid": kid}, claims, func(input string) []byte {
+			hash := sha256.Sum256([]byte(input))
+			r, s, err := ecdsa.Sign(rand.Reader, k.ec, hash[:])
+			assert.NoError(t, err)
+
+			return append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+		})
+	case "hmac":
+		return testingToken(t, map[string]any{"alg": "HS256", "kid": kid}, claims, func(input string) []byte {
+			mac := hmac.New(sha256.New, k.secret)
+			mac.Write([]byte(input))
+			return mac.Sum(nil)
+		})
+	default:
+		return testingToken(t, map[string]any{"alg": "RS256", "kid": kid}, claims, func(input string) []byte {
+			hash := sha256.Sum256([]byte(input))
+			sig, err := rsa.SignPKCS1v15(rand.Reader, k.rsa, crypto.SHA256, hash[:])
+			assert.NoError(t, err)
+
+			return sig
+		})
+	}
+}
+
+func testingToken(t *testing.T, header, claims map[string]any, sign func(input string) []byte) string {
+	h, err := json.Marshal(header)
+	assert.NoError(t, err)
+	c, err := json.Marshal(claims)
+	assert.NoError(t, err)
+
+	input := base64.RawURLEncoding.EncodeToString(h) + "." + base64.RawURLEncoding.EncodeToString(c)
+
+	return input + "." + base64.RawURLEncoding.EncodeToString(sign(input))
+}
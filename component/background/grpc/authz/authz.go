@@ -0,0 +1,246 @@
+package authz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	brokkrgrpc "github.com/Clink-n-Clank/Brokkr/component/background/grpc"
+	"github.com/Clink-n-Clank/Brokkr/component/background/grpc/auth"
+)
+
+var (
+	// ErrNoRule is returned when there is no rule for method, methods are denied by default
+	ErrNoRule = errors.New("no authorization rule for method")
+	// ErrUnauthenticated is returned when method is not public and request has no principal
+	ErrUnauthenticated = errors.New("principal is required")
+	// ErrDenied is returned when principal doesn't satisfy rule
+	ErrDenied = errors.New("access denied")
+)
+
+type (
+	// Decision of authorization
+	Decision struct {
+		FullMethod string
+		Principal  auth.Principal
+		// Rule method filter of applied rule, empty if there is no rule
+		Rule      string
+		IsAllowed bool
+		// IsEnforced false in audit-only mode
+		IsEnforced bool
+		Err        error
+	}
+
+	// Authorizer checks access to methods by policy file, policy is reloaded when file is changed
+	Authorizer struct {
+		path           string
+		reloadInterval time.Duration
+		isAuditOnly    bool
+		onDecision     func(d Decision)
+		onReloadError  func(err error)
+		auditOut       io.Writer
+
+		state authorizerState
+	}
+
+	// authorizerState of loaded policy
+	authorizerState struct {
+		policy        *Policy
+		modTime       time.Time
+		lastCheckedAt time.Time
+
+		sync.Mutex
+	}
+
+	// Option for authorizer configuration
+	Option func(a *Authorizer)
+)
+
+// SetReloadInterval how often policy file is checked for changes
+func SetReloadInterval(interval time.Duration) Option {
+	return func(a *Authorizer) {
+		a.reloadInterval = interval
+	}
+}
+
+// SetAuditOnly mode, denials are reported to decision handler but requests are not rejected,
+// denials are written to audit output if decision handler is not set
+func SetAuditOnly(isAuditOnly bool) Option {
+	return func(a *Authorizer) {
+		a.isAuditOnly = isAuditOnly
+	}
+}
+
+// SetAuditOutput of would-be denials in audit-only mode without decision handler, os.Stderr by default
+func SetAuditOutput(w io.Writer) Option {
+	return func(a *Authorizer) {
+		a.auditOut = w
+	}
+}
+
+// SetDecisionHandler called for each denial, it can be used for audit logs
+func SetDecisionHandler(h func(d Decision)) Option {
+	return func(a *Authorizer) {
+		a.onDecision = h
+	}
+}
+
+// SetReloadErrorHandler called when changed policy file is invalid, previous policy is kept
+func SetReloadErrorHandler(h func(err error)) Option {
+	return func(a *Authorizer) {
+		a.onReloadError = h
+	}
+}
+
+// NewAuthorizer with policy file
+func NewAuthorizer(path string, opts ...Option) (*Authorizer, error) {
+	a := &Authorizer{
+		path:           path,
+		reloadInterval: 10 * time.Second,
+		onReloadError:  func(error) {},
+		auditOut:       os.Stderr,
+	}
+
+	for _, o := range opts {
+		o(a)
+	}
+
+	if a.onDecision == nil {
+		a.onDecision = func(Decision) {}
+		if a.isAuditOnly {
+			a.onDecision = a.writeDecision
+		}
+	}
+
+	a.state.Lock()
+	defer a.state.Unlock()
+
+	if err := a.reload(); err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// Authorize principal from context to call full method
+func (a *Authorizer) Authorize(ctx context.Context, fullMethod string) error {
+	principal, isAuthenticated := auth.PrincipalFromContext(ctx)
+	d := Decision{FullMethod: fullMethod, Principal: principal, IsEnforced: !a.isAuditOnly}
+
+	p := a.policy()
+	r, isRuleExist := p.rule(fullMethod)
+	switch {
+	case !isRuleExist:
+		d.Err = fmt.Errorf("%w: %s", ErrNoRule, fullMethod)
+	case r.Public:
+		d.Rule, d.IsAllowed = r.Method, true
+	case !isAuthenticated:
+		d.Rule, d.Err = r.Method, ErrUnauthenticated
+	default:
+		d.Rule = r.Method
+		if reason, isAllowed := p.check(r, principal); isAllowed {
+			d.IsAllowed = true
+		} else {
+			d.Err = fmt.Errorf("%w: %s", ErrDenied, reason)
+		}
+	}
+
+	if d.IsAllowed {
+		return nil
+	}
+
+	a.onDecision(d)
+	if a.isAuditOnly {
+		return nil
+	}
+
+	return d.Err
+}
+
+// writeDecision of audit-only mode, so would-be denials are visible without decision handler
+func (a *Authorizer) writeDecision(d Decision) {
+	_, _ = fmt.Fprintf(a.auditOut, "authz audit: %s would be denied for %q: %v\n", d.FullMethod, d.Principal.Subject, d.Err)
+}
+
+// Middleware that authorizes unary request, it must be executed after authentication middleware
+func Middleware(a *Authorizer) brokkrgrpc.Middleware {
+	return func(handler brokkrgrpc.RequestHandler) brokkrgrpc.RequestHandler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			ctxMeta, _ := brokkrgrpc.GetContextMetadata(ctx)
+			if err := a.Authorize(ctx, ctxMeta.FullMethod); err != nil {
+				return nil, statusFromError(err)
+			}
+
+			return handler(ctx, req)
+		}
+	}
+}
+
+// StreamMiddleware that authorizes streaming request, it must be executed after authentication middleware
+func StreamMiddleware(a *Authorizer) brokkrgrpc.StreamMiddleware {
+	return func(handler brokkrgrpc.StreamHandler) brokkrgrpc.StreamHandler {
+		return func(srv interface{}, stream grpc.ServerStream) error {
+			ctxMeta, _ := brokkrgrpc.GetContextMetadata(stream.Context())
+			if err := a.Authorize(stream.Context(), ctxMeta.FullMethod); err != nil {
+				return statusFromError(err)
+			}
+
+			return handler(srv, stream)
+		}
+	}
+}
+
+// policy that is reloaded if file is changed
+func (a *Authorizer) policy() *Policy {
+	a.state.Lock()
+	defer a.state.Unlock()
+
+	if time.Since(a.state.lastCheckedAt) >= a.reloadInterval {
+		if err := a.reload(); err != nil {
+			a.onReloadError(err)
+		}
+	}
+
+	return a.state.policy
+}
+
+// reload policy if file modification time is changed
+func (a *Authorizer) reload() error {
+	a.state.lastCheckedAt = time.Now()
+
+	info, err := os.Stat(a.path)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidPolicy, err)
+	}
+
+	if a.state.policy != nil && info.ModTime().Equal(a.state.modTime) {
+		return nil
+	}
+
+	p, err := loadPolicyFile(a.path)
+	if err != nil {
+		return err
+	}
+
+	a.state.policy = p
+	a.state.modTime = info.ModTime()
+
+	return nil
+}
+
+// statusFromError of authorization
+func statusFromError(err error) error {
+	if errors.Is(err, ErrUnauthenticated) {
+		return status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	return status.Error(codes.PermissionDenied, err.Error())
+}
@@ -0,0 +1,160 @@
+package authz
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	brokkrgrpc "github.com/Clink-n-Clank/Brokkr/component/background/grpc"
+	"github.com/Clink-n-Clank/Brokkr/component/background/grpc/auth"
+	"github.com/Clink-n-Clank/Brokkr/component/background/route"
+)
+
+const testingPolicy = `{
+  "rules": [
+    {"method": "/grpc.health.v1.Health/*", "public": true},
+    {"method": "/svc.Admin/Drop", "deny": true},
+    {"method": "/svc.Admin/*", "roles": ["admin"]},
+    {"method": "/svc.Orders/*", "scopes": ["orders:read"], "claims": ["tenant == \"acme\"", "groups contains ops", "level == 2"]}
+  ]
+}`
+
+func TestAuthorize(t *testing.T) {
+	a, err := NewAuthorizer(writeTestingPolicy(t, t.TempDir(), testingPolicy))
+	assert.NoError(t, err)
+
+	admin := testingPrincipalContext(`{"sub":"root","roles":["admin"]}`)
+	orders := testingPrincipalContext(`{"sub":"u","scope":"orders:read orders:write","tenant":"acme","groups":["ops"],"level":2}`)
+	otherTenant := testingPrincipalContext(`{"sub":"u","scope":"orders:read","tenant":"other","groups":["ops"],"level":2}`)
+
+	tests := []struct {
+		ctx        context.Context
+		fullMethod string
+		err        error
+	}{
+		{context.Background(), "/grpc.health.v1.Health/Check", nil},
+		{context.Background(), "/svc.Admin/List", ErrUnauthenticated},
+		{admin, "/svc.Admin/List", nil},
+		{admin, "/svc.Admin/Drop", ErrDenied},
+		{orders, "/svc.Admin/List", ErrDenied},
+		{orders, "/svc.Orders/Get", nil},
+		{otherTenant, "/svc.Orders/Get", ErrDenied},
+		{admin, "/svc.Unknown/Get", ErrNoRule},
+	}
+
+	for _, tt := range tests {
+		assert.ErrorIs(t, a.Authorize(tt.ctx, tt.fullMethod), tt.err, tt.fullMethod)
+	}
+}
+
+func TestMiddlewareStatusCodes(t *testing.T) {
+	a, err := NewAuthorizer(writeTestingPolicy(t, t.TempDir(), testingPolicy))
+	assert.NoError(t, err)
+
+	handler := Middleware(a)(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return req, nil
+	})
+	mc := brokkrgrpc.NewMiddlewareComposer()
+
+	ctx := mc.ExtendContext(context.Background(), brokkrgrpc.RequestContextMetadata{FullMethod: "/svc.Admin/List"})
+	_, err = handler(ctx, nil)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	ctx = mc.ExtendContext(testingPrincipalContext(`{"roles":"user"}`), brokkrgrpc.RequestContextMetadata{FullMethod: "/svc.Admin/List"})
+	_, err = handler(ctx, nil)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+func TestAuditOnlyMode(t *testing.T) {
+	var decisions []Decision
+	a, err := NewAuthorizer(
+		writeTestingPolicy(t, t.TempDir(), testingPolicy),
+		SetAuditOnly(true),
+		SetDecisionHandler(func(d Decision) { decisions = append(decisions, d) }),
+	)
+	assert.NoError(t, err)
+
+	assert.NoError(t, a.Authorize(context.Background(), "/svc.Admin/List"))
+	assert.Len(t, decisions, 1)
+	assert.False(t, decisions[0].IsAllowed)
+	assert.False(t, decisions[0].IsEnforced)
+	assert.Equal(t, "/svc.Admin/*", decisions[0].Rule)
+	assert.ErrorIs(t, decisions[0].Err, ErrUnauthenticated)
+}
+
+func TestAuditOnlyModeLogsByDefault(t *testing.T) {
+	var out bytes.Buffer
+	a, err := NewAuthorizer(writeTestingPolicy(t, t.TempDir(), testingPolicy), SetAuditOnly(true), SetAuditOutput(&out))
+	assert.NoError(t, err)
+
+	assert.NoError(t, a.Authorize(context.Background(), "/svc.Admin/List"))
+	assert.Contains(t, out.String(), "/svc.Admin/List would be denied")
+}
+
+func TestPolicyReload(t *testing.T) {
+	dir := t.TempDir()
+	path := writeTestingPolicy(t, dir, `{"rules":[{"method":"*","deny":true}]}`)
+
+	var reloadErr error
+	a, err := NewAuthorizer(path, SetReloadInterval(time.Nanosecond), SetReloadErrorHandler(func(err error) { reloadErr = err }))
+	assert.NoError(t, err)
+	assert.ErrorIs(t, a.Authorize(context.Background(), "/svc.A/Get"), ErrUnauthenticated)
+
+	writeTestingPolicy(t, dir, `{"rules":[{"method":"*","public":true}]}`)
+	future := time.Now().Add(time.Minute)
+	assert.NoError(t, os.Chtimes(path, future, future))
+	assert.NoError(t, a.Authorize(context.Background(), "/svc.A/Get"))
+
+	// Broken policy is reported and previous one is kept
+	writeTestingPolicy(t, dir, `{"rules":[{"public":true}]}`)
+	future = future.Add(time.Minute)
+	assert.NoError(t, os.Chtimes(path, future, future))
+	assert.NoError(t, a.Authorize(context.Background(), "/svc.A/Get"))
+	assert.ErrorIs(t, reloadErr, ErrInvalidPolicy)
+}
+
+func TestInvalidClaimExpression(t *testing.T) {
+	_, err := NewAuthorizer(writeTestingPolicy(t, t.TempDir(), `{"rules":[{"method":"*","claims":["tenant ~ acme"]}]}`))
+	assert.ErrorIs(t, err, ErrInvalidPolicy)
+}
+
+func TestClaimNameContainsOperator(t *testing.T) {
+	e, err := parseClaimExpression("contains_role contains admin")
+	assert.NoError(t, err)
+	assert.Equal(t, claimExpression{claim: "contains_role", op: "contains", value: "admin"}, e)
+
+	e, err = parseClaimExpression("a==b == \"x\"")
+	assert.NoError(t, err)
+	assert.Equal(t, "x", e.value)
+}
+
+func TestInvalidMethodFilter(t *testing.T) {
+	_, err := NewAuthorizer(writeTestingPolicy(t, t.TempDir(), `{"rules":[{"method":"~(","public":true}]}`))
+	assert.ErrorIs(t, err, ErrInvalidPolicy)
+	assert.ErrorIs(t, err, route.ErrInvalidFilter)
+}
+
+func writeTestingPolicy(t *testing.T, dir, policy string) string {
+	path := filepath.Join(dir, "policy.json")
+	assert.NoError(t, os.WriteFile(path, []byte(policy), 0o600))
+
+	return path
+}
+
+func testingPrincipalContext(claims string) context.Context {
+	var c auth.Claims
+	dec := json.NewDecoder(strings.NewReader(claims))
+	dec.UseNumber()
+	_ = dec.Decode(&c)
+
+	return auth.WithPrincipal(context.Background(), auth.Principal{Subject: c.Subject(), Method: auth.MethodJWT, Claims: c})
+}
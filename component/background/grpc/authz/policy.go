@@ -0,0 +1,249 @@
+package authz
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/Clink-n-Clank/Brokkr/component/background/grpc/auth"
+	"github.com/Clink-n-Clank/Brokkr/component/background/route"
+)
+
+// ErrInvalidPolicy is returned when policy file can't be parsed
+var ErrInvalidPolicy = errors.New("invalid authorization policy")
+
+type (
+	// Policy of methods authorization, first rule that matches method is applied, method without rule is denied
+	//
+	// Example of policy file:
+	//
+	//	{
+	//	  "roles_claim": "roles",
+	//	  "scopes_claim": "scope",
+	//	  "rules": [
+	//	    {"method": "/grpc.health.v1.Health/*", "public": true},
+	//	    {"method": "/myapp.v1.Admin/*", "roles": ["admin"]},
+	//	    {"method": "/myapp.v1.Orders/Get", "scopes": ["orders:read"], "claims": ["tenant == \"acme\""]}
+	//	  ]
+	//	}
+	Policy struct {
+		// RolesClaim name of the claim with roles, it could be a string or an array of strings, "roles" by default
+		RolesClaim string `json:"roles_claim"`
+		// ScopesClaim name of the claim with scopes, it could be space separated string or an array, "scope" by default
+		ScopesClaim string `json:"scopes_claim"`
+		Rules       []Rule `json:"rules"`
+	}
+
+	// Rule of authorization for methods matched by filter with the same syntax as in middlewares filters
+	Rule struct {
+		Method string `json:"method"`
+		// Public methods are allowed without authentication
+		Public bool `json:"public"`
+		// Deny methods for everyone
+		Deny bool `json:"deny"`
+		// Roles any of them is required
+		Roles []string `json:"roles"`
+		// Scopes all of them are required
+		Scopes []string `json:"scopes"`
+		// Claims expressions that all must be true, for example: tenant == "acme", groups contains "ops", email exists
+		Claims []string `json:"claims"`
+
+		matcher     route.Matcher
+		expressions []claimExpression
+	}
+
+	// claimExpression parsed from rule
+	claimExpression struct {
+		claim string
+		op    string
+		value any
+	}
+)
+
+// loadPolicyFile and validate its rules
+func loadPolicyFile(path string) (*Policy, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPolicy, err)
+	}
+
+	p := &Policy{RolesClaim: "roles", ScopesClaim: "scope"}
+	if err = json.Unmarshal(raw, p); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPolicy, err)
+	}
+
+	for i := range p.Rules {
+		if p.Rules[i].Method == "" {
+			return nil, fmt.Errorf("%w: rule %d has no method", ErrInvalidPolicy, i)
+		}
+
+		if p.Rules[i].matcher, err = route.Compile(p.Rules[i].Method); err != nil {
+			return nil, fmt.Errorf("%w: rule %d: %w", ErrInvalidPolicy, i, err)
+		}
+
+		for _, expr := range p.Rules[i].Claims {
+			e, err := parseClaimExpression(expr)
+			if err != nil {
+				return nil, fmt.Errorf("%w: rule %d: %w", ErrInvalidPolicy, i, err)
+			}
+
+			p.Rules[i].expressions = append(p.Rules[i].expressions, e)
+		}
+	}
+
+	return p, nil
+}
+
+// rule that matches full method
+func (p *Policy) rule(fullMethod string) (Rule, bool) {
+	for _, r := range p.Rules {
+		if r.matcher.Match(fullMethod) {
+			return r, true
+		}
+	}
+
+	return Rule{}, false
+}
+
+// check principal against rule, returns reason of denial
+func (p *Policy) check(r Rule, principal auth.Principal) (string, bool) {
+	if r.Deny {
+		return "method is denied by policy", false
+	}
+
+	if len(r.Roles) > 0 && !containsAny(claimList(principal.Claims[p.RolesClaim], false), r.Roles) {
+		return fmt.Sprintf("one of roles %v is required", r.Roles), false
+	}
+
+	scopes := claimList(principal.Claims[p.ScopesClaim], true)
+	for _, s := range r.Scopes {
+		if !containsAny(scopes, []string{s}) {
+			return fmt.Sprintf("scope %s is required", s), false
+		}
+	}
+
+	for i, e := range r.expressions {
+		if !e.eval(principal.Claims) {
+			return fmt.Sprintf("claim expression %s is false", r.Claims[i]), false
+		}
+	}
+
+	return "", true
+}
+
+// parseClaimExpression in format: <claim> <op> <JSON value> or <claim> exists
+func parseClaimExpression(expr string) (claimExpression, error) {
+	fields := strings.Fields(expr)
+	if len(fields) == 2 && fields[1] == "exists" {
+		return claimExpression{claim: fields[0], op: "exists"}, nil
+	}
+
+	if len(fields) < 3 {
+		return claimExpression{}, fmt.Errorf("claim expression %q must be: <claim> <op> <value>", expr)
+	}
+
+	e := claimExpression{claim: fields[0], op: fields[1]}
+	switch e.op {
+	case "==", "!=", "contains":
+	default:
+		return claimExpression{}, fmt.Errorf("unknown operator %q in claim expression %q", e.op, expr)
+	}
+
+	// Value is JSON literal after the operator, not quoted strings are accepted as well
+	rest := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(expr), e.claim))
+	rawValue := strings.TrimSpace(strings.TrimPrefix(rest, e.op))
+	if err := json.Unmarshal([]byte(rawValue), &e.value); err != nil {
+		e.value = rawValue
+	}
+
+	return e, nil
+}
+
+// eval expression with claims, nested claims are addressed with dots
+func (e claimExpression) eval(claims auth.Claims) bool {
+	var value any = map[string]any(claims)
+	for _, name := range strings.Split(e.claim, ".") {
+		m, isMap := value.(map[string]any)
+		if !isMap {
+			return false
+		}
+
+		if value = m[name]; value == nil {
+			return false
+		}
+	}
+
+	switch e.op {
+	case "exists":
+		return true
+	case "==":
+		return equalClaim(value, e.value)
+	case "!=":
+		return !equalClaim(value, e.value)
+	case "contains":
+		if s, isString := value.(string); isString {
+			sub, isSubString := e.value.(string)
+			return isSubString && strings.Contains(s, sub)
+		}
+
+		list, isList := value.([]any)
+		if !isList {
+			return false
+		}
+
+		for _, item := range list {
+			if equalClaim(item, e.value) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// equalClaim compares claim with expected value, numbers are compared by their text
+func equalClaim(claim, expected any) bool {
+	if n, isNumber := claim.(json.Number); isNumber {
+		return n.String() == fmt.Sprint(expected)
+	}
+
+	return reflect.DeepEqual(claim, expected)
+}
+
+// claimList of string values, string claim is split by spaces if needed
+func claimList(claim any, isSpaceSeparated bool) []string {
+	switch c := claim.(type) {
+	case string:
+		if isSpaceSeparated {
+			return strings.Fields(c)
+		}
+
+		return []string{c}
+	case []any:
+		list := make([]string, 0, len(c))
+		for _, item := range c {
+			if s, isString := item.(string); isString {
+				list = append(list, s)
+			}
+		}
+
+		return list
+	default:
+		return nil
+	}
+}
+
+func containsAny(have, want []string) bool {
+	for _, h := range have {
+		for _, w := range want {
+			if h == w {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,311 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	brokkrgrpc "github.com/Clink-n-Clank/Brokkr/component/background/grpc"
+)
+
+const (
+	// CacheControlMetadataKey of request hints: "no-cache" skips lookup, "no-store" bypasses cache,
+	// "max-age=N" accepts entries not older than N seconds
+	CacheControlMetadataKey = "cache-control"
+	// CacheStatusMetadataKey of response header with "hit", "miss" or "bypass"
+	CacheStatusMetadataKey = "cache-status"
+)
+
+type (
+	// cacheControl hints of the request
+	cacheControl struct {
+		isNoCache bool
+		isNoStore bool
+		maxAge    time.Duration
+	}
+
+	// sharedPanic of the shared call, it's propagated to each caller
+	sharedPanic struct {
+		value any
+	}
+
+	// detachedContext keeps values of the parent, but not its deadline and cancellation
+	detachedContext struct {
+		context.Context
+	}
+
+	// Cache of unary responses, key is "<full method>|<metadata key>=<value>,...|<hash of request>",
+	// so it could be invalidated by prefix like "/myapp.v1.Catalog/" or "/myapp.v1.Catalog/Get|x-tenant=acme"
+	Cache struct {
+		mu      sync.Mutex
+		entries *lru
+		group   singleflight.Group
+		// generation is changed by each invalidation, responses of calls started before it are not stored
+		generation uint64
+
+		ttl          time.Duration
+		callTimeout  time.Duration
+		capacity     int
+		metadataKeys []string
+		now          func() time.Time
+	}
+
+	// Option for cache configuration
+	Option func(c *Cache)
+)
+
+// SetCapacity of entries, 1000 by default
+func SetCapacity(capacity int) Option {
+	return func(c *Cache) {
+		c.capacity = capacity
+	}
+}
+
+// SetTTL of entries, 1 minute by default
+func SetTTL(ttl time.Duration) Option {
+	return func(c *Cache) {
+		c.ttl = ttl
+	}
+}
+
+// SetCallTimeout of the call shared by concurrent misses, it's not canceled when caller that started it goes away,
+// 10 seconds by default
+func SetCallTimeout(timeout time.Duration) Option {
+	return func(c *Cache) {
+		c.callTimeout = timeout
+	}
+}
+
+// SetMetadataKeys that are part of cache key, for example tenant or language
+func SetMetadataKeys(keys ...string) Option {
+	return func(c *Cache) {
+		for _, k := range keys {
+			c.metadataKeys = append(c.metadataKeys, strings.ToLower(k))
+		}
+	}
+}
+
+// SetClock used for entries expiration
+func SetClock(now func() time.Time) Option {
+	return func(c *Cache) {
+		c.now = now
+	}
+}
+
+// NewCache of responses in memory
+func NewCache(opts ...Option) *Cache {
+	c := &Cache{
+		ttl:         time.Minute,
+		callTimeout: 10 * time.Second,
+		capacity:    1000,
+		now:         time.Now,
+	}
+
+	for _, o := range opts {
+		o(c)
+	}
+
+	c.entries = newLRU(c.capacity)
+
+	return c
+}
+
+// Middleware that caches successful responses, it should be registered only for read-only methods.
+// Concurrent misses of the same key share one call, each caller stops waiting for it when own context is done,
+// panic of the shared call is propagated to each caller, so recovery middleware should be registered before cache
+func (c *Cache) Middleware() brokkrgrpc.Middleware {
+	return func(handler brokkrgrpc.RequestHandler) brokkrgrpc.RequestHandler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			ctxMeta, _ := brokkrgrpc.GetContextMetadata(ctx)
+			cc := parseCacheControl(ctxMeta.Meta.Get(CacheControlMetadataKey))
+
+			msg, isProto := req.(proto.Message)
+			if !isProto || cc.isNoStore {
+				setCacheStatus(ctx, "bypass")
+				return handler(ctx, req)
+			}
+
+			key, err := c.Key(ctxMeta.FullMethod, ctxMeta.Meta, msg)
+			if err != nil {
+				setCacheStatus(ctx, "bypass")
+				return handler(ctx, req)
+			}
+
+			if !cc.isNoCache {
+				if resp, isHit := c.get(key, cc.maxAge); isHit {
+					setCacheStatus(ctx, "hit")
+					return resp, nil
+				}
+			}
+
+			setCacheStatus(ctx, "miss")
+
+			// Misses after invalidation don't join calls started before it
+			generation := c.currentGeneration()
+			call := c.group.DoChan(key+"|"+strconv.FormatUint(generation, 10), func() (resp interface{}, err error) {
+				callCtx, callCancel := context.WithTimeout(detachedContext{ctx}, c.callTimeout)
+				defer callCancel()
+
+				// Shared call runs in own goroutine, so panic is returned and propagated in goroutines of callers
+				defer func() {
+					if v := recover(); v != nil {
+						resp, err = nil, &sharedPanic{value: v}
+					}
+				}()
+
+				resp, err = handler(callCtx, req)
+				if respMsg, isRespProto := resp.(proto.Message); err == nil && isRespProto {
+					c.set(key, respMsg, generation)
+				}
+
+				return resp, err
+			})
+
+			select {
+			case <-ctx.Done():
+				return nil, status.FromContextError(ctx.Err()).Err()
+			case res := <-call:
+				if p, isPanic := res.Err.(*sharedPanic); isPanic {
+					panic(p.value)
+				}
+
+				// Each caller gets own copy, so response could be changed by next middlewares
+				if respMsg, isRespProto := res.Val.(proto.Message); res.Err == nil && isRespProto {
+					return proto.Clone(respMsg), nil
+				}
+
+				return res.Val, res.Err
+			}
+		}
+	}
+}
+
+// Key of the request in the cache
+func (c *Cache) Key(fullMethod string, md metadata.MD, req proto.Message) (string, error) {
+	raw, err := proto.MarshalOptions{Deterministic: true}.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+
+	values := make([]string, 0, len(c.metadataKeys))
+	for _, k := range c.metadataKeys {
+		values = append(values, k+"="+strings.Join(md.Get(k), ","))
+	}
+
+	hash := sha256.Sum256(raw)
+
+	return fullMethod + "|" + strings.Join(values, ",") + "|" + hex.EncodeToString(hash[:]), nil
+}
+
+// Invalidate entries which keys start with prefix, number of removed entries is returned,
+// responses of in-flight calls are not stored after invalidation
+func (c *Cache) Invalidate(prefix string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+
+	return c.entries.removePrefix(prefix)
+}
+
+// Len of entries in the cache including expired ones that are not evicted yet
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.entries.order.Len()
+}
+
+// get copy of cached response, entry older than maxAge is not used if maxAge is set
+func (c *Cache) get(key string, maxAge time.Duration) (proto.Message, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+
+	e, isExist := c.entries.get(key, now)
+	if !isExist || (maxAge >= 0 && now.Sub(e.storedAt) > maxAge) {
+		return nil, false
+	}
+
+	return proto.Clone(e.resp), true
+}
+
+// currentGeneration of invalidations
+func (c *Cache) currentGeneration() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.generation
+}
+
+// set copy of response if cache was not invalidated since generation
+func (c *Cache) set(key string, resp proto.Message, generation uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if generation != c.generation {
+		return
+	}
+
+	now := c.now()
+	c.entries.add(&entry{key: key, resp: proto.Clone(resp), storedAt: now, expiresAt: now.Add(c.ttl)})
+}
+
+// parseCacheControl hints, unknown directives are ignored
+func parseCacheControl(values []string) cacheControl {
+	cc := cacheControl{maxAge: -1}
+
+	for _, v := range values {
+		for _, directive := range strings.Split(v, ",") {
+			directive = strings.ToLower(strings.TrimSpace(directive))
+
+			switch {
+			case directive == "no-cache":
+				cc.isNoCache = true
+			case directive == "no-store":
+				cc.isNoStore = true
+			case strings.HasPrefix(directive, "max-age="):
+				if seconds, err := strconv.Atoi(strings.TrimPrefix(directive, "max-age=")); err == nil && seconds >= 0 {
+					cc.maxAge = time.Duration(seconds) * time.Second
+				}
+			}
+		}
+	}
+
+	return cc
+}
+
+func (p *sharedPanic) Error() string {
+	return fmt.Sprintf("panic in shared call: %v", p.value)
+}
+
+// Deadline of detached context is not set
+func (detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+// Done of detached context is never closed
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+// Err of detached context is always nil
+func (detachedContext) Err() error {
+	return nil
+}
+
+func setCacheStatus(ctx context.Context, cacheStatus string) {
+	_ = grpc.SetHeader(ctx, metadata.Pairs(CacheStatusMetadataKey, cacheStatus))
+}
@@ -0,0 +1,219 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	brokkrgrpc "github.com/Clink-n-Clank/Brokkr/component/background/grpc"
+	"github.com/Clink-n-Clank/Brokkr/component/background/grpc/grpctest"
+	"github.com/Clink-n-Clank/Brokkr/component/background/grpc/recovery"
+)
+
+type testingCall struct {
+	calls   int32
+	handler brokkrgrpc.RequestHandler
+}
+
+func newTestingCall(c *Cache) *testingCall {
+	tc := &testingCall{}
+	tc.handler = c.Middleware()(func(ctx context.Context, req interface{}) (interface{}, error) {
+		atomic.AddInt32(&tc.calls, 1)
+		return &errdetails.ErrorInfo{Reason: req.(*errdetails.RequestInfo).GetRequestId()}, nil
+	})
+
+	return tc
+}
+
+func (tc *testingCall) call(t *testing.T, fullMethod, id string, kv ...string) string {
+	ctx, ts := grpctest.NewContext(fullMethod, metadata.Pairs(kv...))
+
+	resp, err := tc.handler(ctx, &errdetails.RequestInfo{RequestId: id})
+	assert.NoError(t, err)
+	assert.Equal(t, id, resp.(*errdetails.ErrorInfo).GetReason())
+
+	return ts.Header().Get(CacheStatusMetadataKey)[0]
+}
+
+func TestCache_Middleware(t *testing.T) {
+	tc := newTestingCall(NewCache(SetMetadataKeys("x-tenant")))
+
+	assert.Equal(t, "miss", tc.call(t, "/svc.Catalog/Get", "1", "x-tenant", "acme"))
+	assert.Equal(t, "hit", tc.call(t, "/svc.Catalog/Get", "1", "x-tenant", "acme"))
+	assert.Equal(t, "miss", tc.call(t, "/svc.Catalog/Get", "1", "x-tenant", "globex"))
+	assert.Equal(t, "miss", tc.call(t, "/svc.Catalog/Get", "2", "x-tenant", "acme"))
+	assert.Equal(t, "miss", tc.call(t, "/svc.Catalog/List", "1", "x-tenant", "acme"))
+	assert.Equal(t, int32(4), atomic.LoadInt32(&tc.calls))
+}
+
+func TestCache_CacheControl(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	tc := newTestingCall(NewCache(SetClock(func() time.Time { return now })))
+
+	assert.Equal(t, "bypass", tc.call(t, "/svc.Catalog/Get", "1", CacheControlMetadataKey, "no-store"))
+	assert.Equal(t, "miss", tc.call(t, "/svc.Catalog/Get", "1"))
+
+	now = now.Add(10 * time.Second)
+	assert.Equal(t, "hit", tc.call(t, "/svc.Catalog/Get", "1", CacheControlMetadataKey, "max-age=10"))
+	assert.Equal(t, "miss", tc.call(t, "/svc.Catalog/Get", "1", CacheControlMetadataKey, "max-age=5"))
+
+	// Refreshed entry is used by next calls
+	assert.Equal(t, "miss", tc.call(t, "/svc.Catalog/Get", "1", CacheControlMetadataKey, "no-cache"))
+	assert.Equal(t, "hit", tc.call(t, "/svc.Catalog/Get", "1", CacheControlMetadataKey, "max-age=0"))
+	assert.Equal(t, int32(4), atomic.LoadInt32(&tc.calls))
+
+	// Entry is expired after ttl
+	now = now.Add(time.Minute)
+	assert.Equal(t, "miss", tc.call(t, "/svc.Catalog/Get", "1"))
+}
+
+func TestCache_EvictionAndInvalidation(t *testing.T) {
+	c := NewCache(SetCapacity(2), SetMetadataKeys("x-tenant"))
+	tc := newTestingCall(c)
+
+	tc.call(t, "/svc.Catalog/Get", "1")
+	tc.call(t, "/svc.Catalog/Get", "2")
+	tc.call(t, "/svc.Catalog/Get", "1")
+	tc.call(t, "/svc.Catalog/Get", "3")
+	assert.Equal(t, 2, c.Len())
+
+	// Least recently used entry is evicted
+	assert.Equal(t, "hit", tc.call(t, "/svc.Catalog/Get", "1"))
+	assert.Equal(t, "miss", tc.call(t, "/svc.Catalog/Get", "2"))
+
+	tc.call(t, "/svc.Catalog/List", "1", "x-tenant", "acme")
+	assert.Equal(t, 1, c.Invalidate("/svc.Catalog/List|x-tenant=acme"))
+	assert.Equal(t, 1, c.Invalidate("/svc.Catalog/"))
+	assert.Equal(t, 0, c.Len())
+}
+
+func TestCache_SingleFlight(t *testing.T) {
+	var calls int32
+	unblock := make(chan struct{})
+	handler := NewCache().Middleware()(func(ctx context.Context, req interface{}) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		<-unblock
+		return &errdetails.ErrorInfo{Reason: "shared"}, nil
+	})
+
+	ctx, _ := grpctest.NewContext("/svc.Catalog/Get", nil)
+
+	var wg sync.WaitGroup
+	responses := make(chan interface{}, 5)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, _ := handler(ctx, &errdetails.RequestInfo{RequestId: "1"})
+			responses <- resp
+		}()
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	close(unblock)
+	wg.Wait()
+	close(responses)
+
+	seen := map[interface{}]struct{}{}
+	for resp := range responses {
+		assert.Equal(t, "shared", resp.(*errdetails.ErrorInfo).GetReason())
+		seen[resp] = struct{}{}
+	}
+
+	// Callers don't share response instance
+	assert.Len(t, seen, 5)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestCache_SharedCallIsDetachedFromCaller(t *testing.T) {
+	inHandler, unblock := make(chan struct{}), make(chan struct{})
+	handlerErrs := make(chan error, 1)
+	handler := NewCache(SetCallTimeout(time.Second)).Middleware()(func(ctx context.Context, req interface{}) (interface{}, error) {
+		inHandler <- struct{}{}
+		<-unblock
+		handlerErrs <- ctx.Err()
+
+		return &errdetails.ErrorInfo{Reason: "shared"}, nil
+	})
+
+	baseCtx, _ := grpctest.NewContext("/svc.Catalog/Get", nil)
+	firstCtx, cancelFirst := context.WithCancel(baseCtx)
+
+	firstErrs := make(chan error, 1)
+	go func() {
+		_, err := handler(firstCtx, &errdetails.RequestInfo{RequestId: "1"})
+		firstErrs <- err
+	}()
+	<-inHandler
+
+	secondResps := make(chan interface{}, 1)
+	go func() {
+		resp, _ := handler(baseCtx, &errdetails.RequestInfo{RequestId: "1"})
+		secondResps <- resp
+	}()
+
+	// Caller that started the call goes away, but the call continues for other callers
+	cancelFirst()
+	assert.Equal(t, codes.Canceled, status.Code(<-firstErrs))
+
+	close(unblock)
+	assert.NoError(t, <-handlerErrs)
+	assert.Equal(t, "shared", (<-secondResps).(*errdetails.ErrorInfo).GetReason())
+}
+
+func TestCache_InvalidateDuringMiss(t *testing.T) {
+	c := NewCache()
+	inHandler, unblock := make(chan struct{}), make(chan struct{})
+
+	var calls int32
+	handler := c.Middleware()(func(ctx context.Context, req interface{}) (interface{}, error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			inHandler <- struct{}{}
+			<-unblock
+		}
+
+		return &errdetails.ErrorInfo{Reason: "stale"}, nil
+	})
+
+	ctx, _ := grpctest.NewContext("/svc.Catalog/Get", nil)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = handler(ctx, &errdetails.RequestInfo{RequestId: "1"})
+	}()
+	<-inHandler
+
+	// Response of the call started before invalidation is not stored
+	c.Invalidate("/svc.Catalog/")
+	close(unblock)
+	<-done
+	assert.Equal(t, 0, c.Len())
+
+	_, _ = handler(ctx, &errdetails.RequestInfo{RequestId: "1"})
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	assert.Equal(t, 1, c.Len())
+}
+
+func TestCache_PanicIsRecoveredByOuterMiddleware(t *testing.T) {
+	var recovered []recovery.Panic
+	handler := recovery.Middleware(recovery.SetPanicHandler(func(_ context.Context, p recovery.Panic) {
+		recovered = append(recovered, p)
+	}))(NewCache().Middleware()(func(ctx context.Context, req interface{}) (interface{}, error) {
+		panic("boom")
+	}))
+
+	ctx, _ := grpctest.NewContext("/svc.Catalog/Get", nil)
+
+	_, err := handler(ctx, &errdetails.RequestInfo{RequestId: "1"})
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.Len(t, recovered, 1)
+	assert.Equal(t, "boom", recovered[0].Value)
+}
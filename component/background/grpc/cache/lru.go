@@ -0,0 +1,81 @@
+package cache
+
+import (
+	"container/list"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+)
+
+type (
+	// entry of cached response
+	entry struct {
+		key       string
+		resp      proto.Message
+		storedAt  time.Time
+		expiresAt time.Time
+	}
+
+	// lru of entries with ttl, least recently used entry is evicted when capacity is reached
+	lru struct {
+		capacity int
+		order    *list.List
+		entries  map[string]*list.Element
+	}
+)
+
+func newLRU(capacity int) *lru {
+	return &lru{capacity: capacity, order: list.New(), entries: map[string]*list.Element{}}
+}
+
+// get entry that is not expired
+func (l *lru) get(key string, now time.Time) (*entry, bool) {
+	el, isExist := l.entries[key]
+	if !isExist {
+		return nil, false
+	}
+
+	e := el.Value.(*entry)
+	if !now.Before(e.expiresAt) {
+		l.remove(el)
+		return nil, false
+	}
+
+	l.order.MoveToFront(el)
+
+	return e, true
+}
+
+// add entry, least recently used entry is evicted if capacity is reached
+func (l *lru) add(e *entry) {
+	if el, isExist := l.entries[e.key]; isExist {
+		el.Value = e
+		l.order.MoveToFront(el)
+
+		return
+	}
+
+	l.entries[e.key] = l.order.PushFront(e)
+	if l.order.Len() > l.capacity {
+		l.remove(l.order.Back())
+	}
+}
+
+// removePrefix of keys, number of removed entries is returned
+func (l *lru) removePrefix(prefix string) int {
+	var removed int
+	for key, el := range l.entries {
+		if strings.HasPrefix(key, prefix) {
+			l.remove(el)
+			removed++
+		}
+	}
+
+	return removed
+}
+
+func (l *lru) remove(el *list.Element) {
+	l.order.Remove(el)
+	delete(l.entries, el.Value.(*entry).key)
+}
@@ -0,0 +1,284 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/Clink-n-Clank/Brokkr/component/background"
+	"github.com/Clink-n-Clank/Brokkr/component/background/route"
+	"github.com/Clink-n-Clank/Brokkr/component/behavior/circuitbreaker"
+)
+
+// BackgroundClient wrapper of gRPC client connection, it can be passed to generated clients as grpc.ClientConnInterface
+type BackgroundClient struct {
+	*grpc.ClientConn
+	target             string
+	dialOpts           []grpc.DialOption
+	dialErr            error
+	middlewareComposer *MiddlewareComposer
+
+	timeouts    route.Table[time.Duration]
+	timeoutsErr error
+
+	retryAttempts  uint
+	retryBackoff   time.Duration
+	retryableCodes map[codes.Code]struct{}
+
+	targetBreaker  *circuitbreaker.CircuitBreaker
+	methodBreakers map[string]*circuitbreaker.CircuitBreaker
+
+	propagatedKeys []string
+
+	stopped  chan struct{}
+	stopOnce sync.Once
+}
+
+const clientProcessName = "gRPC Client"
+
+// NewClient instance, connection is established lazily on first call
+func NewClient(builder *ClientOptionsBuilder) *BackgroundClient {
+	// Set defaults for new process wrapper
+	c := &BackgroundClient{
+		middlewareComposer: NewMiddlewareComposer(),
+		retryAttempts:      1,
+		retryableCodes:     map[codes.Code]struct{}{codes.Unavailable: {}},
+		methodBreakers:     map[string]*circuitbreaker.CircuitBreaker{},
+		stopped:            make(chan struct{}),
+	}
+
+	// Load additional grpc client options
+	for _, o := range builder.Build() {
+		o(c)
+	}
+
+	c.dialOpts = append(
+		c.dialOpts,
+		grpc.WithChainUnaryInterceptor(c.unaryClientInterceptorForMiddleware()),
+		grpc.WithChainStreamInterceptor(c.streamClientInterceptorForMetadata()),
+	)
+
+	c.ClientConn, c.dialErr = grpc.Dial(c.target, c.dialOpts...)
+
+	return c
+}
+
+// GetName of the task
+func (c *BackgroundClient) GetName() string {
+	return clientProcessName + " " + c.target
+}
+
+// GetSeverity of the task
+func (c *BackgroundClient) GetSeverity() background.ProcessSeverity {
+	return background.TaskSeverityMajor
+}
+
+// Invoke unary method, dial error is returned if connection wasn't created
+func (c *BackgroundClient) Invoke(ctx context.Context, method string, args, reply interface{}, opts ...grpc.CallOption) error {
+	if c.ClientConn == nil {
+		return c.dialErr
+	}
+
+	return c.ClientConn.Invoke(ctx, method, args, reply, opts...)
+}
+
+// NewStream of streaming method, dial error is returned if connection wasn't created
+func (c *BackgroundClient) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	if c.ClientConn == nil {
+		return nil, c.dialErr
+	}
+
+	return c.ClientConn.NewStream(ctx, desc, method, opts...)
+}
+
+// OnStart event to be called when main loop will be started, client is kept until stop
+func (c *BackgroundClient) OnStart(_ context.Context) error {
+	if c.dialErr != nil {
+		return c.dialErr
+	}
+
+	if err := errors.Join(c.middlewareComposer.Err(), c.timeoutsErr); err != nil {
+		return err
+	}
+
+	<-c.stopped
+
+	return nil
+}
+
+// OnStop event to be called when main loop will be stopped, connection is closed
+func (c *BackgroundClient) OnStop(_ context.Context) error {
+	var closeErr error
+	c.stopOnce.Do(func() {
+		close(c.stopped)
+
+		if c.ClientConn != nil {
+			closeErr = c.ClientConn.Close()
+		}
+	})
+
+	return closeErr
+}
+
+// unaryClientInterceptorForMiddleware managing outbound gRPC request to delegate it to Middleware, retries and breaker
+func (c *BackgroundClient) unaryClientInterceptorForMiddleware() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx = c.propagateMetadata(ctx)
+
+		if timeouts := c.timeouts.Search(method); len(timeouts) > 0 {
+			// The most specific filter is the last one
+			var ctxCancel context.CancelFunc
+			ctx, ctxCancel = context.WithTimeout(ctx, timeouts[len(timeouts)-1])
+			defer ctxCancel()
+		}
+
+		extCtxMeta := RequestContextMetadata{FullMethod: method}
+		if meta, isMetaExist := metadata.FromOutgoingContext(ctx); isMetaExist {
+			extCtxMeta.Meta = meta
+		}
+
+		ctx = c.middlewareComposer.ExtendContext(ctx, extCtxMeta)
+
+		//
+		// Look up for registered middlewares
+		//
+		defaultRequestHandler := func(ctx context.Context, req interface{}) (interface{}, error) {
+			return reply, c.invokeWithRetry(ctx, method, req, reply, cc, invoker, opts...)
+		}
+
+		affectedMiddlewares := c.middlewareComposer.Search(method)
+		if len(affectedMiddlewares) > 0 {
+			defaultRequestHandler = c.middlewareComposer.PassToNext(affectedMiddlewares...)(defaultRequestHandler)
+		}
+
+		resp, err := defaultRequestHandler(ctx, req)
+		if err != nil || resp == reply {
+			return err
+		}
+
+		// Middleware returned own response without calling server, for example from cache
+		return setReply(reply, resp)
+	}
+}
+
+// streamClientInterceptorForMetadata propagates incoming metadata to outbound streams
+func (c *BackgroundClient) streamClientInterceptorForMetadata() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return streamer(c.propagateMetadata(ctx), desc, cc, method, opts...)
+	}
+}
+
+// invokeWithRetry of the call when it failed with retryable code, backoff is increased after each attempt
+func (c *BackgroundClient) invokeWithRetry(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	backoff := c.retryBackoff
+
+	for attempt := uint(1); ; attempt++ {
+		err := c.invokeWithBreaker(ctx, method, req, reply, cc, invoker, opts...)
+		if err == nil {
+			return nil
+		}
+
+		if errors.Is(err, circuitbreaker.ErrCircuitOpen) {
+			return status.Errorf(codes.Unavailable, "%s: %s", method, err)
+		}
+
+		if !c.isRetryable(err) || attempt >= c.retryAttempts {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+
+		backoff <<= 2
+	}
+}
+
+// invokeWithBreaker of the method or target, only retryable codes and deadlines are counted as failures
+func (c *BackgroundClient) invokeWithBreaker(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	cb, hasMethodBreaker := c.methodBreakers[method]
+	if !hasMethodBreaker {
+		cb = c.targetBreaker
+	}
+
+	if cb == nil {
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+
+	var invokeErr error
+	_, cbErr := cb.Proceed(func() (any, error) {
+		invokeErr = invoker(ctx, method, req, reply, cc, opts...)
+		if c.isRetryable(invokeErr) || status.Code(invokeErr) == codes.DeadlineExceeded {
+			return nil, invokeErr
+		}
+
+		return nil, nil
+	})
+
+	if errors.Is(cbErr, circuitbreaker.ErrCircuitOpen) {
+		return cbErr
+	}
+
+	return invokeErr
+}
+
+// isRetryable error of the call
+func (c *BackgroundClient) isRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	_, isRetryable := c.retryableCodes[status.Code(err)]
+
+	return isRetryable
+}
+
+// propagateMetadata of incoming context to outgoing one, it's used when client is called from gRPC handler
+func (c *BackgroundClient) propagateMetadata(ctx context.Context) context.Context {
+	if len(c.propagatedKeys) == 0 {
+		return ctx
+	}
+
+	incoming, isIncomingExist := metadata.FromIncomingContext(ctx)
+	if !isIncomingExist {
+		return ctx
+	}
+
+	outgoing, _ := metadata.FromOutgoingContext(ctx)
+	for _, key := range c.propagatedKeys {
+		key = strings.ToLower(key)
+		if len(outgoing.Get(key)) > 0 {
+			continue
+		}
+
+		for _, v := range incoming.Get(key) {
+			ctx = metadata.AppendToOutgoingContext(ctx, key, v)
+		}
+	}
+
+	return ctx
+}
+
+// setReply of the call with response returned by middleware
+func setReply(reply, resp interface{}) error {
+	replyMsg, isReplyProto := reply.(proto.Message)
+	respMsg, isRespProto := resp.(proto.Message)
+	if !isReplyProto || !isRespProto || replyMsg.ProtoReflect().Descriptor() != respMsg.ProtoReflect().Descriptor() {
+		return status.Errorf(codes.Internal, "middleware response %T doesn't match reply %T", resp, reply)
+	}
+
+	proto.Reset(replyMsg)
+	proto.Merge(replyMsg, respMsg)
+
+	return nil
+}
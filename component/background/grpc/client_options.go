@@ -0,0 +1,89 @@
+package grpc
+
+import (
+	"errors"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+
+	"github.com/Clink-n-Clank/Brokkr/component/behavior/circuitbreaker"
+)
+
+// ClientOptions sets options such as target, retries, breakers, etc.
+type ClientOptions func(c *BackgroundClient)
+
+// ClientOptionsBuilder sets options such as target, retries, breakers, etc, related to gRPC client
+type ClientOptionsBuilder struct {
+	clientOpts []ClientOptions
+}
+
+// NewClientOptionsBuilder for gRPC client configuration
+func NewClientOptionsBuilder() *ClientOptionsBuilder {
+	return &ClientOptionsBuilder{clientOpts: make([]ClientOptions, 0)}
+}
+
+// AddTarget of the gRPC server, for example dns:///my-service:443
+func (b *ClientOptionsBuilder) AddTarget(target string) *ClientOptionsBuilder {
+	b.clientOpts = append(b.clientOpts, func(c *BackgroundClient) { c.target = target })
+	return b
+}
+
+// AddDialOptions that's needed for gRPC client, for example transport credentials
+func (b *ClientOptionsBuilder) AddDialOptions(dialOpts ...grpc.DialOption) *ClientOptionsBuilder {
+	b.clientOpts = append(b.clientOpts, func(c *BackgroundClient) { c.dialOpts = append(c.dialOpts, dialOpts...) })
+	return b
+}
+
+// AddCustomUnaryMiddlewares for outbound requests, filter works the same way as in ServerOptionsBuilder.AddCustomUnaryMiddlewares
+func (b *ClientOptionsBuilder) AddCustomUnaryMiddlewares(filter string, mwList ...Middleware) *ClientOptionsBuilder {
+	b.clientOpts = append(b.clientOpts, func(c *BackgroundClient) { c.middlewareComposer.Register(filter, mwList...) })
+	return b
+}
+
+// AddMethodTimeout deadline of the call including retries, filter works the same way as for middlewares
+func (b *ClientOptionsBuilder) AddMethodTimeout(filter string, timeout time.Duration) *ClientOptionsBuilder {
+	b.clientOpts = append(b.clientOpts, func(c *BackgroundClient) {
+		c.timeoutsErr = errors.Join(c.timeoutsErr, c.timeouts.Register(filter, 0, timeout))
+	})
+	return b
+}
+
+// AddRetry of unary calls with backoff that failed with retryable codes, Unavailable is used if codes are not set
+func (b *ClientOptionsBuilder) AddRetry(attempts uint, backoff time.Duration, retryableCodes ...codes.Code) *ClientOptionsBuilder {
+	b.clientOpts = append(b.clientOpts, func(c *BackgroundClient) {
+		c.retryAttempts = attempts
+		c.retryBackoff = backoff
+
+		if len(retryableCodes) > 0 {
+			c.retryableCodes = map[codes.Code]struct{}{}
+			for _, code := range retryableCodes {
+				c.retryableCodes[code] = struct{}{}
+			}
+		}
+	})
+	return b
+}
+
+// AddCircuitBreaker for all calls to the target
+func (b *ClientOptionsBuilder) AddCircuitBreaker(cb *circuitbreaker.CircuitBreaker) *ClientOptionsBuilder {
+	b.clientOpts = append(b.clientOpts, func(c *BackgroundClient) { c.targetBreaker = cb })
+	return b
+}
+
+// AddMethodCircuitBreaker for calls of the full method, for example /myapp.v1.MyAppAPI/Endpoint, it's used instead of target breaker
+func (b *ClientOptionsBuilder) AddMethodCircuitBreaker(fullMethod string, cb *circuitbreaker.CircuitBreaker) *ClientOptionsBuilder {
+	b.clientOpts = append(b.clientOpts, func(c *BackgroundClient) { c.methodBreakers[fullMethod] = cb })
+	return b
+}
+
+// AddMetadataPropagation of incoming metadata keys to outgoing calls, for example request ID or auth headers
+func (b *ClientOptionsBuilder) AddMetadataPropagation(keys ...string) *ClientOptionsBuilder {
+	b.clientOpts = append(b.clientOpts, func(c *BackgroundClient) { c.propagatedKeys = append(c.propagatedKeys, keys...) })
+	return b
+}
+
+// Build will make sure that all needed options prepared for client
+func (b *ClientOptionsBuilder) Build() []ClientOptions {
+	return b.clientOpts
+}
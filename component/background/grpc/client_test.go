@@ -0,0 +1,202 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/Clink-n-Clank/Brokkr/component/behavior/circuitbreaker"
+)
+
+func TestClientPropagatesMetadataThroughMiddlewares(t *testing.T) {
+	serverMeta := make(chan metadata.MD, 1)
+	addr := startTestingHealthServer(t, func(ctx context.Context) error {
+		ctxMeta, _ := GetContextMetadata(ctx)
+		serverMeta <- ctxMeta.Meta
+
+		return nil
+	})
+
+	var clientMethod string
+	client := NewClient(
+		NewClientOptionsBuilder().
+			AddTarget(addr).
+			AddDialOptions(grpc.WithTransportCredentials(insecure.NewCredentials())).
+			AddMetadataPropagation("x-request-id").
+			AddCustomUnaryMiddlewares("/grpc.health.v1.Health/*", func(handler RequestHandler) RequestHandler {
+				return func(ctx context.Context, req interface{}) (interface{}, error) {
+					ctxMeta, _ := GetContextMetadata(ctx)
+					clientMethod = ctxMeta.FullMethod
+
+					return handler(ctx, req)
+				}
+			}),
+	)
+
+	started := make(chan error, 1)
+	go func() { started <- client.OnStart(context.Background()) }()
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-request-id", "unit", "x-secret", "hidden"))
+	resp, err := grpc_health_v1.NewHealthClient(client).Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, resp.GetStatus())
+
+	assert.Equal(t, "/grpc.health.v1.Health/Check", clientMethod)
+	md := <-serverMeta
+	assert.Equal(t, []string{"unit"}, md.Get("x-request-id"))
+	assert.Empty(t, md.Get("x-secret"), "only configured keys expected to be propagated")
+
+	assert.NoError(t, client.OnStop(context.Background()))
+	assert.NoError(t, <-started)
+	assert.Equal(t, clientProcessName+" "+addr, client.GetName())
+}
+
+func TestClientRetriesRetryableCodes(t *testing.T) {
+	var calls atomic.Int32
+	addr := startTestingHealthServer(t, func(context.Context) error {
+		if calls.Add(1) < 3 {
+			return status.Error(codes.Unavailable, "unit")
+		}
+
+		return nil
+	})
+
+	client := newTestingClient(t, addr, NewClientOptionsBuilder().AddRetry(3, time.Millisecond))
+
+	_, err := grpc_health_v1.NewHealthClient(client).Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestClientDoesNotRetryOtherCodes(t *testing.T) {
+	var calls atomic.Int32
+	addr := startTestingHealthServer(t, func(context.Context) error {
+		calls.Add(1)
+		return status.Error(codes.InvalidArgument, "unit")
+	})
+
+	client := newTestingClient(t, addr, NewClientOptionsBuilder().AddRetry(3, time.Millisecond))
+
+	_, err := grpc_health_v1.NewHealthClient(client).Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestClientCircuitBreaker(t *testing.T) {
+	var calls atomic.Int32
+	addr := startTestingHealthServer(t, func(context.Context) error {
+		calls.Add(1)
+		return status.Error(codes.Unavailable, "unit")
+	})
+
+	cb, err := circuitbreaker.NewCircuitBreaker(circuitbreaker.Configuration{MaxFailuresThreshold: "1", ResetTimeout: "60"})
+	assert.NoError(t, err)
+
+	client := newTestingClient(t, addr, NewClientOptionsBuilder().AddMethodCircuitBreaker("/grpc.health.v1.Health/Check", cb))
+	healthClient := grpc_health_v1.NewHealthClient(client)
+
+	for i := 0; i < 2; i++ {
+		_, err = healthClient.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+	}
+	assert.Equal(t, circuitbreaker.StateOpen, cb.GetState())
+
+	_, err = healthClient.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Contains(t, err.Error(), circuitbreaker.ErrCircuitOpen.Error())
+	assert.Equal(t, int32(2), calls.Load(), "open breaker expected to not call server")
+}
+
+func TestClientMethodTimeout(t *testing.T) {
+	addr := startTestingHealthServer(t, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	client := newTestingClient(t, addr, NewClientOptionsBuilder().
+		AddMethodTimeout("*", time.Hour).
+		AddMethodTimeout("/grpc.health.v1.Health/Check", 20*time.Millisecond),
+	)
+
+	_, err := grpc_health_v1.NewHealthClient(client).Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+}
+
+func TestClientMiddlewareResponseIsSetToReply(t *testing.T) {
+	var calls atomic.Int32
+	addr := startTestingHealthServer(t, func(context.Context) error {
+		calls.Add(1)
+		return nil
+	})
+
+	client := newTestingClient(t, addr, NewClientOptionsBuilder().
+		AddCustomUnaryMiddlewares("/grpc.health.v1.Health/Check", func(handler RequestHandler) RequestHandler {
+			return func(ctx context.Context, req interface{}) (interface{}, error) {
+				return &grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_NOT_SERVING}, nil
+			}
+		}).
+		AddCustomUnaryMiddlewares("/unit.Service/Get", func(handler RequestHandler) RequestHandler {
+			return func(ctx context.Context, req interface{}) (interface{}, error) {
+				return &grpc_health_v1.HealthCheckRequest{}, nil
+			}
+		}),
+	)
+
+	resp, err := grpc_health_v1.NewHealthClient(client).Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, grpc_health_v1.HealthCheckResponse_NOT_SERVING, resp.GetStatus())
+	assert.Equal(t, int32(0), calls.Load(), "server expected to be skipped by middleware")
+
+	reply := &grpc_health_v1.HealthCheckResponse{}
+	err = client.Invoke(context.Background(), "/unit.Service/Get", &grpc_health_v1.HealthCheckRequest{}, reply)
+	assert.Equal(t, codes.Internal, status.Code(err), "response of another type expected to be rejected")
+}
+
+func TestClientDialError(t *testing.T) {
+	client := NewClient(NewClientOptionsBuilder().AddTarget("localhost:1"))
+
+	_, err := grpc_health_v1.NewHealthClient(client).Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	assert.Error(t, err)
+	assert.Equal(t, err, client.OnStart(context.Background()))
+
+	_, err = grpc_health_v1.NewHealthClient(client).Watch(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	assert.Error(t, err)
+	assert.NoError(t, client.OnStop(context.Background()))
+}
+
+// startTestingHealthServer with middleware that handles request before health service
+func startTestingHealthServer(t *testing.T, onRequest func(ctx context.Context) error) string {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	srv := NewServer(NewServerOptionsBuilder().AddListener(lis).AddCustomUnaryMiddlewares("*", func(handler RequestHandler) RequestHandler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			if err := onRequest(ctx); err != nil {
+				return nil, err
+			}
+
+			return handler(ctx, req)
+		}
+	}))
+	go func() { _ = srv.OnStart(context.Background()) }()
+	t.Cleanup(func() { srv.Stop() })
+
+	return lis.Addr().String()
+}
+
+func newTestingClient(t *testing.T, addr string, b *ClientOptionsBuilder) *BackgroundClient {
+	client := NewClient(b.AddTarget(addr).AddDialOptions(grpc.WithTransportCredentials(insecure.NewCredentials())))
+	t.Cleanup(func() { _ = client.OnStop(context.Background()) })
+
+	return client
+}
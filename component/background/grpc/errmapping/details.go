@@ -0,0 +1,81 @@
+package errmapping
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/protobuf/runtime/protoiface"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+type (
+	// FieldViolation of invalid request field
+	FieldViolation struct {
+		// Field path, for example "address.zip_code"
+		Field       string
+		Description string
+	}
+
+	// FieldViolationsError is implemented by errors that describe invalid request fields
+	FieldViolationsError interface {
+		error
+		FieldViolations() []FieldViolation
+	}
+
+	// BadRequestError with list of invalid fields
+	BadRequestError struct {
+		Violations []FieldViolation
+	}
+)
+
+// Error message with all violations
+func (e *BadRequestError) Error() string {
+	parts := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		parts = append(parts, fmt.Sprintf("%s: %s", v.Field, v.Description))
+	}
+
+	return "invalid request: " + strings.Join(parts, "; ")
+}
+
+// FieldViolations of the request
+func (e *BadRequestError) FieldViolations() []FieldViolation {
+	return e.Violations
+}
+
+// BadRequest detail with field violations of the error that implements FieldViolationsError
+func BadRequest() DetailFunc {
+	return func(err error) protoiface.MessageV1 {
+		var violationsErr FieldViolationsError
+		if !errors.As(err, &violationsErr) {
+			return nil
+		}
+
+		br := &errdetails.BadRequest{}
+		for _, v := range violationsErr.FieldViolations() {
+			br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       v.Field,
+				Description: v.Description,
+			})
+		}
+
+		return br
+	}
+}
+
+// RetryInfo detail with delay before client should retry
+func RetryInfo(delay time.Duration) DetailFunc {
+	return func(error) protoiface.MessageV1 {
+		return &errdetails.RetryInfo{RetryDelay: durationpb.New(delay)}
+	}
+}
+
+// ErrorInfo detail with reason of the error, for example "STOCK_EXHAUSTED", and domain of the service
+func ErrorInfo(reason, domain string, metadata map[string]string) DetailFunc {
+	return func(error) protoiface.MessageV1 {
+		return &errdetails.ErrorInfo{Reason: reason, Domain: domain, Metadata: metadata}
+	}
+}
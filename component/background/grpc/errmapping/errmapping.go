@@ -0,0 +1,127 @@
+package errmapping
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/runtime/protoiface"
+
+	brokkrgrpc "github.com/Clink-n-Clank/Brokkr/component/background/grpc"
+)
+
+type (
+	// DetailFunc builds rich error detail of the matched error, nil detail is skipped
+	DetailFunc func(err error) protoiface.MessageV1
+
+	// mapping of the error to the code
+	mapping struct {
+		match   func(err error) bool
+		code    codes.Code
+		details []DetailFunc
+	}
+
+	// Mapper converts domain errors to gRPC status, mappings are checked in registration order
+	Mapper struct {
+		mappings []mapping
+	}
+)
+
+// NewMapper instance, context errors are mapped to Canceled and DeadlineExceeded if they are not registered
+func NewMapper() *Mapper {
+	return &Mapper{}
+}
+
+// Register sentinel error with code, wrapped errors are matched with errors.Is
+func (m *Mapper) Register(target error, code codes.Code, details ...DetailFunc) *Mapper {
+	m.mappings = append(m.mappings, mapping{
+		match:   func(err error) bool { return errors.Is(err, target) },
+		code:    code,
+		details: details,
+	})
+
+	return m
+}
+
+// RegisterType of error with code, wrapped errors are matched with errors.As
+func RegisterType[T error](m *Mapper, code codes.Code, details ...DetailFunc) *Mapper {
+	m.mappings = append(m.mappings, mapping{
+		match: func(err error) bool {
+			var target T
+			return errors.As(err, &target)
+		},
+		code:    code,
+		details: details,
+	})
+
+	return m
+}
+
+// Status of the error, errors that already have status are kept, unknown errors have Unknown code
+func (m *Mapper) Status(err error) *status.Status {
+	if err == nil {
+		return nil
+	}
+
+	if s, isStatus := status.FromError(err); isStatus {
+		return s
+	}
+
+	for _, mp := range m.mappings {
+		if mp.match(err) {
+			return withDetails(status.New(mp.code, err.Error()), err, mp.details)
+		}
+	}
+
+	return status.FromContextError(err)
+}
+
+// Error with gRPC status of given error
+func (m *Mapper) Error(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return m.Status(err).Err()
+}
+
+// Middleware that converts errors of unary handler to gRPC status
+func (m *Mapper) Middleware() brokkrgrpc.Middleware {
+	return func(handler brokkrgrpc.RequestHandler) brokkrgrpc.RequestHandler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			resp, err := handler(ctx, req)
+			if err != nil {
+				return nil, m.Error(err)
+			}
+
+			return resp, nil
+		}
+	}
+}
+
+// StreamMiddleware that converts errors of stream handler to gRPC status
+func (m *Mapper) StreamMiddleware() brokkrgrpc.StreamMiddleware {
+	return func(handler brokkrgrpc.StreamHandler) brokkrgrpc.StreamHandler {
+		return func(srv interface{}, stream grpc.ServerStream) error {
+			return m.Error(handler(srv, stream))
+		}
+	}
+}
+
+// withDetails of the status, details that can't be marshaled are skipped
+func withDetails(s *status.Status, err error, details []DetailFunc) *status.Status {
+	for _, d := range details {
+		detail := d(err)
+		if detail == nil {
+			continue
+		}
+
+		if extended, detailErr := s.WithDetails(detail); detailErr == nil {
+			s = extended
+		}
+	}
+
+	return s
+}
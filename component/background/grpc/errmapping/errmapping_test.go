@@ -0,0 +1,94 @@
+package errmapping
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	errNotFound  = errors.New("order not found")
+	errExhausted = errors.New("stock exhausted")
+)
+
+type testingConflictError struct {
+	ID string
+}
+
+func (e testingConflictError) Error() string {
+	return "order " + e.ID + " is already paid"
+}
+
+func testingMapper() *Mapper {
+	m := NewMapper().
+		Register(errNotFound, codes.NotFound).
+		Register(errExhausted, codes.ResourceExhausted, RetryInfo(time.Minute), ErrorInfo("STOCK_EXHAUSTED", "orders.example.com", nil))
+	RegisterType[testingConflictError](m, codes.FailedPrecondition)
+	RegisterType[*BadRequestError](m, codes.InvalidArgument, BadRequest())
+
+	return m
+}
+
+func TestMapper_Status(t *testing.T) {
+	m := testingMapper()
+
+	tests := []struct {
+		err     error
+		code    codes.Code
+		message string
+	}{
+		{fmt.Errorf("get order: %w", errNotFound), codes.NotFound, "get order: order not found"},
+		{fmt.Errorf("pay: %w", testingConflictError{ID: "42"}), codes.FailedPrecondition, "pay: order 42 is already paid"},
+		{status.Error(codes.Aborted, "aborted"), codes.Aborted, "aborted"},
+		{fmt.Errorf("call: %w", context.DeadlineExceeded), codes.DeadlineExceeded, "call: context deadline exceeded"},
+		{errors.New("plain"), codes.Unknown, "plain"},
+	}
+
+	for _, tt := range tests {
+		s := m.Status(tt.err)
+		assert.Equal(t, tt.code, s.Code(), tt.err.Error())
+		assert.Equal(t, tt.message, s.Message())
+	}
+
+	assert.Nil(t, m.Status(nil))
+	assert.NoError(t, m.Error(nil))
+}
+
+func TestMapper_Details(t *testing.T) {
+	m := testingMapper()
+
+	s := m.Status(fmt.Errorf("reserve: %w", errExhausted))
+	assert.Len(t, s.Details(), 2)
+	assert.Equal(t, time.Minute, s.Details()[0].(*errdetails.RetryInfo).GetRetryDelay().AsDuration())
+	assert.Equal(t, "STOCK_EXHAUSTED", s.Details()[1].(*errdetails.ErrorInfo).GetReason())
+
+	s = m.Status(fmt.Errorf("create: %w", &BadRequestError{Violations: []FieldViolation{{Field: "email", Description: "must be set"}}}))
+	assert.Equal(t, codes.InvalidArgument, s.Code())
+	assert.Equal(t, "create: invalid request: email: must be set", s.Message())
+
+	br := s.Details()[0].(*errdetails.BadRequest)
+	assert.Equal(t, "email", br.GetFieldViolations()[0].GetField())
+	assert.Equal(t, "must be set", br.GetFieldViolations()[0].GetDescription())
+}
+
+func TestMapper_Middleware(t *testing.T) {
+	m := testingMapper()
+
+	_, err := m.Middleware()(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, errNotFound
+	})(context.Background(), nil)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	resp, err := m.Middleware()(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return req, nil
+	})(context.Background(), "ok")
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", resp)
+}
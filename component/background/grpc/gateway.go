@@ -0,0 +1,426 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// JSON/HTTP transcoding of registered unary gRPC methods
+
+const (
+	// gatewayMaxBodySize of HTTP request
+	gatewayMaxBodySize = 4 << 20
+	// gatewayMetadataHeaderPrefix for gRPC header and trailer metadata in HTTP response
+	gatewayMetadataHeaderPrefix = "Grpc-Metadata-"
+)
+
+// gatewayDefaultHeaders forwarded as incoming metadata, other headers are dropped unless they are allowed by option
+var gatewayDefaultHeaders = []string{"authorization", "cache-control", "idempotency-key", "x-request-id", "traceparent", "tracestate"}
+
+type (
+	// registeredService in gRPC server
+	registeredService struct {
+		desc *grpc.ServiceDesc
+		impl interface{}
+	}
+
+	// gatewayRoute of unary method
+	gatewayRoute struct {
+		httpMethod string
+		// template of google.api.http rule, nil for default POST /package.Service/Method route
+		template     *pathTemplate
+		fullMethod   string
+		body         string
+		responseBody string
+
+		method grpc.MethodDesc
+		impl   interface{}
+	}
+
+	// gateway HTTP handler that transcodes requests to gRPC methods
+	gateway struct {
+		server      *BackgroundServer
+		interceptor grpc.UnaryServerInterceptor
+		headers     map[string]struct{}
+
+		routes []gatewayRoute
+		sync.RWMutex
+	}
+
+	// gatewayTransportStream captures metadata that handler sets with grpc.SetHeader or grpc.SetTrailer
+	gatewayTransportStream struct {
+		method  string
+		header  metadata.MD
+		trailer metadata.MD
+
+		sync.Mutex
+	}
+
+	// gatewayAddr of the HTTP client
+	gatewayAddr string
+)
+
+// RegisterService to gRPC server, services registered this way are exposed by HTTP gateway too,
+// gateway routes are built on start, services registered with s.Server.RegisterService are not exposed by gateway
+func (s *BackgroundServer) RegisterService(sd *grpc.ServiceDesc, impl interface{}) {
+	s.Server.RegisterService(sd, impl)
+	s.services = append(s.services, registeredService{desc: sd, impl: impl})
+}
+
+// GatewayHandler that transcodes JSON/HTTP requests to registered gRPC services, it can be mounted to any HTTP server,
+// TLS of that server is not checked against TLS settings of gRPC server, so client certificates must be verified by it
+func (s *BackgroundServer) GatewayHandler() http.Handler {
+	return s.gateway
+}
+
+// HTTPStatusFromCode maps gRPC status code to HTTP status code
+func HTTPStatusFromCode(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		return 499
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// ServeHTTP transcodes request to gRPC method and response back to JSON
+func (g *gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	route, pathValues, isFound := g.match(r.Method, r.URL.EscapedPath())
+	if !isFound {
+		writeGatewayError(w, status.Errorf(codes.NotFound, "no gRPC method for %s %s", r.Method, r.URL.Path))
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, gatewayMaxBodySize))
+	if err != nil {
+		writeGatewayError(w, status.Errorf(codes.InvalidArgument, "failed to read body: %s", err))
+		return
+	}
+
+	// Allowed HTTP headers are passed as incoming metadata, so middlewares can use them the same way as for gRPC
+	md := metadata.MD{}
+	for k, v := range r.Header {
+		if _, isAllowed := g.headers[strings.ToLower(k)]; isAllowed {
+			md.Append(strings.ToLower(k), v...)
+		}
+	}
+
+	stream := &gatewayTransportStream{method: route.fullMethod, header: metadata.MD{}, trailer: metadata.MD{}}
+
+	// Verified client certificate of HTTPS request is available as peer identity the same way as for gRPC
+	p := &peer.Peer{Addr: gatewayAddr(r.RemoteAddr)}
+	if r.TLS != nil {
+		p.AuthInfo = credentials.TLSInfo{State: *r.TLS}
+	}
+
+	ctx := metadata.NewIncomingContext(r.Context(), md)
+	ctx = peer.NewContext(ctx, p)
+	ctx = grpc.NewContextWithServerTransportStream(ctx, stream)
+
+	dec := func(in interface{}) error {
+		msg, isProto := in.(proto.Message)
+		if !isProto {
+			return status.Errorf(codes.Internal, "request of %s is not a proto message", route.fullMethod)
+		}
+
+		if err := decodeGatewayRequest(msg, route, pathValues, body, r); err != nil {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+
+		return nil
+	}
+
+	resp, err := route.method.Handler(route.impl, ctx, dec, g.interceptor)
+	stream.writeHeaders(w)
+	if err != nil {
+		writeGatewayError(w, err)
+		return
+	}
+
+	out, err := encodeGatewayResponse(resp, route.responseBody)
+	if err != nil {
+		writeGatewayError(w, status.Error(codes.Internal, err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(out)
+}
+
+// buildRoutes from registered services, annotated routes have priority over default ones
+func (g *gateway) buildRoutes() {
+	var routes, defaultRoutes []gatewayRoute
+
+	for _, srv := range g.server.services {
+		var serviceDesc protoreflect.ServiceDescriptor
+		if d, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(srv.desc.ServiceName)); err == nil {
+			serviceDesc, _ = d.(protoreflect.ServiceDescriptor)
+		}
+
+		for _, m := range srv.desc.Methods {
+			fullMethod := fmt.Sprintf("/%s/%s", srv.desc.ServiceName, m.MethodName)
+			defaultRoutes = append(defaultRoutes, gatewayRoute{
+				httpMethod: http.MethodPost,
+				fullMethod: fullMethod,
+				body:       "*",
+				method:     m,
+				impl:       srv.impl,
+			})
+
+			if serviceDesc == nil {
+				continue
+			}
+
+			methodDesc := serviceDesc.Methods().ByName(protoreflect.Name(m.MethodName))
+			if methodDesc == nil {
+				continue
+			}
+
+			for _, rule := range httpRulesFromOptions(methodDesc.Options()) {
+				template, err := parsePathTemplate(rule.pattern)
+				if err != nil {
+					continue
+				}
+
+				routes = append(routes, gatewayRoute{
+					httpMethod:   rule.method,
+					template:     template,
+					fullMethod:   fullMethod,
+					body:         rule.body,
+					responseBody: rule.responseBody,
+					method:       m,
+					impl:         srv.impl,
+				})
+			}
+		}
+	}
+
+	g.Lock()
+	defer g.Unlock()
+
+	g.routes = append(routes, defaultRoutes...)
+}
+
+// match route by HTTP method and path
+func (g *gateway) match(httpMethod, requestPath string) (gatewayRoute, map[string]string, bool) {
+	g.RLock()
+	defer g.RUnlock()
+
+	for _, route := range g.routes {
+		if route.httpMethod != httpMethod {
+			continue
+		}
+
+		if route.template == nil {
+			if route.fullMethod == requestPath {
+				return route, nil, true
+			}
+
+			continue
+		}
+
+		if values, isMatched := route.template.match(requestPath); isMatched {
+			return route, values, true
+		}
+	}
+
+	return gatewayRoute{}, nil, false
+}
+
+// decodeGatewayRequest from body, path values and query parameters
+func decodeGatewayRequest(msg proto.Message, route gatewayRoute, pathValues map[string]string, body []byte, r *http.Request) error {
+	if route.body != "" && len(body) > 0 {
+		if err := decodeGatewayBody(msg, route.body, body); err != nil {
+			return err
+		}
+	}
+
+	for fieldPath, value := range pathValues {
+		if err := setMessageField(msg.ProtoReflect(), fieldPath, value); err != nil {
+			return err
+		}
+	}
+
+	// Query parameters are used only for fields that are not in body
+	if route.body == "*" {
+		return nil
+	}
+
+	for fieldPath, values := range r.URL.Query() {
+		if _, isBound := pathValues[fieldPath]; isBound || fieldPath == route.body {
+			continue
+		}
+
+		for _, value := range values {
+			if err := setMessageField(msg.ProtoReflect(), fieldPath, value); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// decodeGatewayBody to whole message or to a single field of it
+func decodeGatewayBody(msg proto.Message, bodyField string, body []byte) error {
+	if bodyField == "*" {
+		return protojson.Unmarshal(body, msg)
+	}
+
+	fd := msg.ProtoReflect().Descriptor().Fields().ByName(protoreflect.Name(bodyField))
+	if fd == nil {
+		return fmt.Errorf("unknown body field %s in %s", bodyField, msg.ProtoReflect().Descriptor().FullName())
+	}
+
+	// Body is decoded as value of the field and merged, so other fields are not reset
+	wrapped := msg.ProtoReflect().New().Interface()
+	if err := protojson.Unmarshal([]byte(fmt.Sprintf("{%q:%s}", fd.JSONName(), body)), wrapped); err != nil {
+		return err
+	}
+
+	proto.Merge(msg, wrapped)
+
+	return nil
+}
+
+// encodeGatewayResponse to JSON, only response body field is encoded if set
+func encodeGatewayResponse(resp interface{}, responseBody string) ([]byte, error) {
+	msg, isProto := resp.(proto.Message)
+	if !isProto {
+		return nil, fmt.Errorf("response %T is not a proto message", resp)
+	}
+
+	if responseBody == "" {
+		return protojson.Marshal(msg)
+	}
+
+	fd := msg.ProtoReflect().Descriptor().Fields().ByName(protoreflect.Name(responseBody))
+	if fd == nil {
+		return nil, fmt.Errorf("unknown response body field %s in %s", responseBody, msg.ProtoReflect().Descriptor().FullName())
+	}
+
+	if fd.Message() == nil || fd.IsList() || fd.IsMap() {
+		return nil, fmt.Errorf("response body field %s must be a message", responseBody)
+	}
+
+	return protojson.Marshal(msg.ProtoReflect().Get(fd).Message().Interface())
+}
+
+// writeGatewayError as JSON of google.rpc.Status with mapped HTTP status code
+func writeGatewayError(w http.ResponseWriter, err error) {
+	st, _ := status.FromError(err)
+
+	out, marshalErr := protojson.Marshal(st.Proto())
+	if marshalErr != nil {
+		out = []byte(fmt.Sprintf(`{"code":%d,"message":%q}`, st.Code(), st.Message()))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(HTTPStatusFromCode(st.Code()))
+	_, _ = w.Write(out)
+}
+
+// chainUnaryInterceptors into one, first interceptor is the outermost
+func chainUnaryInterceptors(interceptors []grpc.UnaryServerInterceptor) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		next := handler
+		for i := len(interceptors) - 1; i >= 0; i-- {
+			interceptor, h := interceptors[i], next
+			next = func(ctx context.Context, req interface{}) (interface{}, error) {
+				return interceptor(ctx, req, info, h)
+			}
+		}
+
+		return next(ctx, req)
+	}
+}
+
+// Method of the stream
+func (s *gatewayTransportStream) Method() string {
+	return s.method
+}
+
+// SetHeader metadata that will be sent as HTTP headers
+func (s *gatewayTransportStream) SetHeader(md metadata.MD) error {
+	s.Lock()
+	defer s.Unlock()
+
+	s.header = metadata.Join(s.header, md)
+
+	return nil
+}
+
+// SendHeader the same as SetHeader, headers are sent with response
+func (s *gatewayTransportStream) SendHeader(md metadata.MD) error {
+	return s.SetHeader(md)
+}
+
+// SetTrailer metadata that will be sent as HTTP headers
+func (s *gatewayTransportStream) SetTrailer(md metadata.MD) error {
+	s.Lock()
+	defer s.Unlock()
+
+	s.trailer = metadata.Join(s.trailer, md)
+
+	return nil
+}
+
+// writeHeaders from captured metadata
+func (s *gatewayTransportStream) writeHeaders(w http.ResponseWriter) {
+	s.Lock()
+	defer s.Unlock()
+
+	for _, md := range []metadata.MD{s.header, s.trailer} {
+		for k, values := range md {
+			for _, v := range values {
+				w.Header().Add(gatewayMetadataHeaderPrefix+k, v)
+			}
+		}
+	}
+}
+
+// Network of the HTTP client
+func (a gatewayAddr) Network() string {
+	return "tcp"
+}
+
+// String address of the HTTP client
+func (a gatewayAddr) String() string {
+	return string(a)
+}
@@ -0,0 +1,369 @@
+package grpc
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Support of google.api.http annotations without dependency on generated googleapis code
+
+// httpRuleExtensionField number of google.api.http extension in google.protobuf.MethodOptions
+const httpRuleExtensionField protowire.Number = 72295728
+
+// ErrInvalidPathTemplate is returned when google.api.http path template can't be parsed
+var ErrInvalidPathTemplate = errors.New("invalid HTTP path template")
+
+type (
+	// httpRule from google.api.http annotation
+	httpRule struct {
+		method       string
+		pattern      string
+		body         string
+		responseBody string
+		additional   []httpRule
+	}
+
+	// pathTemplate of google.api.http rule, for example /v1/{name=shelves/*}/books:verb
+	pathTemplate struct {
+		// tokens literal segments or wildcards * and **
+		tokens []string
+		vars   []pathVariable
+		verb   string
+	}
+
+	// pathVariable bound to tokens in range [start, end)
+	pathVariable struct {
+		fieldPath  string
+		start, end int
+	}
+)
+
+// httpRulesFromOptions of the method, returns rule with additional bindings flattened
+func httpRulesFromOptions(opts proto.Message) []httpRule {
+	if opts == nil {
+		return nil
+	}
+
+	// Extension is kept as unknown field if it's not registered, so raw message is scanned in both cases
+	raw, err := proto.MarshalOptions{Deterministic: true}.Marshal(opts)
+	if err != nil {
+		return nil
+	}
+
+	var rules []httpRule
+	for len(raw) > 0 {
+		num, typ, n := protowire.ConsumeTag(raw)
+		if n < 0 {
+			return rules
+		}
+		raw = raw[n:]
+
+		if num != httpRuleExtensionField || typ != protowire.BytesType {
+			if n = protowire.ConsumeFieldValue(num, typ, raw); n < 0 {
+				return rules
+			}
+			raw = raw[n:]
+
+			continue
+		}
+
+		v, n := protowire.ConsumeBytes(raw)
+		if n < 0 {
+			return rules
+		}
+		raw = raw[n:]
+
+		rule := parseHTTPRule(v)
+		rules = append(rules, rule)
+		rules = append(rules, rule.additional...)
+	}
+
+	return rules
+}
+
+// parseHTTPRule from google.api.HttpRule wire format
+func parseHTTPRule(b []byte) httpRule {
+	var rule httpRule
+
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return rule
+		}
+		b = b[n:]
+
+		if typ != protowire.BytesType {
+			if n = protowire.ConsumeFieldValue(num, typ, b); n < 0 {
+				return rule
+			}
+			b = b[n:]
+
+			continue
+		}
+
+		v, n := protowire.ConsumeBytes(b)
+		if n < 0 {
+			return rule
+		}
+		b = b[n:]
+
+		switch num {
+		case 2:
+			rule.method, rule.pattern = http.MethodGet, string(v)
+		case 3:
+			rule.method, rule.pattern = http.MethodPut, string(v)
+		case 4:
+			rule.method, rule.pattern = http.MethodPost, string(v)
+		case 5:
+			rule.method, rule.pattern = http.MethodDelete, string(v)
+		case 6:
+			rule.method, rule.pattern = http.MethodPatch, string(v)
+		case 7:
+			rule.body = string(v)
+		case 8:
+			rule.method, rule.pattern = parseCustomHTTPPattern(v)
+		case 11:
+			rule.additional = append(rule.additional, parseHTTPRule(v))
+		case 12:
+			rule.responseBody = string(v)
+		}
+	}
+
+	// Additional bindings can't be nested
+	for i := range rule.additional {
+		rule.additional[i].additional = nil
+	}
+
+	return rule
+}
+
+// parseCustomHTTPPattern from google.api.CustomHttpPattern wire format
+func parseCustomHTTPPattern(b []byte) (method, pattern string) {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 || typ != protowire.BytesType {
+			return method, pattern
+		}
+		b = b[n:]
+
+		v, n := protowire.ConsumeBytes(b)
+		if n < 0 {
+			return method, pattern
+		}
+		b = b[n:]
+
+		switch num {
+		case 1:
+			method = strings.ToUpper(string(v))
+		case 2:
+			pattern = string(v)
+		}
+	}
+
+	return method, pattern
+}
+
+// parsePathTemplate of google.api.http rule
+func parsePathTemplate(p string) (*pathTemplate, error) {
+	if !strings.HasPrefix(p, "/") {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidPathTemplate, p)
+	}
+
+	t := &pathTemplate{}
+	p = p[1:]
+
+	if i := strings.LastIndex(p, ":"); i >= 0 && i > strings.LastIndex(p, "/") && i > strings.LastIndex(p, "}") {
+		t.verb = p[i+1:]
+		p = p[:i]
+	}
+
+	for len(p) > 0 {
+		var segment string
+		if p[0] == '{' {
+			end := strings.IndexByte(p, '}')
+			if end < 0 {
+				return nil, fmt.Errorf("%w: not closed variable in %s", ErrInvalidPathTemplate, p)
+			}
+			segment, p = p[1:end], p[end+1:]
+
+			fieldPath, subTemplate, hasSubTemplate := strings.Cut(segment, "=")
+			if !hasSubTemplate {
+				subTemplate = "*"
+			}
+
+			v := pathVariable{fieldPath: fieldPath, start: len(t.tokens)}
+			t.tokens = append(t.tokens, strings.Split(subTemplate, "/")...)
+			v.end = len(t.tokens)
+			t.vars = append(t.vars, v)
+		} else {
+			end := strings.IndexByte(p, '/')
+			if end < 0 {
+				end = len(p)
+			}
+			segment, p = p[:end], p[end:]
+
+			t.tokens = append(t.tokens, segment)
+		}
+
+		if strings.HasPrefix(p, "/") {
+			p = p[1:]
+		} else if len(p) > 0 {
+			return nil, fmt.Errorf("%w: unexpected %s", ErrInvalidPathTemplate, p)
+		}
+	}
+
+	return t, nil
+}
+
+// match request path with template, returns values of bound variables
+func (t *pathTemplate) match(requestPath string) (map[string]string, bool) {
+	requestPath = strings.TrimPrefix(requestPath, "/")
+
+	if t.verb != "" {
+		if !strings.HasSuffix(requestPath, ":"+t.verb) {
+			return nil, false
+		}
+		requestPath = strings.TrimSuffix(requestPath, ":"+t.verb)
+	}
+
+	segments := strings.Split(requestPath, "/")
+
+	// Position of the first request segment matched by each token, last element is end of matched segments
+	positions := make([]int, len(t.tokens)+1)
+	s := 0
+	for i, token := range t.tokens {
+		positions[i] = s
+
+		switch token {
+		case "**":
+			// Matches remaining segments except the ones required by following tokens
+			s = len(segments) - (len(t.tokens) - i - 1)
+			if s < positions[i] {
+				return nil, false
+			}
+		case "*":
+			if s >= len(segments) || segments[s] == "" {
+				return nil, false
+			}
+			s++
+		default:
+			if s >= len(segments) || segments[s] != token {
+				return nil, false
+			}
+			s++
+		}
+	}
+	positions[len(t.tokens)] = s
+
+	if s != len(segments) {
+		return nil, false
+	}
+
+	values := make(map[string]string, len(t.vars))
+	for _, v := range t.vars {
+		value, err := url.PathUnescape(strings.Join(segments[positions[v.start]:positions[v.end]], "/"))
+		if err != nil {
+			return nil, false
+		}
+
+		values[v.fieldPath] = value
+	}
+
+	return values, true
+}
+
+// setMessageField by dotted field path from string value of path variable or query parameter
+func setMessageField(msg protoreflect.Message, fieldPath, value string) error {
+	names := strings.Split(fieldPath, ".")
+	for i, name := range names {
+		fields := msg.Descriptor().Fields()
+
+		fd := fields.ByName(protoreflect.Name(name))
+		if fd == nil {
+			fd = fields.ByJSONName(name)
+		}
+		if fd == nil {
+			return fmt.Errorf("unknown field %s in %s", fieldPath, msg.Descriptor().FullName())
+		}
+
+		if i < len(names)-1 {
+			if fd.Message() == nil || fd.IsList() || fd.IsMap() {
+				return fmt.Errorf("field %s in %s is not a message", name, msg.Descriptor().FullName())
+			}
+
+			msg = msg.Mutable(fd).Message()
+			continue
+		}
+
+		v, err := parseFieldValue(fd, value)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", fieldPath, err)
+		}
+
+		if fd.IsList() {
+			msg.Mutable(fd).List().Append(v)
+			return nil
+		}
+
+		msg.Set(fd, v)
+	}
+
+	return nil
+}
+
+// parseFieldValue of scalar field kind
+func parseFieldValue(fd protoreflect.FieldDescriptor, value string) (protoreflect.Value, error) {
+	if fd.IsMap() || fd.Kind() == protoreflect.MessageKind || fd.Kind() == protoreflect.GroupKind {
+		return protoreflect.Value{}, errors.New("only scalar fields can be set from path or query")
+	}
+
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(value), nil
+	case protoreflect.BoolKind:
+		v, err := strconv.ParseBool(value)
+		return protoreflect.ValueOfBool(v), err
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		v, err := strconv.ParseInt(value, 10, 32)
+		return protoreflect.ValueOfInt32(int32(v)), err
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		v, err := strconv.ParseInt(value, 10, 64)
+		return protoreflect.ValueOfInt64(v), err
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		v, err := strconv.ParseUint(value, 10, 32)
+		return protoreflect.ValueOfUint32(uint32(v)), err
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		v, err := strconv.ParseUint(value, 10, 64)
+		return protoreflect.ValueOfUint64(v), err
+	case protoreflect.FloatKind:
+		v, err := strconv.ParseFloat(value, 32)
+		return protoreflect.ValueOfFloat32(float32(v)), err
+	case protoreflect.DoubleKind:
+		v, err := strconv.ParseFloat(value, 64)
+		return protoreflect.ValueOfFloat64(v), err
+	case protoreflect.BytesKind:
+		v, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			v, err = base64.URLEncoding.DecodeString(value)
+		}
+		return protoreflect.ValueOfBytes(v), err
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByName(protoreflect.Name(value)); ev != nil {
+			return protoreflect.ValueOfEnum(ev.Number()), nil
+		}
+
+		v, err := strconv.ParseInt(value, 10, 32)
+		return protoreflect.ValueOfEnum(protoreflect.EnumNumber(v)), err
+	default:
+		return protoreflect.Value{}, fmt.Errorf("unsupported kind %s", fd.Kind())
+	}
+}
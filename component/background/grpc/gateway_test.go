@@ -0,0 +1,263 @@
+package grpc
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	brokkrhttp "github.com/Clink-n-Clank/Brokkr/component/background/http"
+)
+
+func TestGatewayTranscodesRequests(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	httpLis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	calls := make(chan RequestContextMetadata, 2)
+	captureMiddleware := func(handler RequestHandler) RequestHandler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			ctxMeta, _ := GetContextMetadata(ctx)
+			calls <- ctxMeta
+
+			return handler(ctx, req)
+		}
+	}
+
+	srv := NewServer(
+		NewServerOptionsBuilder().
+			AddListener(lis).
+			AddHTTPGateway(brokkrhttp.NewServerOptionsBuilder().AddListener(httpLis)).
+			AddHTTPGatewayHeaders("X-Unit").
+			AddCustomUnaryMiddlewares("/grpc.health.v1.Health/*", captureMiddleware),
+	)
+	go func() { _ = srv.OnStart(context.Background()) }()
+	defer func() { _ = srv.OnStop(context.Background()) }()
+
+	url := "http://" + httpLis.Addr().String() + "/grpc.health.v1.Health/Check"
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewBufferString(`{"service":""}`))
+	assert.NoError(t, err)
+	req.Header.Set("X-Unit", "gateway")
+	req.Header.Set("X-Request-Id", "unit-request")
+	req.Header.Set("Cookie", "session=secret")
+
+	code, body := doTestingRequest(t, req)
+	assert.Equal(t, http.StatusOK, code)
+	assert.JSONEq(t, `{"status":"SERVING"}`, body)
+
+	ctxMeta := <-calls
+	assert.Equal(t, "/grpc.health.v1.Health/Check", ctxMeta.FullMethod)
+	assert.Equal(t, []string{"gateway"}, ctxMeta.Meta.Get("x-unit"))
+	assert.Equal(t, []string{"unit-request"}, ctxMeta.Meta.Get("x-request-id"))
+	assert.Empty(t, ctxMeta.Meta.Get("cookie"), "not allowed header expected to be dropped")
+	assert.NotEmpty(t, ctxMeta.Peer.Address)
+
+	req, err = http.NewRequest(http.MethodPost, url, bytes.NewBufferString(`{"service":"unknown"}`))
+	assert.NoError(t, err)
+
+	code, body = doTestingRequest(t, req)
+	assert.Equal(t, http.StatusNotFound, code, "gRPC status code expected to be mapped")
+	assert.Contains(t, body, `"code":5`)
+
+	req, err = http.NewRequest(http.MethodPost, url, bytes.NewBufferString(`{"unknownField":1}`))
+	assert.NoError(t, err)
+
+	code, _ = doTestingRequest(t, req)
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	req, err = http.NewRequest(http.MethodGet, url, nil)
+	assert.NoError(t, err)
+
+	code, _ = doTestingRequest(t, req)
+	assert.Equal(t, http.StatusNotFound, code, "default route expected to accept only POST")
+}
+
+func TestGatewayWithMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestingCA(t)
+
+	writeTestingCertificate(t, dir, "server", ca.issue(t, "localhost", 1))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "ca.pem"), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.cert.Raw}), 0o600))
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	httpLis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	peers := make(chan *PeerIdentity, 1)
+	capturePeer := func(handler RequestHandler) RequestHandler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			ctxMeta, _ := GetContextMetadata(ctx)
+			peers <- ctxMeta.Peer
+
+			return handler(ctx, req)
+		}
+	}
+
+	srv := NewServer(
+		NewServerOptionsBuilder().
+			AddListener(lis).
+			AddTLSCertificate(filepath.Join(dir, "server.pem"), filepath.Join(dir, "server.key")).
+			AddTLSClientCA(filepath.Join(dir, "ca.pem")).
+			AddHTTPGateway(brokkrhttp.NewServerOptionsBuilder().AddListener(httpLis)).
+			AddCustomUnaryMiddlewares("*", capturePeer),
+	)
+	go func() { _ = srv.OnStart(context.Background()) }()
+	defer func() { _ = srv.OnStop(context.Background()) }()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	post := func(scheme string, certs ...tls.Certificate) (*http.Response, error) {
+		client := &http.Client{
+			Timeout: 5 * time.Second,
+			Transport: &http.Transport{
+				TLSClientConfig:   &tls.Config{RootCAs: roots, ServerName: "localhost", Certificates: certs},
+				ForceAttemptHTTP2: true,
+			},
+		}
+
+		return client.Post(scheme+"://"+httpLis.Addr().String()+"/grpc.health.v1.Health/Check", "application/json", bytes.NewBufferString(`{}`))
+	}
+
+	// Plain HTTP client doesn't bypass mTLS of gRPC server
+	if resp, err := post("http"); err == nil {
+		assert.NotEqual(t, http.StatusOK, resp.StatusCode)
+		_ = resp.Body.Close()
+	}
+
+	_, err = post("https")
+	assert.Error(t, err, "client certificate expected to be required")
+
+	resp, err := post("https", ca.issue(t, "allowed-client", 10))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, 2, resp.ProtoMajor, "gateway expected to serve HTTP/2 over TLS")
+	_ = resp.Body.Close()
+
+	identity := <-peers
+	assert.True(t, identity.IsVerified)
+	assert.Equal(t, "allowed-client", identity.CommonName)
+}
+
+func TestGatewayAnnotatedRoute(t *testing.T) {
+	srv := NewServer(NewServerOptionsBuilder().AddListener(&net.TCPListener{}))
+	srv.health.SetServingStatus("unit", grpc_health_v1.HealthCheckResponse_NOT_SERVING)
+
+	template, err := parsePathTemplate("/v1/health/{service=services/*}:check")
+	assert.NoError(t, err)
+
+	srv.gateway.routes = []gatewayRoute{{
+		httpMethod: http.MethodGet,
+		template:   template,
+		fullMethod: "/grpc.health.v1.Health/Check",
+		method:     grpc_health_v1.Health_ServiceDesc.Methods[0],
+		impl:       srv.health,
+	}}
+
+	rec := httptest.NewRecorder()
+	srv.GatewayHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/health/services/unit:check", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code, "service name is bound with services/ prefix")
+
+	srv.health.SetServingStatus("services/unit", grpc_health_v1.HealthCheckResponse_SERVING)
+
+	rec = httptest.NewRecorder()
+	srv.GatewayHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/health/services/unit:check", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"status":"SERVING"}`, rec.Body.String())
+}
+
+func TestPathTemplateMatch(t *testing.T) {
+	tests := []struct {
+		template string
+		path     string
+		values   map[string]string
+		isMatch  bool
+	}{
+		{"/v1/users/{id}", "/v1/users/42", map[string]string{"id": "42"}, true},
+		{"/v1/users/{id}", "/v1/users/42/books", nil, false},
+		{"/v1/{name=shelves/*/books/*}", "/v1/shelves/1/books/2", map[string]string{"name": "shelves/1/books/2"}, true},
+		{"/v1/{name=files/**}", "/v1/files/a/b/c", map[string]string{"name": "files/a/b/c"}, true},
+		{"/v1/{name=files/**}/meta", "/v1/files/a/b/meta", map[string]string{"name": "files/a/b"}, true},
+		{"/v1/users/{id}:activate", "/v1/users/42:activate", map[string]string{"id": "42"}, true},
+		{"/v1/users/{id}:activate", "/v1/users/42", nil, false},
+		{"/v1/users/{id}", "/v1/users/a%2Fb", map[string]string{"id": "a/b"}, true},
+	}
+
+	for _, tt := range tests {
+		template, err := parsePathTemplate(tt.template)
+		assert.NoError(t, err)
+
+		values, isMatch := template.match(tt.path)
+		assert.Equal(t, tt.isMatch, isMatch, "%s -> %s", tt.template, tt.path)
+		if tt.isMatch {
+			assert.Equal(t, tt.values, values)
+		}
+	}
+
+	_, err := parsePathTemplate("v1/{id")
+	assert.ErrorIs(t, err, ErrInvalidPathTemplate)
+}
+
+func TestHTTPRulesFromOptions(t *testing.T) {
+	var binding []byte
+	binding = protowire.AppendTag(binding, 6, protowire.BytesType)
+	binding = protowire.AppendString(binding, "/v1/users/{id}")
+	binding = protowire.AppendTag(binding, 7, protowire.BytesType)
+	binding = protowire.AppendString(binding, "user")
+
+	var rule []byte
+	rule = protowire.AppendTag(rule, 2, protowire.BytesType)
+	rule = protowire.AppendString(rule, "/v1/users/{id}")
+	rule = protowire.AppendTag(rule, 11, protowire.BytesType)
+	rule = protowire.AppendBytes(rule, binding)
+
+	var raw []byte
+	raw = protowire.AppendTag(raw, httpRuleExtensionField, protowire.BytesType)
+	raw = protowire.AppendBytes(raw, rule)
+
+	opts := &descriptorpb.MethodOptions{}
+	assert.NoError(t, proto.Unmarshal(raw, opts))
+
+	rules := httpRulesFromOptions(opts)
+	assert.Len(t, rules, 2)
+	assert.Equal(t, http.MethodGet, rules[0].method)
+	assert.Equal(t, "/v1/users/{id}", rules[0].pattern)
+	assert.Equal(t, http.MethodPatch, rules[1].method)
+	assert.Equal(t, "user", rules[1].body)
+}
+
+func TestHTTPStatusFromCode(t *testing.T) {
+	assert.Equal(t, http.StatusOK, HTTPStatusFromCode(codes.OK))
+	assert.Equal(t, http.StatusUnauthorized, HTTPStatusFromCode(codes.Unauthenticated))
+	assert.Equal(t, http.StatusTooManyRequests, HTTPStatusFromCode(codes.ResourceExhausted))
+	assert.Equal(t, http.StatusInternalServerError, HTTPStatusFromCode(codes.DataLoss))
+}
+
+func doTestingRequest(t *testing.T, req *http.Request) (int, string) {
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+
+	return resp.StatusCode, string(body)
+}
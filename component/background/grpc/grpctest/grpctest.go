@@ -0,0 +1,48 @@
+package grpctest
+
+import (
+	"context"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	brokkrgrpc "github.com/Clink-n-Clank/Brokkr/component/background/grpc"
+)
+
+// TransportStream captures headers that middleware or handler sets with grpc.SetHeader
+type TransportStream struct {
+	grpc.ServerTransportStream
+	header metadata.MD
+
+	sync.Mutex
+}
+
+// NewContext of unary call with the same metadata that middlewares get from server, returned stream captures headers
+func NewContext(fullMethod string, md metadata.MD) (context.Context, *TransportStream) {
+	ts := &TransportStream{}
+
+	ctx := grpc.NewContextWithServerTransportStream(context.Background(), ts)
+	ctx = metadata.NewIncomingContext(ctx, md)
+	ctx = brokkrgrpc.NewMiddlewareComposer().ExtendContext(ctx, brokkrgrpc.RequestContextMetadata{FullMethod: fullMethod, Meta: md})
+
+	return ctx, ts
+}
+
+// SetHeader metadata, headers of multiple calls are joined
+func (s *TransportStream) SetHeader(md metadata.MD) error {
+	s.Lock()
+	defer s.Unlock()
+
+	s.header = metadata.Join(s.header, md)
+
+	return nil
+}
+
+// Header captured from calls of SetHeader
+func (s *TransportStream) Header() metadata.MD {
+	s.Lock()
+	defer s.Unlock()
+
+	return s.header.Copy()
+}
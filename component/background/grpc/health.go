@@ -0,0 +1,178 @@
+package grpc
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/Clink-n-Clank/Brokkr/component/execution"
+)
+
+// OverallHealthService name of the service that reports overall status of the server derived from critical checks
+const OverallHealthService = ""
+
+type (
+	// HealthCheck of depended service that is periodically re-evaluated
+	HealthCheck struct {
+		// Service name reported in health server
+		Service string
+		// Check returns current status, context is canceled after timeout
+		Check func(ctx context.Context) grpc_health_v1.HealthCheckResponse_ServingStatus
+		// Timeout of single check, default timeout of the monitor used if not set
+		Timeout time.Duration
+		// IsCritical check affects overall status of the server
+		IsCritical bool
+	}
+
+	// healthMonitor re-evaluates health checks and updates health server when results change
+	healthMonitor struct {
+		health   *health.Server
+		checks   []HealthCheck
+		interval time.Duration
+		timeout  time.Duration
+
+		state healthMonitorState
+	}
+
+	// healthMonitorState of last results
+	healthMonitorState struct {
+		statuses map[string]grpc_health_v1.HealthCheckResponse_ServingStatus
+		stopped  chan struct{}
+		stopOnce sync.Once
+
+		sync.Mutex
+	}
+)
+
+func newHealthMonitor(h *health.Server) *healthMonitor {
+	return &healthMonitor{
+		health:   h,
+		interval: 10 * time.Second,
+		timeout:  time.Second,
+		state: healthMonitorState{
+			statuses: map[string]grpc_health_v1.HealthCheckResponse_ServingStatus{},
+			stopped:  make(chan struct{}),
+		},
+	}
+}
+
+// addChecks to the monitor
+func (m *healthMonitor) addChecks(checks ...HealthCheck) {
+	m.checks = append(m.checks, checks...)
+}
+
+// run checks on interval until monitor is stopped
+func (m *healthMonitor) run() {
+	if len(m.checks) == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.state.stopped:
+			return
+		case <-ticker.C:
+			m.evaluate()
+		}
+	}
+}
+
+// stop periodic evaluation
+func (m *healthMonitor) stop() {
+	m.state.stopOnce.Do(func() { close(m.state.stopped) })
+}
+
+// evaluate all checks at once, updates health server only with changed statuses
+func (m *healthMonitor) evaluate() {
+	if len(m.checks) == 0 {
+		return
+	}
+
+	results := make([]grpc_health_v1.HealthCheckResponse_ServingStatus, len(m.checks))
+
+	var wg sync.WaitGroup
+	for i, c := range m.checks {
+		wg.Add(1)
+		go func(i int, c HealthCheck) {
+			defer wg.Done()
+			results[i] = m.check(c)
+		}(i, c)
+	}
+	wg.Wait()
+
+	overall := grpc_health_v1.HealthCheckResponse_SERVING
+	for i, c := range m.checks {
+		m.setStatus(c.Service, results[i])
+
+		if c.IsCritical && results[i] != grpc_health_v1.HealthCheckResponse_SERVING {
+			overall = grpc_health_v1.HealthCheckResponse_NOT_SERVING
+		}
+	}
+
+	m.setStatus(OverallHealthService, overall)
+}
+
+// check with timeout, check that didn't respond in time or panicked is not serving
+func (m *healthMonitor) check(c HealthCheck) (status grpc_health_v1.HealthCheckResponse_ServingStatus) {
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = m.timeout
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			status = grpc_health_v1.HealthCheckResponse_NOT_SERVING
+		}
+	}()
+
+	checkCtx, checkCtxCancel := context.WithTimeout(context.Background(), timeout)
+	defer checkCtxCancel()
+
+	// Result is read only when check is finished in time, late check result is dropped
+	var checkStatus grpc_health_v1.HealthCheckResponse_ServingStatus
+	if err := execution.RunWithTimeout(checkCtx, timeout, func() error {
+		checkStatus = c.Check(checkCtx)
+		return nil
+	}); err != nil {
+		return grpc_health_v1.HealthCheckResponse_NOT_SERVING
+	}
+
+	return checkStatus
+}
+
+// setStatus in health server if it's changed, so watchers are notified only about changes
+func (m *healthMonitor) setStatus(service string, status grpc_health_v1.HealthCheckResponse_ServingStatus) {
+	m.state.Lock()
+	defer m.state.Unlock()
+
+	if prev, exist := m.state.statuses[service]; exist && prev == status {
+		return
+	}
+
+	m.state.statuses[service] = status
+	m.health.SetServingStatus(service, status)
+}
+
+// status of the service from last evaluation
+func (m *healthMonitor) status(service string) (grpc_health_v1.HealthCheckResponse_ServingStatus, bool) {
+	m.state.Lock()
+	defer m.state.Unlock()
+
+	status, exist := m.state.statuses[service]
+
+	return status, exist
+}
+
+// reset last results, so all statuses will be set again on next evaluation
+func (m *healthMonitor) reset() {
+	m.state.Lock()
+	defer m.state.Unlock()
+
+	m.state.statuses = map[string]grpc_health_v1.HealthCheckResponse_ServingStatus{}
+}
@@ -0,0 +1,67 @@
+package grpc
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestHealthMonitorReEvaluatesChecks(t *testing.T) {
+	var isHealthy atomic.Bool
+	isHealthy.Store(true)
+
+	checks := []HealthCheck{
+		{
+			Service:    "unit.critical",
+			IsCritical: true,
+			Check: func(context.Context) grpc_health_v1.HealthCheckResponse_ServingStatus {
+				if isHealthy.Load() {
+					return grpc_health_v1.HealthCheckResponse_SERVING
+				}
+
+				return grpc_health_v1.HealthCheckResponse_NOT_SERVING
+			},
+		},
+		{
+			Service: "unit.slow",
+			Timeout: 10 * time.Millisecond,
+			Check: func(ctx context.Context) grpc_health_v1.HealthCheckResponse_ServingStatus {
+				<-ctx.Done()
+				return grpc_health_v1.HealthCheckResponse_SERVING
+			},
+		},
+	}
+
+	srv, conn := startTestServer(t, NewServerOptionsBuilder().AddHealthChecks(checks...).AddHealthCheckInterval(20*time.Millisecond))
+	defer func() { _ = srv.OnStop(context.Background()) }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	watch, err := grpc_health_v1.NewHealthClient(conn).Watch(ctx, &grpc_health_v1.HealthCheckRequest{Service: OverallHealthService})
+	assert.NoError(t, err)
+
+	resp, err := watch.Recv()
+	assert.NoError(t, err)
+	assert.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, resp.GetStatus())
+
+	slowStatus, isExist := srv.HealthStatus("unit.slow")
+	assert.True(t, isExist)
+	assert.Equal(t, grpc_health_v1.HealthCheckResponse_NOT_SERVING, slowStatus, "check that timed out is not serving")
+
+	isHealthy.Store(false)
+
+	resp, err = watch.Recv()
+	assert.NoError(t, err)
+	assert.Equal(t, grpc_health_v1.HealthCheckResponse_NOT_SERVING, resp.GetStatus())
+
+	isHealthy.Store(true)
+
+	resp, err = watch.Recv()
+	assert.NoError(t, err)
+	assert.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, resp.GetStatus())
+}
@@ -0,0 +1,316 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"time"
+
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	brokkrgrpc "github.com/Clink-n-Clank/Brokkr/component/background/grpc"
+)
+
+const (
+	// DefaultMetadataKey of idempotency key
+	DefaultMetadataKey = "idempotency-key"
+	// ReplayedMetadataKey of response header that is set when stored result is replayed
+	ReplayedMetadataKey = "idempotency-replayed"
+)
+
+type (
+	// idempotency of unary calls
+	idempotency struct {
+		store        Store
+		metadataKey  string
+		ttl          time.Duration
+		inFlightTTL  time.Duration
+		pollInterval time.Duration
+		releasedCode map[codes.Code]struct{}
+	}
+
+	// Option for idempotency middleware
+	Option func(i *idempotency)
+)
+
+// SetMetadataKey of idempotency key, "idempotency-key" by default
+func SetMetadataKey(key string) Option {
+	return func(i *idempotency) {
+		i.metadataKey = key
+	}
+}
+
+// SetTTL of stored results, 24 hours by default
+func SetTTL(ttl time.Duration) Option {
+	return func(i *idempotency) {
+		i.ttl = ttl
+	}
+}
+
+// SetInFlightTTL of reservation, it's renewed while the call is running and expires when process is stopped during the call,
+// 1 minute by default
+func SetInFlightTTL(ttl time.Duration) Option {
+	return func(i *idempotency) {
+		i.inFlightTTL = ttl
+	}
+}
+
+// SetWaitForInFlight duplicates until first call is finished by polling the store,
+// duplicates are rejected with Aborted by default
+func SetWaitForInFlight(pollInterval time.Duration) Option {
+	return func(i *idempotency) {
+		i.pollInterval = pollInterval
+	}
+}
+
+// SetReleasedCodes of errors that are not stored, so call can be retried with the same key,
+// Unavailable, DeadlineExceeded, Canceled, Aborted and ResourceExhausted by default
+func SetReleasedCodes(cs ...codes.Code) Option {
+	return func(i *idempotency) {
+		i.releasedCode = map[codes.Code]struct{}{}
+		for _, c := range cs {
+			i.releasedCode[c] = struct{}{}
+		}
+	}
+}
+
+// Middleware that stores first result of unary call by idempotency key and method, replays return stored result.
+// Calls without idempotency key are not affected
+func Middleware(store Store, opts ...Option) brokkrgrpc.Middleware {
+	i := &idempotency{
+		store:       store,
+		metadataKey: DefaultMetadataKey,
+		ttl:         24 * time.Hour,
+		inFlightTTL: time.Minute,
+		releasedCode: map[codes.Code]struct{}{
+			codes.Unavailable:       {},
+			codes.DeadlineExceeded:  {},
+			codes.Canceled:          {},
+			codes.Aborted:           {},
+			codes.ResourceExhausted: {},
+		},
+	}
+
+	for _, o := range opts {
+		o(i)
+	}
+
+	return func(handler brokkrgrpc.RequestHandler) brokkrgrpc.RequestHandler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			ctxMeta, _ := brokkrgrpc.GetContextMetadata(ctx)
+
+			key := idempotencyKey(ctx, ctxMeta, i.metadataKey)
+			if key == "" {
+				return handler(ctx, req)
+			}
+
+			return i.call(ctx, ctxMeta.FullMethod+"|"+key, req, handler)
+		}
+	}
+}
+
+// call handler once per key
+func (i *idempotency) call(ctx context.Context, key string, req interface{}, handler brokkrgrpc.RequestHandler) (interface{}, error) {
+	hash, err := requestHash(req)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "idempotency: %s", err)
+	}
+
+	for {
+		existing, isReserved, reserveErr := i.store.Reserve(ctx, key, Record{RequestHash: hash}, i.inFlightTTL)
+		if reserveErr != nil {
+			return nil, status.Errorf(codes.Unavailable, "idempotency: %s", reserveErr)
+		}
+
+		if isReserved {
+			break
+		}
+
+		if !bytes.Equal(existing.RequestHash, hash) {
+			return nil, status.Error(codes.InvalidArgument, "idempotency key is already used with different request")
+		}
+
+		if existing.IsCompleted {
+			return replay(ctx, existing)
+		}
+
+		if i.pollInterval <= 0 {
+			return nil, status.Error(codes.Aborted, "request with the same idempotency key is in progress")
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, status.FromContextError(ctx.Err()).Err()
+		case <-time.After(i.pollInterval):
+		}
+	}
+
+	resp, err := i.handle(ctx, key, req, handler)
+
+	// Context of the call could be already canceled, but reservation must be finished anyway
+	storeCtx := context.Background()
+
+	r, isStored := i.record(hash, resp, err)
+	if !isStored {
+		_ = i.store.Release(storeCtx, key)
+		return resp, err
+	}
+
+	// Result is returned even if it's not stored, the call already had its effect
+	_ = i.store.Complete(storeCtx, key, r, i.ttl)
+
+	return resp, err
+}
+
+// handle request while reservation of the key is renewed, panic of the handler releases the key before it's propagated,
+// so the call could be retried
+func (i *idempotency) handle(ctx context.Context, key string, req interface{}, handler brokkrgrpc.RequestHandler) (interface{}, error) {
+	stopRenew := i.keepReservation(key)
+	defer func() {
+		stopRenew()
+
+		if v := recover(); v != nil {
+			_ = i.store.Release(context.Background(), key)
+			panic(v)
+		}
+	}()
+
+	return handler(ctx, req)
+}
+
+// keepReservation renewed while handler is running, so retry doesn't run long call twice, returns function to stop renewal
+func (i *idempotency) keepReservation(key string) func() {
+	interval := i.inFlightTTL / 3
+	if interval <= 0 {
+		return func() {}
+	}
+
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				ctx, ctxCancel := context.WithTimeout(context.Background(), i.inFlightTTL)
+				_ = i.store.Renew(ctx, key, i.inFlightTTL)
+				ctxCancel()
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(stop)
+		<-stopped
+	}
+}
+
+// record of the call result, false is returned when result must not be stored
+func (i *idempotency) record(hash []byte, resp interface{}, err error) (Record, bool) {
+	r := Record{RequestHash: hash, IsCompleted: true}
+
+	if err != nil {
+		s := status.Convert(err)
+		if _, isReleased := i.releasedCode[s.Code()]; isReleased {
+			return r, false
+		}
+
+		raw, marshalErr := proto.Marshal(s.Proto())
+		if marshalErr != nil {
+			return r, false
+		}
+
+		r.Status = raw
+
+		return r, true
+	}
+
+	msg, isProto := resp.(proto.Message)
+	if !isProto {
+		return r, false
+	}
+
+	a, anyErr := anypb.New(msg)
+	if anyErr != nil {
+		return r, false
+	}
+
+	raw, marshalErr := proto.Marshal(a)
+	if marshalErr != nil {
+		return r, false
+	}
+
+	r.Response = raw
+
+	return r, true
+}
+
+// replay stored result of the call
+func replay(ctx context.Context, r Record) (interface{}, error) {
+	_ = grpc.SetHeader(ctx, metadata.Pairs(ReplayedMetadataKey, "true"))
+
+	if len(r.Status) > 0 {
+		s := &spb.Status{}
+		if err := proto.Unmarshal(r.Status, s); err != nil {
+			return nil, status.Errorf(codes.Internal, "idempotency: %s", err)
+		}
+
+		return nil, status.ErrorProto(s)
+	}
+
+	a := &anypb.Any{}
+	if err := proto.Unmarshal(r.Response, a); err != nil {
+		return nil, status.Errorf(codes.Internal, "idempotency: %s", err)
+	}
+
+	resp, err := a.UnmarshalNew()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "idempotency: %s", err)
+	}
+
+	return resp, nil
+}
+
+// idempotencyKey from metadata of the request
+func idempotencyKey(ctx context.Context, ctxMeta brokkrgrpc.RequestContextMetadata, metadataKey string) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if ctxMeta.Meta != nil {
+		md = ctxMeta.Meta
+	}
+
+	if values := md.Get(metadataKey); len(values) > 0 {
+		return values[0]
+	}
+
+	return ""
+}
+
+// requestHash of deterministic wire format of the request
+func requestHash(req interface{}) ([]byte, error) {
+	msg, isProto := req.(proto.Message)
+	if !isProto {
+		return nil, nil
+	}
+
+	raw, err := proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	hash := sha256.Sum256(raw)
+
+	return hash[:], nil
+}
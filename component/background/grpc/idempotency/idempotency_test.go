@@ -0,0 +1,205 @@
+package idempotency
+
+import (
+	"context"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/Clink-n-Clank/Brokkr/component/background/grpc/grpctest"
+)
+
+func testingContext(fullMethod, key string) (context.Context, *grpctest.TransportStream) {
+	meta := metadata.MD{}
+	if key != "" {
+		meta = metadata.Pairs(DefaultMetadataKey, key)
+	}
+
+	return grpctest.NewContext(fullMethod, meta)
+}
+
+func TestMiddleware_Replay(t *testing.T) {
+	var calls int32
+	handler := Middleware(NewMemoryStore())(func(ctx context.Context, req interface{}) (interface{}, error) {
+		n := atomic.AddInt32(&calls, 1)
+		return &errdetails.ErrorInfo{Reason: "CREATED", Metadata: map[string]string{"call": strconv.Itoa(int(n))}}, nil
+	})
+
+	req := &errdetails.RequestInfo{RequestId: "order-1"}
+
+	ctx, _ := testingContext("/svc.Orders/Create", "k-1")
+	first, err := handler(ctx, req)
+	assert.NoError(t, err)
+
+	ctx, ts := testingContext("/svc.Orders/Create", "k-1")
+	replayed, err := handler(ctx, proto.Clone(req))
+	assert.NoError(t, err)
+	assert.True(t, proto.Equal(first.(proto.Message), replayed.(proto.Message)))
+	assert.Equal(t, []string{"true"}, ts.Header().Get(ReplayedMetadataKey))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	// Same key with different request is rejected
+	ctx, _ = testingContext("/svc.Orders/Create", "k-1")
+	_, err = handler(ctx, &errdetails.RequestInfo{RequestId: "order-2"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// Keys are scoped by method and calls without key are not affected
+	ctx, _ = testingContext("/svc.Orders/Update", "k-1")
+	_, err = handler(ctx, req)
+	assert.NoError(t, err)
+
+	ctx, _ = testingContext("/svc.Orders/Create", "")
+	_, _ = handler(ctx, req)
+	_, _ = handler(ctx, req)
+	assert.Equal(t, int32(4), atomic.LoadInt32(&calls))
+}
+
+func TestMiddleware_Errors(t *testing.T) {
+	var calls int32
+	code := codes.Unavailable
+	handler := Middleware(NewMemoryStore())(func(ctx context.Context, req interface{}) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		return nil, status.Error(code, "failed")
+	})
+
+	req := &errdetails.RequestInfo{RequestId: "order-1"}
+
+	// Transient errors are not stored
+	ctx, _ := testingContext("/svc.Orders/Create", "k-1")
+	_, err := handler(ctx, req)
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+
+	code = codes.NotFound
+	_, err = handler(ctx, req)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	code = codes.Internal
+	_, err = handler(ctx, req)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	assert.Equal(t, "failed", status.Convert(err).Message())
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestMiddleware_InFlight(t *testing.T) {
+	for name, opts := range map[string][]Option{"reject": nil, "wait": {SetWaitForInFlight(time.Millisecond)}} {
+		t.Run(name, func(t *testing.T) {
+			inHandler, unblock := make(chan struct{}), make(chan struct{})
+			handler := Middleware(NewMemoryStore(), opts...)(func(ctx context.Context, req interface{}) (interface{}, error) {
+				inHandler <- struct{}{}
+				<-unblock
+				return &errdetails.ErrorInfo{Reason: "CREATED"}, nil
+			})
+
+			req := &errdetails.RequestInfo{RequestId: "order-1"}
+			ctx, _ := testingContext("/svc.Orders/Create", "k-1")
+
+			go func() { _, _ = handler(ctx, req) }()
+			<-inHandler
+
+			type result struct {
+				resp interface{}
+				err  error
+			}
+			results := make(chan result, 1)
+			go func() {
+				resp, err := handler(ctx, req)
+				results <- result{resp, err}
+			}()
+
+			if name == "reject" {
+				res := <-results
+				assert.Equal(t, codes.Aborted, status.Code(res.err))
+				close(unblock)
+
+				return
+			}
+
+			close(unblock)
+			res := <-results
+			assert.NoError(t, res.err)
+			assert.Equal(t, "CREATED", res.resp.(*errdetails.ErrorInfo).GetReason())
+		})
+	}
+}
+
+func TestMiddleware_LongCallKeepsReservation(t *testing.T) {
+	var calls int32
+	inHandler, unblock := make(chan struct{}), make(chan struct{})
+	handler := Middleware(NewMemoryStore(), SetInFlightTTL(15*time.Millisecond))(func(ctx context.Context, req interface{}) (interface{}, error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			inHandler <- struct{}{}
+			<-unblock
+		}
+
+		return &errdetails.ErrorInfo{Reason: "CREATED"}, nil
+	})
+
+	req := &errdetails.RequestInfo{RequestId: "order-1"}
+	ctx, _ := testingContext("/svc.Orders/Create", "k-1")
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = handler(ctx, req)
+	}()
+	<-inHandler
+
+	// Call runs longer than in-flight ttl, retry must not run it again
+	time.Sleep(50 * time.Millisecond)
+	_, err := handler(ctx, req)
+	assert.Equal(t, codes.Aborted, status.Code(err))
+
+	close(unblock)
+	<-done
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestMemoryStore_Sweep(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore()
+
+	assert.NoError(t, s.Complete(ctx, "a", Record{IsCompleted: true}, 10*time.Millisecond))
+	assert.NoError(t, s.Complete(ctx, "b", Record{IsCompleted: true}, 10*time.Millisecond))
+	assert.Equal(t, 2, s.Len(), "expired keys are not swept more often than once per ttl")
+
+	time.Sleep(20 * time.Millisecond)
+	assert.NoError(t, s.Complete(ctx, "c", Record{IsCompleted: true}, 10*time.Millisecond))
+	assert.Equal(t, 1, s.Len())
+
+	// Completed record is not renewed
+	assert.NoError(t, s.Renew(ctx, "c", time.Hour))
+	time.Sleep(20 * time.Millisecond)
+	_, isReserved, err := s.Reserve(ctx, "c", Record{}, time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, isReserved)
+}
+
+func TestMiddleware_PanicReleasesKey(t *testing.T) {
+	var calls int32
+	handler := Middleware(NewMemoryStore(), SetInFlightTTL(30*time.Millisecond))(func(ctx context.Context, req interface{}) (interface{}, error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			panic("boom")
+		}
+
+		return &errdetails.ErrorInfo{Reason: "CREATED"}, nil
+	})
+
+	req := &errdetails.RequestInfo{RequestId: "order-1"}
+	ctx, _ := testingContext("/svc.Orders/Create", "k-1")
+
+	assert.Panics(t, func() { _, _ = handler(ctx, req) })
+
+	// Retry runs the call again instead of waiting for reservation that is never finished
+	resp, err := handler(ctx, req)
+	assert.NoError(t, err)
+	assert.Equal(t, "CREATED", resp.(*errdetails.ErrorInfo).GetReason())
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
@@ -0,0 +1,125 @@
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type (
+	// Record of the call by idempotency key
+	Record struct {
+		// RequestHash of the request, the key can't be reused with different request
+		RequestHash []byte `json:"request_hash"`
+		// IsCompleted when call is finished, otherwise it's in-flight
+		IsCompleted bool `json:"is_completed"`
+		// Response marshaled as google.protobuf.Any
+		Response []byte `json:"response,omitempty"`
+		// Status marshaled as google.rpc.Status when call failed
+		Status []byte `json:"status,omitempty"`
+	}
+
+	// Store of idempotency records until ttl, Reserve must be atomic, so only one call of the key runs across replicas
+	Store interface {
+		// Reserve key with in-flight record if key doesn't exist, otherwise existing record is returned
+		Reserve(ctx context.Context, key string, r Record, ttl time.Duration) (existing Record, isReserved bool, err error)
+		// Renew in-flight reservation of the key for next ttl while call is running
+		Renew(ctx context.Context, key string, ttl time.Duration) error
+		// Complete key with result of the call
+		Complete(ctx context.Context, key string, r Record, ttl time.Duration) error
+		// Release key, so call can be retried with the same key
+		Release(ctx context.Context, key string) error
+	}
+
+	// memoryRecord of the key
+	memoryRecord struct {
+		record    Record
+		expiresAt time.Time
+	}
+)
+
+// MemoryStore keeps idempotency records in process memory, retry routed to another replica runs the call again
+type MemoryStore struct {
+	mu        sync.Mutex
+	records   map[string]memoryRecord
+	lastSweep time.Time
+}
+
+// NewMemoryStore instance
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{records: map[string]memoryRecord{}}
+}
+
+// Reserve key with in-flight record if key doesn't exist, otherwise existing record is returned
+func (m *MemoryStore) Reserve(_ context.Context, key string, r Record, ttl time.Duration) (Record, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	if existing, isExist := m.records[key]; isExist && now.Before(existing.expiresAt) {
+		return existing.record, false, nil
+	}
+
+	m.records[key] = memoryRecord{record: r, expiresAt: now.Add(ttl)}
+
+	return Record{}, true, nil
+}
+
+// Renew in-flight reservation of the key for next ttl, completed and expired keys are not changed
+func (m *MemoryStore) Renew(_ context.Context, key string, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	if existing, isExist := m.records[key]; isExist && !existing.record.IsCompleted && now.Before(existing.expiresAt) {
+		existing.expiresAt = now.Add(ttl)
+		m.records[key] = existing
+	}
+
+	return nil
+}
+
+// Complete key with result of the call, expired keys are removed from time to time
+func (m *MemoryStore) Complete(_ context.Context, key string, r Record, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	m.sweep(now, ttl)
+
+	m.records[key] = memoryRecord{record: r, expiresAt: now.Add(ttl)}
+
+	return nil
+}
+
+// Release key, so call can be retried with the same key
+func (m *MemoryStore) Release(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.records, key)
+
+	return nil
+}
+
+// Len of the keys in the store including expired ones that are not removed yet
+func (m *MemoryStore) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return len(m.records)
+}
+
+// sweep expired records not more often than once per ttl
+func (m *MemoryStore) sweep(now time.Time, ttl time.Duration) {
+	if now.Sub(m.lastSweep) < ttl {
+		return
+	}
+
+	m.lastSweep = now
+	for key, r := range m.records {
+		if !now.Before(r.expiresAt) {
+			delete(m.records, key)
+		}
+	}
+}
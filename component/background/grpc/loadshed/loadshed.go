@@ -0,0 +1,144 @@
+package loadshed
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	brokkrgrpc "github.com/Clink-n-Clank/Brokkr/component/background/grpc"
+	"github.com/Clink-n-Clank/Brokkr/component/background/route"
+	"github.com/Clink-n-Clank/Brokkr/component/behavior/concurrency"
+)
+
+// healthCheckFilter of gRPC health service, it's critical by default
+const healthCheckFilter = "/grpc.health.v1.Health/*"
+
+type (
+	// methodPriority registered for middleware filter
+	methodPriority struct {
+		matcher  route.Matcher
+		priority concurrency.Priority
+	}
+
+	// shedder of unary and streaming requests
+	shedder struct {
+		limiter    *concurrency.Limiter
+		priorities []methodPriority
+		onShed     func(fullMethod string, priority concurrency.Priority)
+	}
+
+	// Option for load shedding middleware
+	Option func(s *shedder)
+)
+
+// SetMethodPriority of methods matched by filter, first registered filter wins, other methods have normal priority,
+// panics on invalid filter
+func SetMethodPriority(filter string, priority concurrency.Priority) Option {
+	return func(s *shedder) {
+		s.priorities = append(s.priorities, methodPriority{matcher: route.MustCompile(filter), priority: priority})
+	}
+}
+
+// SetShedHandler that is called each time request is shed
+func SetShedHandler(h func(fullMethod string, priority concurrency.Priority)) Option {
+	return func(s *shedder) {
+		s.onShed = h
+	}
+}
+
+// Middleware that sheds unary requests with Unavailable status when concurrency limit is reached,
+// deadline exceeded requests are reported to limiter as drops
+func Middleware(l *concurrency.Limiter, opts ...Option) brokkrgrpc.Middleware {
+	s := newShedder(l, opts...)
+
+	return func(handler brokkrgrpc.RequestHandler) brokkrgrpc.RequestHandler {
+		return func(ctx context.Context, req interface{}) (resp interface{}, err error) {
+			release, err := s.acquire(ctx)
+			if err != nil {
+				return nil, err
+			}
+			defer func() {
+				// Panic is reported as dropped request, so slot is not leaked when panic is recovered by outer middleware
+				if v := recover(); v != nil {
+					release(true)
+					panic(v)
+				}
+
+				release(isDropped(err))
+			}()
+
+			return handler(ctx, req)
+		}
+	}
+}
+
+// StreamMiddleware that sheds streams with Unavailable status when concurrency limit is reached,
+// stream holds the slot until handler returns, so long-lived streams should have low priority or own limiter
+func StreamMiddleware(l *concurrency.Limiter, opts ...Option) brokkrgrpc.StreamMiddleware {
+	s := newShedder(l, opts...)
+
+	return func(handler brokkrgrpc.StreamHandler) brokkrgrpc.StreamHandler {
+		return func(srv interface{}, stream grpc.ServerStream) (err error) {
+			release, err := s.acquire(stream.Context())
+			if err != nil {
+				return err
+			}
+			defer func() {
+				// Panic is reported as dropped request, so slot is not leaked when panic is recovered by outer middleware
+				if v := recover(); v != nil {
+					release(true)
+					panic(v)
+				}
+
+				release(isDropped(err))
+			}()
+
+			return handler(srv, stream)
+		}
+	}
+}
+
+// newShedder with options, health checks are critical unless other priority is set
+func newShedder(l *concurrency.Limiter, opts ...Option) *shedder {
+	s := &shedder{limiter: l, onShed: func(string, concurrency.Priority) {}}
+	for _, o := range opts {
+		o(s)
+	}
+
+	s.priorities = append(s.priorities, methodPriority{matcher: route.MustCompile(healthCheckFilter), priority: concurrency.PriorityCritical})
+
+	return s
+}
+
+// acquire slot for the method of request, shed request returns Unavailable status
+func (s *shedder) acquire(ctx context.Context) (concurrency.ReleaseFunc, error) {
+	ctxMeta, _ := brokkrgrpc.GetContextMetadata(ctx)
+	priority := s.priority(ctxMeta.FullMethod)
+
+	release, isAcquired := s.limiter.Acquire(priority)
+	if !isAcquired {
+		s.onShed(ctxMeta.FullMethod, priority)
+		return nil, status.Errorf(codes.Unavailable, "server is overloaded, %s is shed", ctxMeta.FullMethod)
+	}
+
+	return release, nil
+}
+
+// isDropped reports deadline exceeded requests to limiter
+func isDropped(err error) bool {
+	return status.Code(err) == codes.DeadlineExceeded || errors.Is(err, context.DeadlineExceeded)
+}
+
+// priority of the method
+func (s *shedder) priority(fullMethod string) concurrency.Priority {
+	for _, p := range s.priorities {
+		if p.matcher.Match(fullMethod) {
+			return p.priority
+		}
+	}
+
+	return concurrency.PriorityNormal
+}
@@ -0,0 +1,102 @@
+package loadshed
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	brokkrgrpc "github.com/Clink-n-Clank/Brokkr/component/background/grpc"
+	"github.com/Clink-n-Clank/Brokkr/component/behavior/concurrency"
+)
+
+func TestMiddleware(t *testing.T) {
+	l := concurrency.NewLimiter(concurrency.AIMD{}, concurrency.SetInitialLimit(4), concurrency.SetLimitBounds(4, 4))
+
+	var shed []string
+	mw := Middleware(l,
+		SetMethodPriority("/svc.Reports/*", concurrency.PriorityLow),
+		SetShedHandler(func(fullMethod string, _ concurrency.Priority) { shed = append(shed, fullMethod) }),
+	)
+
+	inHandler, unblock := make(chan struct{}), make(chan struct{})
+	blocking := mw(func(ctx context.Context, req interface{}) (interface{}, error) {
+		inHandler <- struct{}{}
+		<-unblock
+		return req, nil
+	})
+	handler := mw(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return req, nil
+	})
+
+	call := func(h brokkrgrpc.RequestHandler, fullMethod string) error {
+		ctx := brokkrgrpc.NewMiddlewareComposer().ExtendContext(context.Background(), brokkrgrpc.RequestContextMetadata{FullMethod: fullMethod})
+		_, err := h(ctx, nil)
+
+		return err
+	}
+
+	// Fill 3 of 4 slots with normal requests
+	errs := make(chan error, 3)
+	for i := 0; i < 3; i++ {
+		go func() { errs <- call(blocking, "/svc.Orders/Get") }()
+		<-inHandler
+	}
+
+	assert.Equal(t, codes.Unavailable, status.Code(call(handler, "/svc.Reports/Build")))
+	assert.Equal(t, codes.Unavailable, status.Code(call(handler, "/svc.Orders/Get")))
+	assert.NoError(t, call(handler, "/grpc.health.v1.Health/Check"))
+
+	close(unblock)
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, <-errs)
+	}
+
+	assert.Equal(t, []string{"/svc.Reports/Build", "/svc.Orders/Get"}, shed)
+	assert.Equal(t, uint64(1), l.Stats().Shed[concurrency.PriorityLow])
+	assert.Equal(t, 0, l.Stats().InFlight)
+}
+
+func TestStreamMiddleware(t *testing.T) {
+	l := concurrency.NewLimiter(concurrency.AIMD{}, concurrency.SetInitialLimit(1), concurrency.SetLimitBounds(1, 1))
+	mw := StreamMiddleware(l, SetMethodPriority("/svc.Reports/*", concurrency.PriorityLow))
+
+	stream := func(fullMethod string) grpc.ServerStream {
+		ctx := brokkrgrpc.NewMiddlewareComposer().ExtendContext(context.Background(), brokkrgrpc.RequestContextMetadata{FullMethod: fullMethod})
+		return brokkrgrpc.WrapServerStream(ctx, nil)
+	}
+
+	var shedErr error
+	handler := mw(func(srv interface{}, ss grpc.ServerStream) error {
+		// Stream holds the slot until handler returns
+		shedErr = mw(func(interface{}, grpc.ServerStream) error { return nil })(srv, stream("/svc.Orders/Watch"))
+		return nil
+	})
+
+	// Low priority stream gets the only slot of limit 1
+	assert.NoError(t, handler(nil, stream("/svc.Reports/Watch")))
+	assert.Equal(t, codes.Unavailable, status.Code(shedErr))
+	assert.Equal(t, 0, l.Stats().InFlight)
+}
+
+func TestMiddleware_PanicReleasesSlot(t *testing.T) {
+	l := concurrency.NewLimiter(concurrency.AIMD{}, concurrency.SetInitialLimit(2), concurrency.SetLimitBounds(2, 2))
+	ctx := brokkrgrpc.NewMiddlewareComposer().ExtendContext(context.Background(), brokkrgrpc.RequestContextMetadata{FullMethod: "/svc.Orders/Get"})
+
+	handler := Middleware(l)(func(ctx context.Context, req interface{}) (interface{}, error) {
+		panic("boom")
+	})
+	streamHandler := StreamMiddleware(l)(func(srv interface{}, stream grpc.ServerStream) error {
+		panic("boom")
+	})
+
+	for i := 0; i < 3; i++ {
+		assert.PanicsWithValue(t, "boom", func() { _, _ = handler(ctx, nil) })
+		assert.PanicsWithValue(t, "boom", func() { _ = streamHandler(nil, brokkrgrpc.WrapServerStream(ctx, nil)) })
+	}
+
+	assert.Equal(t, 0, l.Stats().InFlight)
+}
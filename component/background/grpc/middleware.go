@@ -2,46 +2,46 @@ package grpc
 
 import (
 	"context"
-	"sort"
-	"strings"
+	"errors"
 
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/metadata"
+
+	"github.com/Clink-n-Clank/Brokkr/component/background/route"
 )
 
 // Abstract middleware functionality based on chain of responsibility
 
-const (
-	// globalMiddlewareFilterEmpty will be used if we have middlewares like * (always for all routes)
-	globalMiddlewareFilterEmpty = ""
-)
-
 type (
 	// RequestHandler will be invoked in the gRPC Middleware
 	RequestHandler func(ctx context.Context, req interface{}) (interface{}, error)
 	// Middleware to do some actions between gRPC requests
 	Middleware func(RequestHandler) RequestHandler
 
+	// StreamHandler will be invoked in the gRPC StreamMiddleware
+	StreamHandler func(srv interface{}, stream grpc.ServerStream) error
+	// StreamMiddleware to do some actions between gRPC streaming requests
+	StreamMiddleware func(StreamHandler) StreamHandler
+
 	// MiddlewareComposer keeps middlewares and keeps it sorted by filter
 	MiddlewareComposer struct {
-		mws               []Middleware
-		routesUnderFilter []string
-		routes            map[string][]Middleware
+		routes       route.Table[Middleware]
+		streamRoutes route.Table[StreamMiddleware]
+		registerErr  error
 	}
 	middlewareComposerContextMetadataKey struct{}
 	// RequestContextMetadata context data from interception
 	RequestContextMetadata struct {
 		Meta       metadata.MD
 		FullMethod string
+		// Peer that sent request, identity of client certificate is set when mTLS is used
+		Peer *PeerIdentity
 	}
 )
 
 // NewMiddlewareComposer instance
 func NewMiddlewareComposer() *MiddlewareComposer {
-	return &MiddlewareComposer{
-		mws:               make([]Middleware, 0),
-		routesUnderFilter: make([]string, 0),
-		routes:            map[string][]Middleware{},
-	}
+	return &MiddlewareComposer{}
 }
 
 // ExtendContext baseCtx with new RequestContextMetadata
@@ -49,50 +49,44 @@ func (mc *MiddlewareComposer) ExtendContext(baseCtx context.Context, newCtxMetad
 	return context.WithValue(baseCtx, middlewareComposerContextMetadataKey{}, newCtxMetadata)
 }
 
-// Register middleware with filter
+// Register middleware with filter, see route package for filter syntax and order of execution
 func (mc *MiddlewareComposer) Register(filter string, mw ...Middleware) {
-	if filter == globalMiddlewareFilterEmpty {
-		filter = "*"
-	}
-
-	if strings.HasSuffix(filter, "*") {
-		filter = strings.TrimSuffix(filter, "*")
-		mc.routesUnderFilter = append(mc.routesUnderFilter, filter)
-
-		sort.Slice(mc.routesUnderFilter, func(f1, f2 int) bool {
-			return mc.routesUnderFilter[f1] > mc.routesUnderFilter[f2]
-		})
-	}
+	mc.RegisterWithPriority(filter, 0, mw...)
+}
 
-	mc.routes[filter] = append(mc.routes[filter], mw...)
+// RegisterWithPriority middleware with filter, middlewares with higher priority run first
+func (mc *MiddlewareComposer) RegisterWithPriority(filter string, priority int, mw ...Middleware) {
+	mc.registerErr = errors.Join(mc.registerErr, mc.routes.Register(filter, priority, mw...))
 }
 
-// Search middleware that related to filtered request
-func (mc *MiddlewareComposer) Search(requestPath string) []Middleware {
-	ms := make([]Middleware, 0)
-	if globalMiddlewares, exist := mc.routes[globalMiddlewareFilterEmpty]; exist {
-		ms = append(ms, globalMiddlewares...)
-	}
+// RegisterStream middleware with filter
+func (mc *MiddlewareComposer) RegisterStream(filter string, mw ...StreamMiddleware) {
+	mc.RegisterStreamWithPriority(filter, 0, mw...)
+}
 
-	if requestPath == globalMiddlewareFilterEmpty {
-		return ms
-	}
+// RegisterStreamWithPriority middleware with filter, middlewares with higher priority run first
+func (mc *MiddlewareComposer) RegisterStreamWithPriority(filter string, priority int, mw ...StreamMiddleware) {
+	mc.registerErr = errors.Join(mc.registerErr, mc.streamRoutes.Register(filter, priority, mw...))
+}
 
-	if neededMiddleware, ok := mc.routes[requestPath]; ok {
-		return append(ms, neededMiddleware...)
-	}
+// Err of middlewares registered with invalid filter, such middlewares are never called
+func (mc *MiddlewareComposer) Err() error {
+	return mc.registerErr
+}
 
-	for _, filtered := range mc.routesUnderFilter {
-		if filtered == globalMiddlewareFilterEmpty { // Included by default
-			continue
-		}
+// Search middlewares of all filters that match request in order of execution
+func (mc *MiddlewareComposer) Search(requestPath string) []Middleware {
+	return mc.routes.Search(requestPath)
+}
 
-		if strings.HasPrefix(requestPath, filtered) {
-			return append(ms, mc.routes[filtered]...)
-		}
-	}
+// SearchStream middlewares of all filters that match streaming request in order of execution
+func (mc *MiddlewareComposer) SearchStream(requestPath string) []StreamMiddleware {
+	return mc.streamRoutes.Search(requestPath)
+}
 
-	return ms
+// Filters of registered unary middlewares in order of execution
+func (mc *MiddlewareComposer) Filters() []string {
+	return mc.routes.Filters()
 }
 
 // PassToNext delegate to next middleware to execute
@@ -106,6 +100,17 @@ func (mc *MiddlewareComposer) PassToNext(m ...Middleware) Middleware {
 	}
 }
 
+// PassToNextStream delegate to next stream middleware to execute
+func (mc *MiddlewareComposer) PassToNextStream(m ...StreamMiddleware) StreamMiddleware {
+	return func(streamHandler StreamHandler) StreamHandler {
+		for i := len(m) - 1; i >= 0; i-- {
+			streamHandler = m[i](streamHandler)
+		}
+
+		return streamHandler
+	}
+}
+
 // GetContextMetadata will try get form context.Context metadata about request from middleware during interception
 func GetContextMetadata(baseCtx context.Context) (meta RequestContextMetadata, isExist bool) {
 	meta, isExist = baseCtx.Value(middlewareComposerContextMetadataKey{}).(RequestContextMetadata)
@@ -4,9 +4,14 @@ import (
 	"context"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/metadata"
+
+	"github.com/Clink-n-Clank/Brokkr/component/background/route"
 )
 
 var (
@@ -23,9 +28,8 @@ func TestRegisterAndSearchOfMiddlewares(t *testing.T) {
 	assert.NotEmpty(t, affectedMiddlewares)
 	assert.Len(t, affectedMiddlewares, 1)
 
-	assert.Contains(t, mc.routesUnderFilter, "")
-	assert.Contains(t, mc.routesUnderFilter, "route/A/test/")
-	assert.Contains(t, mc.routesUnderFilter, "route/A/")
+	assert.Len(t, mc.Search("route/A/test/1"), 3, "all matched filters expected to be composed")
+	assert.Equal(t, []string{"*", "route/A/*", "route/A/test/*"}, mc.Filters())
 }
 
 func TestOrderMiddlewares(t *testing.T) {
@@ -114,3 +118,86 @@ func testingThisWillLogEachRequestMiddleware(t *testing.T) Middleware {
 		}
 	}
 }
+
+func TestRegisterAndSearchOfStreamMiddlewares(t *testing.T) {
+	mc := NewMiddlewareComposer()
+	mc.RegisterStream("*", testingStreamMiddleware)
+	mc.RegisterStream("/api.route/*", testingStreamMiddleware, testingStreamMiddleware)
+
+	assert.Len(t, mc.SearchStream("/api.route/Watch"), 3)
+	assert.Len(t, mc.SearchStream("/other.route/Watch"), 1)
+	assert.Empty(t, mc.Search("/api.route/Watch"), "unary middlewares must not be affected")
+}
+
+func TestPassToNextStream(t *testing.T) {
+	testingPassToMiddleware = 0
+
+	streamHandler := func(srv interface{}, stream grpc.ServerStream) error {
+		testingPassToMiddleware++
+		return nil
+	}
+
+	mc := NewMiddlewareComposer()
+	assert.NoError(t, mc.PassToNextStream(testingStreamMiddleware, testingStreamMiddleware)(streamHandler)(nil, nil))
+	assert.Equal(t, 3, testingPassToMiddleware)
+}
+
+func testingStreamMiddleware(handler StreamHandler) StreamHandler {
+	return func(srv interface{}, stream grpc.ServerStream) error {
+		testingPassToMiddleware++
+		return handler(srv, stream)
+	}
+}
+
+func TestSearchComposesAllMatchedFilters(t *testing.T) {
+	var order []string
+	named := func(name string) Middleware {
+		return func(handler RequestHandler) RequestHandler {
+			return func(ctx context.Context, req interface{}) (interface{}, error) {
+				order = append(order, name)
+				return handler(ctx, req)
+			}
+		}
+	}
+
+	mc := NewMiddlewareComposer()
+	mc.Register("/svc.A/Get", named("exact"))
+	mc.Register("/svc.A/*", named("service"))
+	mc.Register("/svc.*/Get", named("glob"))
+	mc.Register("/*", named("all-services"))
+	mc.Register("!/grpc.health.v1.Health/*", named("not-health"))
+	mc.Register("*", named("global"))
+	mc.RegisterWithPriority("/svc.A/Get", 10, named("priority"))
+
+	handler := mc.PassToNext(mc.Search("/svc.A/Get")...)(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, nil
+	})
+	_, _ = handler(context.Background(), nil)
+
+	assert.Equal(t, []string{"priority", "not-health", "global", "all-services", "service", "glob", "exact"}, order)
+
+	order = nil
+	handler = mc.PassToNext(mc.Search("/grpc.health.v1.Health/Check")...)(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, nil
+	})
+	_, _ = handler(context.Background(), nil)
+
+	assert.Equal(t, []string{"global", "all-services"}, order, "excluded filter expected to be skipped")
+}
+
+func TestInvalidFilterIsReportedOnStart(t *testing.T) {
+	mc := NewMiddlewareComposer()
+	mc.Register("~(", testingPermanentMiddleware)
+	mc.RegisterStream("/svc.[/Watch", testingStreamMiddleware)
+	assert.ErrorIs(t, mc.Err(), route.ErrInvalidFilter)
+	assert.Empty(t, mc.Search("/svc.A/Get"))
+
+	srv := NewServer(NewServerOptionsBuilder().AddCustomUnaryMiddlewares("~(", testingPermanentMiddleware))
+	assert.ErrorIs(t, srv.OnStart(context.Background()), route.ErrInvalidFilter)
+
+	c := NewClient(NewClientOptionsBuilder().
+		AddTarget("localhost:1").
+		AddDialOptions(grpc.WithTransportCredentials(insecure.NewCredentials())).
+		AddMethodTimeout("~(", time.Second))
+	assert.ErrorIs(t, c.OnStart(context.Background()), route.ErrInvalidFilter)
+}
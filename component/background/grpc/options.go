@@ -2,10 +2,13 @@ package grpc
 
 import (
 	"net"
+	"strings"
 	"time"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/health/grpc_health_v1"
+
+	brokkrhttp "github.com/Clink-n-Clank/Brokkr/component/background/http"
 )
 
 // Options sets options such as credentials, keepalive parameters, etc.
@@ -61,17 +64,127 @@ func (b *ServerOptionsBuilder) AddGrpcStreamInterceptors(streamInter ...grpc.Str
 // filter used for calling middleware for example:
 // - /myapp.v1.MyAppAPI/*                     - Middleware will be executed for all endpoints under "/myapp.v1.MyAppAPI"
 // - /myapp.v1.MyAppAPI/OnlyThatEndpoint      - Middleware will be executed only for "OnlyThatEndpoint"
+// - !/grpc.health.v1.Health/*                - Middleware will be executed for all endpoints except health checks
+// glob and regex filters are supported as well, middlewares of all matched filters are executed, see route package
 func (b *ServerOptionsBuilder) AddCustomUnaryMiddlewares(filter string, mwList ...Middleware) *ServerOptionsBuilder {
 	b.srvOpts = append(b.srvOpts, func(s *BackgroundServer) { s.middlewareComposer.Register(filter, mwList...) })
 	return b
 }
 
-// AddServicesHealthChecks to verify if gRPC working correctly as health checks
+// AddPrioritizedUnaryMiddlewares the same as AddCustomUnaryMiddlewares, middlewares with higher priority are executed first
+func (b *ServerOptionsBuilder) AddPrioritizedUnaryMiddlewares(filter string, priority int, mwList ...Middleware) *ServerOptionsBuilder {
+	b.srvOpts = append(b.srvOpts, func(s *BackgroundServer) { s.middlewareComposer.RegisterWithPriority(filter, priority, mwList...) })
+	return b
+}
+
+// AddCustomStreamMiddlewares that have first priority to intercepted streaming request in the middlewares, filter works the same way
+// as in AddCustomUnaryMiddlewares
+func (b *ServerOptionsBuilder) AddCustomStreamMiddlewares(filter string, mwList ...StreamMiddleware) *ServerOptionsBuilder {
+	b.srvOpts = append(b.srvOpts, func(s *BackgroundServer) { s.middlewareComposer.RegisterStream(filter, mwList...) })
+	return b
+}
+
+// AddPrioritizedStreamMiddlewares the same as AddCustomStreamMiddlewares, middlewares with higher priority are executed first
+func (b *ServerOptionsBuilder) AddPrioritizedStreamMiddlewares(filter string, priority int, mwList ...StreamMiddleware) *ServerOptionsBuilder {
+	b.srvOpts = append(b.srvOpts, func(s *BackgroundServer) {
+		s.middlewareComposer.RegisterStreamWithPriority(filter, priority, mwList...)
+	})
+	return b
+}
+
+// AddServicesHealthChecks to verify if gRPC working correctly as health checks, checks are re-evaluated on interval
 func (b *ServerOptionsBuilder) AddServicesHealthChecks(srv map[string]func() grpc_health_v1.HealthCheckResponse_ServingStatus) *ServerOptionsBuilder {
 	b.srvOpts = append(b.srvOpts, func(s *BackgroundServer) { s.dependedServicesCheck = srv })
 	return b
 }
 
+// AddHealthChecks that are re-evaluated on interval, critical checks define overall status of the server
+func (b *ServerOptionsBuilder) AddHealthChecks(checks ...HealthCheck) *ServerOptionsBuilder {
+	b.srvOpts = append(b.srvOpts, func(s *BackgroundServer) { s.healthMonitor.addChecks(checks...) })
+	return b
+}
+
+// AddHealthCheckInterval how often health checks are re-evaluated
+func (b *ServerOptionsBuilder) AddHealthCheckInterval(interval time.Duration) *ServerOptionsBuilder {
+	b.srvOpts = append(b.srvOpts, func(s *BackgroundServer) { s.healthMonitor.interval = interval })
+	return b
+}
+
+// AddHealthCheckTimeout default timeout of single health check, check that didn't respond in time is not serving
+func (b *ServerOptionsBuilder) AddHealthCheckTimeout(timeout time.Duration) *ServerOptionsBuilder {
+	b.srvOpts = append(b.srvOpts, func(s *BackgroundServer) { s.healthMonitor.timeout = timeout })
+	return b
+}
+
+// AddTLSCertificate files of the server, certificate is reloaded when files are changed
+func (b *ServerOptionsBuilder) AddTLSCertificate(certFile, keyFile string) *ServerOptionsBuilder {
+	b.srvOpts = append(b.srvOpts, func(s *BackgroundServer) {
+		s.tls.certFile = certFile
+		s.tls.keyFile = keyFile
+	})
+	return b
+}
+
+// AddTLSClientCA file to enable mTLS, clients must present certificate signed by this CA, requires AddTLSCertificate
+func (b *ServerOptionsBuilder) AddTLSClientCA(caFile string) *ServerOptionsBuilder {
+	b.srvOpts = append(b.srvOpts, func(s *BackgroundServer) { s.tls.clientCAFile = caFile })
+	return b
+}
+
+// AddTLSMinVersion of TLS protocol, for example tls.VersionTLS13, TLS 1.2 is used by default
+func (b *ServerOptionsBuilder) AddTLSMinVersion(v uint16) *ServerOptionsBuilder {
+	b.srvOpts = append(b.srvOpts, func(s *BackgroundServer) { s.tls.minVersion = v })
+	return b
+}
+
+// AddTLSAllowedClientIdentities of client certificates matched with SAN or CN, all verified clients are allowed if not set, requires AddTLSClientCA
+func (b *ServerOptionsBuilder) AddTLSAllowedClientIdentities(ids ...string) *ServerOptionsBuilder {
+	b.srvOpts = append(b.srvOpts, func(s *BackgroundServer) {
+		for _, id := range ids {
+			s.tls.allowedIdentities[id] = struct{}{}
+		}
+	})
+	return b
+}
+
+// AddTLSReloadInterval how often certificate files are checked for changes
+func (b *ServerOptionsBuilder) AddTLSReloadInterval(interval time.Duration) *ServerOptionsBuilder {
+	b.srvOpts = append(b.srvOpts, func(s *BackgroundServer) { s.tls.reloadInterval = interval })
+	return b
+}
+
+// AddHTTPGateway that exposes registered unary methods over JSON/HTTP as POST /package.Service/Method
+// or by google.api.http annotations, requests are passed through the same middlewares as gRPC requests,
+// gateway is served with the same TLS settings as gRPC server
+func (b *ServerOptionsBuilder) AddHTTPGateway(httpBuilder *brokkrhttp.ServerOptionsBuilder) *ServerOptionsBuilder {
+	b.srvOpts = append(b.srvOpts, func(s *BackgroundServer) {
+		s.gatewayBuilder = httpBuilder
+		s.isGatewayInsecure = false
+	})
+	return b
+}
+
+// AddInsecureHTTPGateway the same as AddHTTPGateway, but gateway is served without TLS even if gRPC server has it,
+// for example behind TLS terminating proxy, client certificates are not verified for HTTP requests
+func (b *ServerOptionsBuilder) AddInsecureHTTPGateway(httpBuilder *brokkrhttp.ServerOptionsBuilder) *ServerOptionsBuilder {
+	b.srvOpts = append(b.srvOpts, func(s *BackgroundServer) {
+		s.gatewayBuilder = httpBuilder
+		s.isGatewayInsecure = true
+	})
+	return b
+}
+
+// AddHTTPGatewayHeaders that are forwarded by HTTP gateway as incoming metadata, only Authorization, Cache-Control,
+// Idempotency-Key, X-Request-Id, Traceparent and Tracestate are forwarded by default
+func (b *ServerOptionsBuilder) AddHTTPGatewayHeaders(headers ...string) *ServerOptionsBuilder {
+	b.srvOpts = append(b.srvOpts, func(s *BackgroundServer) {
+		for _, h := range headers {
+			s.gatewayHeaders[strings.ToLower(h)] = struct{}{}
+		}
+	})
+	return b
+}
+
 // AddAdditionalGrpcOptions that's needed for gRPC server
 func (b *ServerOptionsBuilder) AddAdditionalGrpcOptions(grpcOpts ...grpc.ServerOption) *ServerOptionsBuilder {
 	b.srvOpts = append(b.srvOpts, func(s *BackgroundServer) { s.opts = grpcOpts })
@@ -0,0 +1,118 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	brokkrgrpc "github.com/Clink-n-Clank/Brokkr/component/background/grpc"
+	brokkrratelimit "github.com/Clink-n-Clank/Brokkr/component/behavior/ratelimit"
+)
+
+// RetryAfterMetadataKey of response header with seconds to wait before next request
+const RetryAfterMetadataKey = "retry-after"
+
+// KeyFunc returns rate limit key of the request, requests with the same key share the limit
+type KeyFunc func(ctx context.Context) string
+
+// ByMethod key, each method has own limit
+func ByMethod() KeyFunc {
+	return func(ctx context.Context) string {
+		ctxMeta, _ := brokkrgrpc.GetContextMetadata(ctx)
+		return "method:" + ctxMeta.FullMethod
+	}
+}
+
+// ByMetadata key like tenant or API key header, requests without the key share one limit
+func ByMetadata(key string) KeyFunc {
+	return func(ctx context.Context) string {
+		md, _ := metadata.FromIncomingContext(ctx)
+		if ctxMeta, isCtxMetaExist := brokkrgrpc.GetContextMetadata(ctx); isCtxMetaExist && ctxMeta.Meta != nil {
+			md = ctxMeta.Meta
+		}
+
+		var value string
+		if values := md.Get(key); len(values) > 0 {
+			value = values[0]
+		}
+
+		return strings.ToLower(key) + ":" + value
+	}
+}
+
+// ByPeer key with address of the client without port
+func ByPeer() KeyFunc {
+	return func(ctx context.Context) string {
+		var addr string
+		if ctxMeta, isCtxMetaExist := brokkrgrpc.GetContextMetadata(ctx); isCtxMetaExist && ctxMeta.Peer != nil {
+			addr = ctxMeta.Peer.Address
+			if host, _, err := net.SplitHostPort(addr); err == nil {
+				addr = host
+			}
+		}
+
+		return "peer:" + addr
+	}
+}
+
+// Keys composes key of several keys, for example limit of each tenant per method
+func Keys(keys ...KeyFunc) KeyFunc {
+	return func(ctx context.Context) string {
+		parts := make([]string, 0, len(keys))
+		for _, k := range keys {
+			parts = append(parts, k(ctx))
+		}
+
+		return strings.Join(parts, "|")
+	}
+}
+
+// Middleware that limits unary requests, exceeded limit returns ResourceExhausted with retry-after header
+func Middleware(l *brokkrratelimit.Limiter, key KeyFunc) brokkrgrpc.Middleware {
+	return func(handler brokkrgrpc.RequestHandler) brokkrgrpc.RequestHandler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			if err := allow(ctx, l, key, func(md metadata.MD) { _ = grpc.SetHeader(ctx, md) }); err != nil {
+				return nil, err
+			}
+
+			return handler(ctx, req)
+		}
+	}
+}
+
+// StreamMiddleware that limits opening of streams, exceeded limit returns ResourceExhausted with retry-after header
+func StreamMiddleware(l *brokkrratelimit.Limiter, key KeyFunc) brokkrgrpc.StreamMiddleware {
+	return func(handler brokkrgrpc.StreamHandler) brokkrgrpc.StreamHandler {
+		return func(srv interface{}, stream grpc.ServerStream) error {
+			if err := allow(stream.Context(), l, key, func(md metadata.MD) { _ = stream.SetHeader(md) }); err != nil {
+				return err
+			}
+
+			return handler(srv, stream)
+		}
+	}
+}
+
+// allow request by limiter, store failure returns Unavailable status
+func allow(ctx context.Context, l *brokkrratelimit.Limiter, key KeyFunc, setHeader func(md metadata.MD)) error {
+	res, err := l.Allow(ctx, key(ctx))
+	if err != nil {
+		return status.Errorf(codes.Unavailable, "rate limit: %s", err)
+	}
+
+	if res.IsAllowed {
+		return nil
+	}
+
+	retryAfter := int64(math.Ceil(res.RetryAfter.Seconds()))
+	setHeader(metadata.Pairs(RetryAfterMetadataKey, strconv.FormatInt(retryAfter, 10)))
+
+	return status.Errorf(codes.ResourceExhausted, "rate limit exceeded, retry after %s", res.RetryAfter)
+}
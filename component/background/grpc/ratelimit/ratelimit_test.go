@@ -0,0 +1,54 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	brokkrgrpc "github.com/Clink-n-Clank/Brokkr/component/background/grpc"
+	"github.com/Clink-n-Clank/Brokkr/component/background/grpc/grpctest"
+	brokkrratelimit "github.com/Clink-n-Clank/Brokkr/component/behavior/ratelimit"
+)
+
+func TestMiddleware(t *testing.T) {
+	l, err := brokkrratelimit.NewLimiter(brokkrratelimit.GCRA, brokkrratelimit.Limit{Rate: 1, Period: time.Minute})
+	assert.NoError(t, err)
+
+	handler := Middleware(l, Keys(ByMethod(), ByMetadata("x-tenant")))(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return req, nil
+	})
+
+	call := func(fullMethod, tenant string) (*grpctest.TransportStream, error) {
+		ctx, ts := grpctest.NewContext(fullMethod, metadata.Pairs("x-tenant", tenant))
+		_, callErr := handler(ctx, nil)
+
+		return ts, callErr
+	}
+
+	_, err = call("/svc.A/Get", "acme")
+	assert.NoError(t, err)
+
+	ts, err := call("/svc.A/Get", "acme")
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Equal(t, []string{"60"}, ts.Header().Get(RetryAfterMetadataKey))
+
+	// Other tenant and other method have own limits
+	_, err = call("/svc.A/Get", "globex")
+	assert.NoError(t, err)
+	_, err = call("/svc.A/List", "acme")
+	assert.NoError(t, err)
+}
+
+func TestByPeer(t *testing.T) {
+	ctx := brokkrgrpc.NewMiddlewareComposer().ExtendContext(context.Background(), brokkrgrpc.RequestContextMetadata{
+		Peer: &brokkrgrpc.PeerIdentity{Address: "10.0.0.1:53412"},
+	})
+
+	assert.Equal(t, "peer:10.0.0.1", ByPeer()(ctx))
+	assert.Equal(t, "peer:", ByPeer()(context.Background()))
+}
@@ -0,0 +1,108 @@
+package recovery
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"runtime/debug"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	brokkrgrpc "github.com/Clink-n-Clank/Brokkr/component/background/grpc"
+)
+
+type (
+	// Panic recovered from handler
+	Panic struct {
+		FullMethod string
+		Value      any
+		Stack      []byte
+	}
+
+	// recoverer of panics in handlers
+	recoverer struct {
+		onPanic func(ctx context.Context, p Panic)
+		out     io.Writer
+	}
+
+	// Option for recovery middleware
+	Option func(r *recoverer)
+)
+
+// SetPanicHandler that records recovered panic with its stack, for example to log or metrics,
+// by default panic value and stack are written to output
+func SetPanicHandler(h func(ctx context.Context, p Panic)) Option {
+	return func(r *recoverer) {
+		r.onPanic = h
+	}
+}
+
+// SetOutput of recovered panics that are recorded by default panic handler, os.Stderr by default
+func SetOutput(w io.Writer) Option {
+	return func(r *recoverer) {
+		r.out = w
+	}
+}
+
+// Middleware that recovers panic in unary handler and returns Internal status, it should be registered with the highest priority
+func Middleware(opts ...Option) brokkrgrpc.Middleware {
+	r := newRecoverer(opts...)
+
+	return func(handler brokkrgrpc.RequestHandler) brokkrgrpc.RequestHandler {
+		return func(ctx context.Context, req interface{}) (resp interface{}, err error) {
+			defer func() {
+				if v := recover(); v != nil {
+					resp, err = nil, r.recovered(ctx, v)
+				}
+			}()
+
+			return handler(ctx, req)
+		}
+	}
+}
+
+// StreamMiddleware that recovers panic in stream handler and returns Internal status
+func StreamMiddleware(opts ...Option) brokkrgrpc.StreamMiddleware {
+	r := newRecoverer(opts...)
+
+	return func(handler brokkrgrpc.StreamHandler) brokkrgrpc.StreamHandler {
+		return func(srv interface{}, stream grpc.ServerStream) (err error) {
+			defer func() {
+				if v := recover(); v != nil {
+					err = r.recovered(stream.Context(), v)
+				}
+			}()
+
+			return handler(srv, stream)
+		}
+	}
+}
+
+func newRecoverer(opts ...Option) *recoverer {
+	r := &recoverer{out: os.Stderr}
+	for _, o := range opts {
+		o(r)
+	}
+
+	if r.onPanic == nil {
+		r.onPanic = r.writePanic
+	}
+
+	return r
+}
+
+// writePanic value and stack to output
+func (r *recoverer) writePanic(_ context.Context, p Panic) {
+	_, _ = fmt.Fprintf(r.out, "panic recovered in %s: %v\n%s\n", p.FullMethod, p.Value, p.Stack)
+}
+
+// recovered panic is reported to handler, details of the panic are not exposed to client
+func (r *recoverer) recovered(ctx context.Context, v any) error {
+	ctxMeta, _ := brokkrgrpc.GetContextMetadata(ctx)
+	r.onPanic(ctx, Panic{FullMethod: ctxMeta.FullMethod, Value: v, Stack: debug.Stack()})
+
+	return status.Error(codes.Internal, "internal error")
+}
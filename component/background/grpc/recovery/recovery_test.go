@@ -0,0 +1,68 @@
+package recovery
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	brokkrgrpc "github.com/Clink-n-Clank/Brokkr/component/background/grpc"
+)
+
+type testingServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s testingServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestMiddleware(t *testing.T) {
+	var recovered []Panic
+	opt := SetPanicHandler(func(_ context.Context, p Panic) { recovered = append(recovered, p) })
+	ctx := brokkrgrpc.NewMiddlewareComposer().ExtendContext(context.Background(), brokkrgrpc.RequestContextMetadata{FullMethod: "/svc.A/Get"})
+
+	_, err := Middleware(opt)(func(ctx context.Context, req interface{}) (interface{}, error) {
+		panic("boom")
+	})(ctx, nil)
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.NotContains(t, err.Error(), "boom")
+
+	err = StreamMiddleware(opt)(func(srv interface{}, stream grpc.ServerStream) error {
+		var m map[string]int
+		m["x"]++
+		return nil
+	})(nil, testingServerStream{ctx: ctx})
+	assert.Equal(t, codes.Internal, status.Code(err))
+
+	assert.Len(t, recovered, 2)
+	assert.Equal(t, "/svc.A/Get", recovered[0].FullMethod)
+	assert.Equal(t, "boom", recovered[0].Value)
+	assert.Contains(t, string(recovered[0].Stack), "recovery_test.go")
+	assert.Implements(t, (*error)(nil), recovered[1].Value)
+
+	// Handler without panic is not affected
+	resp, err := Middleware()(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return req, nil
+	})(ctx, "ok")
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", resp)
+}
+
+func TestMiddleware_WritesPanicByDefault(t *testing.T) {
+	out := &bytes.Buffer{}
+	ctx := brokkrgrpc.NewMiddlewareComposer().ExtendContext(context.Background(), brokkrgrpc.RequestContextMetadata{FullMethod: "/svc.A/Get"})
+
+	_, err := Middleware(SetOutput(out))(func(ctx context.Context, req interface{}) (interface{}, error) {
+		panic("boom")
+	})(ctx, nil)
+	assert.Equal(t, codes.Internal, status.Code(err))
+
+	assert.Contains(t, out.String(), "panic recovered in /svc.A/Get: boom")
+	assert.Contains(t, out.String(), "recovery_test.go")
+}
@@ -11,6 +11,7 @@ import (
 	"google.golang.org/grpc/metadata"
 
 	"github.com/Clink-n-Clank/Brokkr/component/background"
+	brokkrhttp "github.com/Clink-n-Clank/Brokkr/component/background/http"
 )
 
 // BackgroundServer wrapper
@@ -20,6 +21,7 @@ type BackgroundServer struct {
 	unaryInterceptors  []grpc.UnaryServerInterceptor
 	streamInterceptors []grpc.StreamServerInterceptor
 	health             *health.Server
+	healthMonitor      *healthMonitor
 	middlewareComposer *MiddlewareComposer
 
 	network string
@@ -29,6 +31,16 @@ type BackgroundServer struct {
 	listener    net.Listener
 	listenerErr error
 
+	tls    *tlsSettings
+	tlsErr error
+
+	services          []registeredService
+	gateway           *gateway
+	gatewayBuilder    *brokkrhttp.ServerOptionsBuilder
+	gatewayServer     *brokkrhttp.BackgroundServer
+	gatewayHeaders    map[string]struct{}
+	isGatewayInsecure bool
+
 	// dependedServicesCheck has as string - service name and function that returns state
 	dependedServicesCheck map[string]func() grpc_health_v1.HealthCheckResponse_ServingStatus
 }
@@ -48,38 +60,79 @@ func NewServer(builder *ServerOptionsBuilder) *BackgroundServer {
 		timeout:            30 * time.Second,
 		health:             health.NewServer(),
 		middlewareComposer: NewMiddlewareComposer(),
+		tls:                newTLSSettings(),
+		gatewayHeaders:     map[string]struct{}{},
 	}
+	serv.healthMonitor = newHealthMonitor(serv.health)
 
 	// Load additional grpc server options
 	for _, o := range builder.Build() {
 		o(serv)
 	}
 
-	// TODO add stream middleware support
 	// Load middlewares and interceptors
 	serverUnaryInterceptor := []grpc.UnaryServerInterceptor{serv.unaryServerInterceptorForMiddleware()}
 	if len(serv.unaryInterceptors) > 0 {
 		serverUnaryInterceptor = append(serverUnaryInterceptor, serv.unaryInterceptors...)
 	}
 
+	serverStreamInterceptor := []grpc.StreamServerInterceptor{serv.streamServerInterceptorForMiddleware()}
+	if len(serv.streamInterceptors) > 0 {
+		serverStreamInterceptor = append(serverStreamInterceptor, serv.streamInterceptors...)
+	}
+
 	serv.opts = append(
 		serv.opts,
 		[]grpc.ServerOption{
 			grpc.ChainUnaryInterceptor(serverUnaryInterceptor...),
-			grpc.ChainStreamInterceptor(serv.streamInterceptors...),
+			grpc.ChainStreamInterceptor(serverStreamInterceptor...),
 		}...,
 	)
 
+	serv.tlsErr = serv.tls.validate()
+	if serv.tlsErr == nil && serv.tls.isEnabled() {
+		creds, err := serv.tls.credentials()
+		if err == nil {
+			serv.opts = append(serv.opts, grpc.Creds(creds))
+		}
+
+		serv.tlsErr = err
+	}
+
 	// Create and run gRPC server
 	serv.Server = grpc.NewServer(serv.opts...)
 	serv.listenerErr = serv.listen()
 
-	// Add internal sub-services to gRPC server register
+	// HTTP gateway calls handlers through the same interceptors as gRPC server
+	serv.gateway = &gateway{server: serv, interceptor: chainUnaryInterceptors(serverUnaryInterceptor), headers: serv.gatewayHeaders}
+	for _, h := range gatewayDefaultHeaders {
+		serv.gatewayHeaders[h] = struct{}{}
+	}
+
+	if serv.gatewayBuilder != nil {
+		gatewayBuilder := serv.gatewayBuilder.AddHandler("/", serv.gateway)
+
+		// Gateway is served with the same TLS settings, so client certificates are verified for HTTP requests too
+		if serv.tls.isEnabled() && !serv.isGatewayInsecure {
+			gatewayBuilder = gatewayBuilder.AddTLSConfig(serv.tls.serverConfig("h2", "http/1.1"))
+		}
+
+		serv.gatewayServer = brokkrhttp.NewServer(gatewayBuilder)
+	}
+
+	// Add internal sub-services to gRPC server register, they are re-evaluated by health monitor
 	for serviceName, check := range serv.dependedServicesCheck {
-		serv.health.SetServingStatus(serviceName, check())
+		serviceCheck := check
+		serv.healthMonitor.addChecks(HealthCheck{
+			Service: serviceName,
+			Check: func(context.Context) grpc_health_v1.HealthCheckResponse_ServingStatus {
+				return serviceCheck()
+			},
+		})
 	}
+	serv.healthMonitor.evaluate()
 
-	grpc_health_v1.RegisterHealthServer(serv.Server, serv.health)
+	grpc_health_v1.RegisterHealthServer(serv, serv.health)
 
 	return serv
 }
@@ -95,22 +148,67 @@ func (s *BackgroundServer) GetSeverity() background.ProcessSeverity {
 }
 
 // OnStart event to be called when main loop will be started
-func (s *BackgroundServer) OnStart(_ context.Context) error {
+func (s *BackgroundServer) OnStart(ctx context.Context) error {
 	if s.listenerErr != nil {
 		return s.listenerErr
 	}
 
+	if s.tlsErr != nil {
+		return s.tlsErr
+	}
+
+	if err := s.middlewareComposer.Err(); err != nil {
+		return err
+	}
+
+	s.gateway.buildRoutes()
+
+	// Resume sets all services as serving, so checks results are applied again
 	s.health.Resume()
+	s.healthMonitor.reset()
+	s.healthMonitor.evaluate()
+
+	go s.healthMonitor.run()
+
+	if s.gatewayServer == nil {
+		return s.Serve(s.listener)
+	}
 
-	return s.Serve(s.listener)
+	// gRPC server is stopped if gateway failed, so process is not running partially
+	gatewayErr := make(chan error, 1)
+	go func() {
+		if err := s.gatewayServer.OnStart(ctx); err != nil {
+			gatewayErr <- err
+			s.Stop()
+		}
+	}()
+
+	serveErr := s.Serve(s.listener)
+	select {
+	case err := <-gatewayErr:
+		return err
+	default:
+		return serveErr
+	}
 }
 
 // OnStop event to be called when main loop will be started
-func (s *BackgroundServer) OnStop(_ context.Context) error {
+func (s *BackgroundServer) OnStop(ctx context.Context) error {
+	var gatewayErr error
+	if s.gatewayServer != nil {
+		gatewayErr = s.gatewayServer.OnStop(ctx)
+	}
+
+	s.healthMonitor.stop()
 	s.health.Shutdown()
 	s.GracefulStop()
 
-	return nil
+	return gatewayErr
+}
+
+// HealthStatus of the service from last health checks evaluation, OverallHealthService is used for server status
+func (s *BackgroundServer) HealthStatus(service string) (grpc_health_v1.HealthCheckResponse_ServingStatus, bool) {
+	return s.healthMonitor.status(service)
 }
 
 // Listen network traffic for service handling
@@ -131,8 +229,8 @@ func (s *BackgroundServer) listen() error {
 // unaryServerInterceptorForMiddleware managing gRPC request interception to delegate it to Middleware
 func (s *BackgroundServer) unaryServerInterceptorForMiddleware() grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
-		extCtxMeta := RequestContextMetadata{FullMethod: info.FullMethod}
-		if meta, metaNotExist := metadata.FromIncomingContext(ctx); !metaNotExist {
+		extCtxMeta := RequestContextMetadata{FullMethod: info.FullMethod, Peer: peerIdentityFromContext(ctx)}
+		if meta, isMetaExist := metadata.FromIncomingContext(ctx); isMetaExist {
 			extCtxMeta.Meta = meta
 		}
 
@@ -154,4 +252,30 @@ func (s *BackgroundServer) unaryServerInterceptorForMiddleware() grpc.UnaryServe
 	}
 }
 
-// TODO add stream middleware support
+// streamServerInterceptorForMiddleware managing gRPC stream interception to delegate it to StreamMiddleware
+func (s *BackgroundServer) streamServerInterceptorForMiddleware() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx := ss.Context()
+
+		extCtxMeta := RequestContextMetadata{FullMethod: info.FullMethod, Peer: peerIdentityFromContext(ctx)}
+		if meta, isMetaExist := metadata.FromIncomingContext(ctx); isMetaExist {
+			extCtxMeta.Meta = meta
+		}
+
+		ctx = s.middlewareComposer.ExtendContext(ctx, extCtxMeta)
+
+		//
+		// Look up for registered stream middlewares
+		//
+		defaultStreamHandler := func(srv interface{}, stream grpc.ServerStream) error {
+			return handler(srv, stream)
+		}
+
+		affectedMiddlewares := s.middlewareComposer.SearchStream(info.FullMethod)
+		if len(affectedMiddlewares) > 0 {
+			defaultStreamHandler = s.middlewareComposer.PassToNextStream(affectedMiddlewares...)(defaultStreamHandler)
+		}
+
+		return defaultStreamHandler(srv, WrapServerStream(ctx, ss))
+	}
+}
@@ -11,7 +11,9 @@ import (
 	grpc_auth "github.com/grpc-ecosystem/go-grpc-middleware/auth"
 	"github.com/stretchr/testify/assert"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
 )
 
 func TestServer(t *testing.T) {
@@ -106,3 +108,63 @@ func customMiddleware(handler RequestHandler) RequestHandler {
 		return handler(ctx, req)
 	}
 }
+
+func TestStreamMiddlewares(t *testing.T) {
+	type streamCall struct {
+		fullMethod string
+		meta       metadata.MD
+		sent       interface{}
+	}
+
+	calls := make(chan streamCall, 1)
+	captureMiddleware := func(handler StreamHandler) StreamHandler {
+		return func(srv interface{}, stream grpc.ServerStream) error {
+			ctxMeta, isExist := GetContextMetadata(stream.Context())
+			assert.True(t, isExist)
+
+			call := streamCall{fullMethod: ctxMeta.FullMethod, meta: ctxMeta.Meta}
+			onSend := func(ctx context.Context, msg interface{}) error {
+				call.sent = msg
+				calls <- call
+
+				return nil
+			}
+
+			return InterceptStreamMessages(nil, onSend)(handler)(srv, stream)
+		}
+	}
+
+	srv, conn := startTestServer(t, NewServerOptionsBuilder().AddCustomStreamMiddlewares("/grpc.health.v1.Health/*", captureMiddleware))
+	defer func() { _ = srv.OnStop(context.Background()) }()
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-unit", "stream")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	watch, err := grpc_health_v1.NewHealthClient(conn).Watch(ctx, &grpc_health_v1.HealthCheckRequest{})
+	assert.NoError(t, err)
+
+	resp, err := watch.Recv()
+	assert.NoError(t, err)
+	assert.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, resp.GetStatus())
+
+	call := <-calls
+	assert.Equal(t, "/grpc.health.v1.Health/Watch", call.fullMethod)
+	assert.Equal(t, []string{"stream"}, call.meta.Get("x-unit"))
+	assert.IsType(t, &grpc_health_v1.HealthCheckResponse{}, call.sent)
+}
+
+// startTestServer on local port and connect client to it
+func startTestServer(t *testing.T, b *ServerOptionsBuilder) (*BackgroundServer, *grpc.ClientConn) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	srv := NewServer(b.AddListener(lis))
+	go func() { _ = srv.OnStart(context.Background()) }()
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return srv, conn
+}
@@ -0,0 +1,69 @@
+package grpc
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+type (
+	// ServerStream wrapper of grpc.ServerStream with replaceable context and message interception
+	ServerStream struct {
+		grpc.ServerStream
+		ctx context.Context
+
+		onRecv StreamMessageInterceptor
+		onSend StreamMessageInterceptor
+	}
+
+	// StreamMessageInterceptor called for each message in stream, returned error terminates the stream
+	StreamMessageInterceptor func(ctx context.Context, msg interface{}) error
+)
+
+// WrapServerStream with new context, it's needed when StreamMiddleware extends context of the stream
+func WrapServerStream(ctx context.Context, stream grpc.ServerStream) *ServerStream {
+	return &ServerStream{ServerStream: stream, ctx: ctx}
+}
+
+// InterceptStreamMessages creates StreamMiddleware that calls onRecv after each received message and onSend before each sent message,
+// any of interceptors could be nil
+func InterceptStreamMessages(onRecv, onSend StreamMessageInterceptor) StreamMiddleware {
+	return func(handler StreamHandler) StreamHandler {
+		return func(srv interface{}, stream grpc.ServerStream) error {
+			wrapped := WrapServerStream(stream.Context(), stream)
+			wrapped.onRecv = onRecv
+			wrapped.onSend = onSend
+
+			return handler(srv, wrapped)
+		}
+	}
+}
+
+// Context of the stream
+func (s *ServerStream) Context() context.Context {
+	return s.ctx
+}
+
+// RecvMsg from the stream and pass it to interceptor
+func (s *ServerStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+
+	if s.onRecv != nil {
+		return s.onRecv(s.ctx, m)
+	}
+
+	return nil
+}
+
+// SendMsg to the stream after it's passed by interceptor
+func (s *ServerStream) SendMsg(m interface{}) error {
+	if s.onSend != nil {
+		if err := s.onSend(s.ctx, m); err != nil {
+			return err
+		}
+	}
+
+	return s.ServerStream.SendMsg(m)
+}
@@ -0,0 +1,260 @@
+package grpc
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+var (
+	// ErrTLSCertificate is returned when certificate or key can't be loaded
+	ErrTLSCertificate = errors.New("failed to load TLS certificate")
+	// ErrTLSClientCA is returned when client CA file has no valid certificates
+	ErrTLSClientCA = errors.New("failed to load TLS client CA")
+	// ErrTLSConfig is returned when TLS options are inconsistent, for example client CA is set without server certificate
+	ErrTLSConfig = errors.New("invalid TLS configuration")
+	// ErrTLSClientIdentity is returned when client certificate identity is not allowed
+	ErrTLSClientIdentity = errors.New("client identity is not allowed")
+)
+
+type (
+	// PeerIdentity of the client that sent request
+	PeerIdentity struct {
+		Address string
+		// IsVerified when client presented certificate that was verified by client CA
+		IsVerified     bool
+		CommonName     string
+		DNSNames       []string
+		URIs           []string
+		EmailAddresses []string
+		IPAddresses    []string
+	}
+
+	// tlsSettings of the server, certificates are reloaded when files are changed
+	tlsSettings struct {
+		certFile          string
+		keyFile           string
+		clientCAFile      string
+		minVersion        uint16
+		allowedIdentities map[string]struct{}
+		reloadInterval    time.Duration
+
+		state tlsState
+	}
+
+	// tlsState of loaded certificates
+	tlsState struct {
+		cert          *tls.Certificate
+		clientCAs     *x509.CertPool
+		modTimes      map[string]time.Time
+		lastCheckedAt time.Time
+
+		sync.Mutex
+	}
+)
+
+func newTLSSettings() *tlsSettings {
+	return &tlsSettings{
+		minVersion:        tls.VersionTLS12,
+		allowedIdentities: map[string]struct{}{},
+		reloadInterval:    10 * time.Second,
+		state:             tlsState{modTimes: map[string]time.Time{}},
+	}
+}
+
+// isEnabled when certificate is configured
+func (s *tlsSettings) isEnabled() bool {
+	return s.certFile != "" && s.keyFile != ""
+}
+
+// validate that client authentication is not silently disabled by missing options
+func (s *tlsSettings) validate() error {
+	isClientAuth := s.clientCAFile != "" || len(s.allowedIdentities) > 0
+
+	switch {
+	case isClientAuth && !s.isEnabled():
+		return fmt.Errorf("%w: client authentication requires server certificate", ErrTLSConfig)
+	case len(s.allowedIdentities) > 0 && s.clientCAFile == "":
+		return fmt.Errorf("%w: allowed client identities require client CA", ErrTLSConfig)
+	}
+
+	return nil
+}
+
+// credentials for gRPC server, certificates are loaded once to fail fast on misconfiguration
+func (s *tlsSettings) credentials() (credentials.TransportCredentials, error) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+
+	return credentials.NewTLS(s.serverConfig("h2")), nil
+}
+
+// serverConfig that resolves latest certificates for each handshake, it's shared by gRPC server and HTTP gateway,
+// nextProtos are negotiated with ALPN
+func (s *tlsSettings) serverConfig(nextProtos ...string) *tls.Config {
+	return &tls.Config{
+		MinVersion: s.minVersion,
+		NextProtos: nextProtos,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			return s.configForClient(nextProtos)
+		},
+	}
+}
+
+// configForClient with latest certificates for each handshake, config of handshake replaces server config,
+// so it must have the same ALPN protocols
+func (s *tlsSettings) configForClient(nextProtos []string) (*tls.Config, error) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	if time.Since(s.state.lastCheckedAt) >= s.reloadInterval {
+		// Previous certificates are kept if new ones are broken, for example when files are partially written
+		_ = s.load()
+	}
+
+	cfg := &tls.Config{
+		MinVersion:   s.minVersion,
+		NextProtos:   nextProtos,
+		Certificates: []tls.Certificate{*s.state.cert},
+	}
+
+	if s.state.clientCAs != nil {
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+		cfg.ClientCAs = s.state.clientCAs
+	}
+
+	if len(s.allowedIdentities) > 0 {
+		cfg.VerifyPeerCertificate = s.verifyClientIdentity
+	}
+
+	return cfg, nil
+}
+
+// load certificates if files were changed since last load
+func (s *tlsSettings) load() error {
+	s.state.lastCheckedAt = time.Now()
+
+	files := []string{s.certFile, s.keyFile}
+	if s.clientCAFile != "" {
+		files = append(files, s.clientCAFile)
+	}
+
+	modTimes := make(map[string]time.Time, len(files))
+	isChanged := s.state.cert == nil
+	for _, f := range files {
+		info, err := os.Stat(f)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrTLSCertificate, err)
+		}
+
+		modTimes[f] = info.ModTime()
+		if !s.state.modTimes[f].Equal(info.ModTime()) {
+			isChanged = true
+		}
+	}
+
+	if !isChanged {
+		return nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(s.certFile, s.keyFile)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrTLSCertificate, err)
+	}
+
+	var clientCAs *x509.CertPool
+	if s.clientCAFile != "" {
+		pem, err := os.ReadFile(s.clientCAFile)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrTLSClientCA, err)
+		}
+
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("%w: no certificates in %s", ErrTLSClientCA, s.clientCAFile)
+		}
+	}
+
+	s.state.cert = &cert
+	s.state.clientCAs = clientCAs
+	s.state.modTimes = modTimes
+
+	return nil
+}
+
+// verifyClientIdentity of verified client certificate against allowed SAN or CN
+func (s *tlsSettings) verifyClientIdentity(_ [][]byte, verifiedChains [][]*x509.Certificate) error {
+	if len(verifiedChains) == 0 || len(verifiedChains[0]) == 0 {
+		return ErrTLSClientIdentity
+	}
+
+	for _, id := range certificateIdentities(verifiedChains[0][0]) {
+		if _, isAllowed := s.allowedIdentities[id]; isAllowed {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: %s", ErrTLSClientIdentity, verifiedChains[0][0].Subject.CommonName)
+}
+
+// certificateIdentities from SAN and CN
+func certificateIdentities(cert *x509.Certificate) []string {
+	ids := append([]string{cert.Subject.CommonName}, cert.DNSNames...)
+	ids = append(ids, cert.EmailAddresses...)
+
+	for _, uri := range cert.URIs {
+		ids = append(ids, uri.String())
+	}
+
+	for _, ip := range cert.IPAddresses {
+		ids = append(ids, ip.String())
+	}
+
+	return ids
+}
+
+// peerIdentityFromContext of the gRPC request
+func peerIdentityFromContext(ctx context.Context) *PeerIdentity {
+	p, isPeerExist := peer.FromContext(ctx)
+	if !isPeerExist {
+		return nil
+	}
+
+	id := &PeerIdentity{}
+	if p.Addr != nil {
+		id.Address = p.Addr.String()
+	}
+
+	tlsInfo, isTLS := p.AuthInfo.(credentials.TLSInfo)
+	if !isTLS || len(tlsInfo.State.VerifiedChains) == 0 || len(tlsInfo.State.VerifiedChains[0]) == 0 {
+		return id
+	}
+
+	cert := tlsInfo.State.VerifiedChains[0][0]
+	id.IsVerified = true
+	id.CommonName = cert.Subject.CommonName
+	id.DNSNames = cert.DNSNames
+	id.EmailAddresses = cert.EmailAddresses
+
+	for _, uri := range cert.URIs {
+		id.URIs = append(id.URIs, uri.String())
+	}
+
+	for _, ip := range cert.IPAddresses {
+		id.IPAddresses = append(id.IPAddresses, ip.String())
+	}
+
+	return id
+}
@@ -0,0 +1,208 @@
+package grpc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+type testingCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func TestServerWithMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestingCA(t)
+
+	serverCert := ca.issue(t, "localhost", 1)
+	writeTestingCertificate(t, dir, "server", serverCert)
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "ca.pem"), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.cert.Raw}), 0o600))
+
+	peers := make(chan *PeerIdentity, 1)
+	capturePeer := func(handler RequestHandler) RequestHandler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			ctxMeta, _ := GetContextMetadata(ctx)
+			peers <- ctxMeta.Peer
+
+			return handler(ctx, req)
+		}
+	}
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	srv := NewServer(
+		NewServerOptionsBuilder().
+			AddListener(lis).
+			AddTLSCertificate(filepath.Join(dir, "server.pem"), filepath.Join(dir, "server.key")).
+			AddTLSClientCA(filepath.Join(dir, "ca.pem")).
+			AddTLSAllowedClientIdentities("allowed-client").
+			AddTLSReloadInterval(time.Nanosecond).
+			AddCustomUnaryMiddlewares("*", capturePeer),
+	)
+	go func() { _ = srv.OnStart(context.Background()) }()
+	defer func() { _ = srv.OnStop(context.Background()) }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Allowed client identity is exposed to middlewares
+	serial, err := checkTestingHealth(ctx, lis.Addr().String(), ca, ca.issue(t, "allowed-client", 10))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), serial)
+
+	identity := <-peers
+	assert.True(t, identity.IsVerified)
+	assert.Equal(t, "allowed-client", identity.CommonName)
+
+	// Not allowed client identity is rejected on handshake
+	_, err = checkTestingHealth(ctx, lis.Addr().String(), ca, ca.issue(t, "unknown-client", 11))
+	assert.Error(t, err)
+
+	// Certificate is reloaded without restart when files are changed
+	writeTestingCertificate(t, dir, "server", ca.issue(t, "localhost", 2))
+	future := time.Now().Add(time.Minute)
+	assert.NoError(t, os.Chtimes(filepath.Join(dir, "server.pem"), future, future))
+
+	serial, err = checkTestingHealth(ctx, lis.Addr().String(), ca, ca.issue(t, "allowed-client", 12))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), serial)
+}
+
+func TestServerNegotiatesHTTP2(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestingCA(t)
+	writeTestingCertificate(t, dir, "server", ca.issue(t, "localhost", 1))
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	srv := NewServer(NewServerOptionsBuilder().
+		AddListener(lis).
+		AddTLSCertificate(filepath.Join(dir, "server.pem"), filepath.Join(dir, "server.key")))
+	go func() { _ = srv.OnStart(context.Background()) }()
+	defer func() { _ = srv.OnStop(context.Background()) }()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+
+	conn, err := tls.Dial("tcp", lis.Addr().String(), &tls.Config{RootCAs: roots, ServerName: "localhost", NextProtos: []string{"h2"}})
+	assert.NoError(t, err)
+	defer func() { _ = conn.Close() }()
+
+	assert.Equal(t, "h2", conn.ConnectionState().NegotiatedProtocol)
+}
+
+func TestServerWithBrokenTLSCertificate(t *testing.T) {
+	srv := NewServer(NewServerOptionsBuilder().AddTLSCertificate("not-exist.pem", "not-exist.key"))
+
+	assert.ErrorIs(t, srv.OnStart(context.Background()), ErrTLSCertificate)
+}
+
+func TestServerWithInconsistentTLSConfig(t *testing.T) {
+	srv := NewServer(NewServerOptionsBuilder().AddTLSClientCA("ca.pem"))
+	assert.ErrorIs(t, srv.OnStart(context.Background()), ErrTLSConfig)
+
+	srv = NewServer(NewServerOptionsBuilder().
+		AddTLSCertificate("server.pem", "server.key").
+		AddTLSAllowedClientIdentities("orders-service"))
+	assert.ErrorIs(t, srv.OnStart(context.Background()), ErrTLSConfig)
+}
+
+// checkTestingHealth with new connection and returns serial number of server certificate
+func checkTestingHealth(ctx context.Context, addr string, ca *testingCA, clientCert tls.Certificate) (int64, error) {
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+
+	var serial int64
+	creds := credentials.NewTLS(&tls.Config{
+		RootCAs:      roots,
+		ServerName:   "localhost",
+		Certificates: []tls.Certificate{clientCert},
+		VerifyConnection: func(cs tls.ConnectionState) error {
+			serial = cs.PeerCertificates[0].SerialNumber.Int64()
+			return nil
+		},
+	})
+
+	conn, err := grpc.DialContext(ctx, addr, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = conn.Close() }()
+
+	_, err = grpc_health_v1.NewHealthClient(conn).Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+
+	return serial, err
+}
+
+func newTestingCA(t *testing.T) *testingCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	tpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(100),
+		Subject:               pkix.Name{CommonName: "unit-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tpl, tpl, &key.PublicKey, key)
+	assert.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+
+	return &testingCA{cert: cert, key: key}
+}
+
+func (ca *testingCA) issue(t *testing.T, commonName string, serial int64) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	tpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: commonName},
+		DNSNames:     []string{commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tpl, ca.cert, &key.PublicKey, ca.key)
+	assert.NoError(t, err)
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func writeTestingCertificate(t *testing.T, dir, name string, cert tls.Certificate) {
+	keyDer, err := x509.MarshalECPrivateKey(cert.PrivateKey.(*ecdsa.PrivateKey))
+	assert.NoError(t, err)
+
+	certPem := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Certificate[0]})
+	keyPem := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, name+".pem"), certPem, 0o600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, name+".key"), keyPem, 0o600))
+}
@@ -0,0 +1,162 @@
+package validation
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Rule of the message field, field is a path of proto field names like "address.zip_code".
+// Only Required reports unset messages and optional fields, other rules are checked when they are set
+type Rule struct {
+	Field      string
+	isRequired bool
+	supports   func(fd protoreflect.FieldDescriptor) bool
+	check      func(fd protoreflect.FieldDescriptor, v protoreflect.Value) (description string)
+}
+
+// Required field, scalar must not be zero value, list and map must not be empty, message must be set
+func Required(field string) Rule {
+	return Rule{
+		Field:      field,
+		isRequired: true,
+		supports:   func(protoreflect.FieldDescriptor) bool { return true },
+		check:      func(protoreflect.FieldDescriptor, protoreflect.Value) string { return "" },
+	}
+}
+
+// MinLen of string in characters, bytes, list or map
+func MinLen(field string, n int) Rule {
+	return Rule{
+		Field:    field,
+		supports: hasLength,
+		check: func(fd protoreflect.FieldDescriptor, v protoreflect.Value) string {
+			if length(fd, v) < n {
+				return fmt.Sprintf("length must be at least %d", n)
+			}
+
+			return ""
+		},
+	}
+}
+
+// MaxLen of string in characters, bytes, list or map
+func MaxLen(field string, n int) Rule {
+	return Rule{
+		Field:    field,
+		supports: hasLength,
+		check: func(fd protoreflect.FieldDescriptor, v protoreflect.Value) string {
+			if length(fd, v) > n {
+				return fmt.Sprintf("length must be at most %d", n)
+			}
+
+			return ""
+		},
+	}
+}
+
+// Range of numeric field including bounds
+func Range(field string, min, max float64) Rule {
+	return Rule{
+		Field: field,
+		supports: func(fd protoreflect.FieldDescriptor) bool {
+			return !fd.IsList() && !fd.IsMap() && isNumeric(fd.Kind())
+		},
+		check: func(fd protoreflect.FieldDescriptor, v protoreflect.Value) string {
+			if n := number(fd, v); n < min || n > max {
+				return fmt.Sprintf("must be between %v and %v", min, max)
+			}
+
+			return ""
+		},
+	}
+}
+
+// Pattern that string field must match, panics on invalid regular expression
+func Pattern(field, expr string) Rule {
+	re := regexp.MustCompile(expr)
+
+	return Rule{
+		Field: field,
+		supports: func(fd protoreflect.FieldDescriptor) bool {
+			return !fd.IsList() && !fd.IsMap() && fd.Kind() == protoreflect.StringKind
+		},
+		check: func(_ protoreflect.FieldDescriptor, v protoreflect.Value) string {
+			if !re.MatchString(v.String()) {
+				return "must match " + expr
+			}
+
+			return ""
+		},
+	}
+}
+
+// In list of allowed values of string field or names of enum values
+func In(field string, values ...string) Rule {
+	return Rule{
+		Field: field,
+		supports: func(fd protoreflect.FieldDescriptor) bool {
+			return !fd.IsList() && !fd.IsMap() && (fd.Kind() == protoreflect.StringKind || fd.Kind() == protoreflect.EnumKind)
+		},
+		check: func(fd protoreflect.FieldDescriptor, v protoreflect.Value) string {
+			value := v.String()
+			if fd.Kind() == protoreflect.EnumKind {
+				value = ""
+				if ev := fd.Enum().Values().ByNumber(v.Enum()); ev != nil {
+					value = string(ev.Name())
+				}
+			}
+
+			for _, allowed := range values {
+				if value == allowed {
+					return ""
+				}
+			}
+
+			return "must be one of " + strings.Join(values, ", ")
+		},
+	}
+}
+
+func hasLength(fd protoreflect.FieldDescriptor) bool {
+	return fd.IsList() || fd.IsMap() || fd.Kind() == protoreflect.StringKind || fd.Kind() == protoreflect.BytesKind
+}
+
+func length(fd protoreflect.FieldDescriptor, v protoreflect.Value) int {
+	switch {
+	case fd.IsList():
+		return v.List().Len()
+	case fd.IsMap():
+		return v.Map().Len()
+	case fd.Kind() == protoreflect.StringKind:
+		return utf8.RuneCountInString(v.String())
+	default:
+		return len(v.Bytes())
+	}
+}
+
+func isNumeric(k protoreflect.Kind) bool {
+	switch k {
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind,
+		protoreflect.Uint32Kind, protoreflect.Fixed32Kind, protoreflect.Uint64Kind, protoreflect.Fixed64Kind,
+		protoreflect.FloatKind, protoreflect.DoubleKind:
+		return true
+	default:
+		return false
+	}
+}
+
+func number(fd protoreflect.FieldDescriptor, v protoreflect.Value) float64 {
+	switch fd.Kind() {
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind, protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return float64(v.Uint())
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return v.Float()
+	default:
+		return float64(v.Int())
+	}
+}
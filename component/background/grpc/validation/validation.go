@@ -0,0 +1,233 @@
+package validation
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	brokkrgrpc "github.com/Clink-n-Clank/Brokkr/component/background/grpc"
+	"github.com/Clink-n-Clank/Brokkr/component/background/grpc/errmapping"
+	"github.com/Clink-n-Clank/Brokkr/component/background/route"
+)
+
+// errorMapper converts validation errors to InvalidArgument with BadRequest details
+var errorMapper = errmapping.RegisterType[*errmapping.BadRequestError](errmapping.NewMapper(), codes.InvalidArgument, errmapping.BadRequest())
+
+type (
+	// validatable request with own validation, for example generated by protoc-gen-validate
+	validatable interface {
+		Validate() error
+	}
+
+	// fieldError with field and reason, protoc-gen-validate errors implement it
+	fieldError interface {
+		Field() string
+		Reason() string
+	}
+
+	// multiError with all errors of validation
+	multiError interface {
+		AllErrors() []error
+	}
+
+	// fieldRule of the message with resolved field path
+	fieldRule struct {
+		Rule
+		path []protoreflect.FieldDescriptor
+	}
+
+	// Validator of request messages
+	Validator struct {
+		rules   map[protoreflect.FullName][]fieldRule
+		skipped []route.Matcher
+	}
+
+	// Option for validator configuration
+	Option func(v *Validator)
+)
+
+// SetSkippedRoutes that are not validated, filters work the same way as for middlewares, panics on invalid filter
+func SetSkippedRoutes(filters ...string) Option {
+	return func(v *Validator) {
+		for _, filter := range filters {
+			v.skipped = append(v.skipped, route.MustCompile(filter))
+		}
+	}
+}
+
+// NewValidator of requests
+func NewValidator(opts ...Option) *Validator {
+	v := &Validator{rules: map[protoreflect.FullName][]fieldRule{}}
+	for _, o := range opts {
+		o(v)
+	}
+
+	return v
+}
+
+// Register rules of the message type, panics if field of the rule doesn't exist or rule doesn't support its type
+func (v *Validator) Register(msg proto.Message, rules ...Rule) *Validator {
+	desc := msg.ProtoReflect().Descriptor()
+
+	for _, r := range rules {
+		path, err := resolveFieldPath(desc, r.Field)
+		if err != nil {
+			panic("invalid validation rule of " + string(desc.FullName()) + ": " + err.Error())
+		}
+
+		if !r.supports(path[len(path)-1]) {
+			panic("invalid validation rule of " + string(desc.FullName()) + ": rule is not supported by type of field " + r.Field)
+		}
+
+		v.rules[desc.FullName()] = append(v.rules[desc.FullName()], fieldRule{Rule: r, path: path})
+	}
+
+	return v
+}
+
+// Validate message with its Validate method and registered rules, *errmapping.BadRequestError is returned on failure
+func (v *Validator) Validate(msg interface{}) error {
+	var violations []errmapping.FieldViolation
+
+	if m, isValidatable := msg.(validatable); isValidatable {
+		violations = append(violations, fieldViolations(m.Validate())...)
+	}
+
+	if m, isProto := msg.(proto.Message); isProto {
+		ref := m.ProtoReflect()
+		for _, r := range v.rules[ref.Descriptor().FullName()] {
+			if description := r.validate(ref); description != "" {
+				violations = append(violations, errmapping.FieldViolation{Field: r.Field, Description: description})
+			}
+		}
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+
+	return &errmapping.BadRequestError{Violations: violations}
+}
+
+// Middleware that validates unary requests, failure returns InvalidArgument with BadRequest details
+func (v *Validator) Middleware() brokkrgrpc.Middleware {
+	return func(handler brokkrgrpc.RequestHandler) brokkrgrpc.RequestHandler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			if err := v.validateRequest(ctx, req); err != nil {
+				return nil, err
+			}
+
+			return handler(ctx, req)
+		}
+	}
+}
+
+// StreamMiddleware that validates each received message of the stream, failure terminates the stream
+func (v *Validator) StreamMiddleware() brokkrgrpc.StreamMiddleware {
+	return brokkrgrpc.InterceptStreamMessages(v.validateRequest, nil)
+}
+
+// validateRequest of the route if it's not skipped
+func (v *Validator) validateRequest(ctx context.Context, req interface{}) error {
+	ctxMeta, _ := brokkrgrpc.GetContextMetadata(ctx)
+	if v.isSkipped(ctxMeta.FullMethod) {
+		return nil
+	}
+
+	return errorMapper.Error(v.Validate(req))
+}
+
+func (v *Validator) isSkipped(fullMethod string) bool {
+	for _, m := range v.skipped {
+		if m.Match(fullMethod) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// validate field of the message, unset messages and optional fields are reported only by Required rule
+func (r fieldRule) validate(msg protoreflect.Message) string {
+	for _, fd := range r.path[:len(r.path)-1] {
+		if !msg.Has(fd) {
+			return r.unset()
+		}
+
+		msg = msg.Get(fd).Message()
+	}
+
+	// Fields without presence like proto3 scalars are checked with their zero value
+	fd := r.path[len(r.path)-1]
+	if !msg.Has(fd) && (r.isRequired || fd.HasPresence()) {
+		return r.unset()
+	}
+
+	return r.check(fd, msg.Get(fd))
+}
+
+func (r fieldRule) unset() string {
+	if r.isRequired {
+		return "is required"
+	}
+
+	return ""
+}
+
+// resolveFieldPath of nested fields separated by dot
+func resolveFieldPath(desc protoreflect.MessageDescriptor, field string) ([]protoreflect.FieldDescriptor, error) {
+	names := strings.Split(field, ".")
+	path := make([]protoreflect.FieldDescriptor, 0, len(names))
+
+	for i, name := range names {
+		if desc == nil {
+			return nil, errors.New("field " + strings.Join(names[:i], ".") + " is not a message")
+		}
+
+		fd := desc.Fields().ByName(protoreflect.Name(name))
+		if fd == nil {
+			return nil, errors.New("unknown field " + field)
+		}
+
+		path = append(path, fd)
+
+		desc = nil
+		if fd.Message() != nil && !fd.IsList() && !fd.IsMap() {
+			desc = fd.Message()
+		}
+	}
+
+	return path, nil
+}
+
+// fieldViolations of error returned by Validate method of the message
+func fieldViolations(err error) []errmapping.FieldViolation {
+	if err == nil {
+		return nil
+	}
+
+	var violationsErr errmapping.FieldViolationsError
+	if errors.As(err, &violationsErr) {
+		return violationsErr.FieldViolations()
+	}
+
+	if multi, isMulti := err.(multiError); isMulti {
+		var violations []errmapping.FieldViolation
+		for _, e := range multi.AllErrors() {
+			violations = append(violations, fieldViolations(e)...)
+		}
+
+		return violations
+	}
+
+	var fieldErr fieldError
+	if errors.As(err, &fieldErr) {
+		return []errmapping.FieldViolation{{Field: fieldErr.Field(), Description: fieldErr.Reason()}}
+	}
+
+	return []errmapping.FieldViolation{{Description: err.Error()}}
+}
@@ -0,0 +1,211 @@
+package validation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	brokkrgrpc "github.com/Clink-n-Clank/Brokkr/component/background/grpc"
+	"github.com/Clink-n-Clank/Brokkr/component/background/grpc/errmapping"
+)
+
+// testingValidatable request with own validation method
+type testingValidatable struct {
+	err error
+}
+
+func (r testingValidatable) Validate() error {
+	return r.err
+}
+
+// testingFieldError like errors of protoc-gen-validate
+type testingFieldError struct {
+	field, reason string
+}
+
+func (e testingFieldError) Field() string  { return e.field }
+func (e testingFieldError) Reason() string { return e.reason }
+func (e testingFieldError) Error() string  { return e.field + ": " + e.reason }
+
+type testingMultiError []error
+
+func (e testingMultiError) Error() string      { return fmt.Sprint([]error(e)) }
+func (e testingMultiError) AllErrors() []error { return e }
+
+type testingServerStream struct {
+	grpc.ServerStream
+	ctx  context.Context
+	msgs []proto.Message
+}
+
+func (s *testingServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *testingServerStream) RecvMsg(m interface{}) error {
+	proto.Merge(m.(proto.Message), s.msgs[0])
+	s.msgs = s.msgs[1:]
+
+	return nil
+}
+
+func testingValidator(opts ...Option) *Validator {
+	return NewValidator(opts...).
+		Register(&errdetails.ErrorInfo{},
+			Required("reason"),
+			Pattern("reason", "^[A-Z_]+$"),
+			MaxLen("domain", 10),
+			MinLen("metadata", 1),
+		).
+		Register(&errdetails.RetryInfo{},
+			Required("retry_delay"),
+			Range("retry_delay.seconds", 1, 60),
+		).
+		Register(&grpc_health_v1.HealthCheckResponse{},
+			In("status", "SERVING", "NOT_SERVING"),
+		)
+}
+
+func TestValidator_Rules(t *testing.T) {
+	v := testingValidator()
+
+	tests := []struct {
+		msg        interface{}
+		violations []errmapping.FieldViolation
+	}{
+		{
+			&errdetails.ErrorInfo{Reason: "STOCK_EXHAUSTED", Domain: "orders", Metadata: map[string]string{"sku": "1"}},
+			nil,
+		},
+		{
+			&errdetails.ErrorInfo{Reason: "exhausted", Domain: "orders.example.com"},
+			[]errmapping.FieldViolation{
+				{Field: "reason", Description: "must match ^[A-Z_]+$"},
+				{Field: "domain", Description: "length must be at most 10"},
+				{Field: "metadata", Description: "length must be at least 1"},
+			},
+		},
+		{
+			&errdetails.ErrorInfo{Metadata: map[string]string{"sku": "1"}},
+			[]errmapping.FieldViolation{
+				{Field: "reason", Description: "is required"},
+				{Field: "reason", Description: "must match ^[A-Z_]+$"},
+			},
+		},
+		{
+			&errdetails.RetryInfo{},
+			[]errmapping.FieldViolation{{Field: "retry_delay", Description: "is required"}},
+		},
+		{
+			&errdetails.RetryInfo{RetryDelay: &durationpb.Duration{}},
+			[]errmapping.FieldViolation{{Field: "retry_delay.seconds", Description: "must be between 1 and 60"}},
+		},
+		{
+			&errdetails.RetryInfo{RetryDelay: &durationpb.Duration{Seconds: 30}},
+			nil,
+		},
+		{
+			&grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_SERVICE_UNKNOWN},
+			[]errmapping.FieldViolation{{Field: "status", Description: "must be one of SERVING, NOT_SERVING"}},
+		},
+		{
+			&errdetails.DebugInfo{},
+			nil,
+		},
+	}
+
+	for _, tt := range tests {
+		err := v.Validate(tt.msg)
+		if tt.violations == nil {
+			assert.NoError(t, err)
+			continue
+		}
+
+		var badRequest *errmapping.BadRequestError
+		assert.ErrorAs(t, err, &badRequest)
+		assert.Equal(t, tt.violations, badRequest.Violations)
+	}
+}
+
+func TestValidator_ValidateMethod(t *testing.T) {
+	v := NewValidator()
+
+	tests := []struct {
+		err        error
+		violations []errmapping.FieldViolation
+	}{
+		{nil, nil},
+		{errors.New("invalid"), []errmapping.FieldViolation{{Description: "invalid"}}},
+		{testingFieldError{"email", "must be set"}, []errmapping.FieldViolation{{Field: "email", Description: "must be set"}}},
+		{
+			testingMultiError{testingFieldError{"email", "must be set"}, testingFieldError{"age", "must be positive"}},
+			[]errmapping.FieldViolation{{Field: "email", Description: "must be set"}, {Field: "age", Description: "must be positive"}},
+		},
+	}
+
+	for _, tt := range tests {
+		err := v.Validate(testingValidatable{err: tt.err})
+		if tt.violations == nil {
+			assert.NoError(t, err)
+			continue
+		}
+
+		var badRequest *errmapping.BadRequestError
+		assert.ErrorAs(t, err, &badRequest)
+		assert.Equal(t, tt.violations, badRequest.Violations)
+	}
+}
+
+func TestValidator_Register(t *testing.T) {
+	assert.Panics(t, func() { NewValidator().Register(&errdetails.ErrorInfo{}, Required("unknown")) })
+	assert.Panics(t, func() { NewValidator().Register(&errdetails.ErrorInfo{}, Range("reason", 0, 1)) })
+	assert.Panics(t, func() { NewValidator().Register(&errdetails.ErrorInfo{}, Required("reason.nested")) })
+}
+
+func TestValidator_Middleware(t *testing.T) {
+	v := testingValidator(SetSkippedRoutes("/svc.Internal/*"))
+	handler := v.Middleware()(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return req, nil
+	})
+
+	ctxFor := func(fullMethod string) context.Context {
+		return brokkrgrpc.NewMiddlewareComposer().ExtendContext(context.Background(), brokkrgrpc.RequestContextMetadata{FullMethod: fullMethod})
+	}
+
+	_, err := handler(ctxFor("/svc.Orders/Create"), &errdetails.RetryInfo{})
+	s := status.Convert(err)
+	assert.Equal(t, codes.InvalidArgument, s.Code())
+	assert.Equal(t, "retry_delay", s.Details()[0].(*errdetails.BadRequest).GetFieldViolations()[0].GetField())
+
+	_, err = handler(ctxFor("/svc.Internal/Create"), &errdetails.RetryInfo{})
+	assert.NoError(t, err)
+
+	// Stream messages are validated on receive
+	stream := &testingServerStream{
+		ctx:  ctxFor("/svc.Orders/Upload"),
+		msgs: []proto.Message{&errdetails.ErrorInfo{Reason: "OK", Metadata: map[string]string{"a": "b"}}, &errdetails.ErrorInfo{}},
+	}
+
+	var received int
+	err = v.StreamMiddleware()(func(srv interface{}, stream grpc.ServerStream) error {
+		for {
+			if recvErr := stream.RecvMsg(&errdetails.ErrorInfo{}); recvErr != nil {
+				return recvErr
+			}
+
+			received++
+		}
+	})(nil, stream)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Equal(t, 1, received)
+}
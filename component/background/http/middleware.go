@@ -0,0 +1,82 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/Clink-n-Clank/Brokkr/component/background/route"
+)
+
+// Abstract middleware functionality based on chain of responsibility
+
+type (
+	// Middleware to do some actions between HTTP requests
+	Middleware func(http.Handler) http.Handler
+
+	// MiddlewareComposer keeps middlewares and keeps it sorted by filter
+	MiddlewareComposer struct {
+		routes      route.Table[Middleware]
+		registerErr error
+	}
+	middlewareComposerContextMetadataKey struct{}
+	// RequestContextMetadata context data from interception
+	RequestContextMetadata struct {
+		Header     http.Header
+		Method     string
+		Path       string
+		RemoteAddr string
+	}
+)
+
+// NewMiddlewareComposer instance
+func NewMiddlewareComposer() *MiddlewareComposer {
+	return &MiddlewareComposer{}
+}
+
+// ExtendContext baseCtx with new RequestContextMetadata
+func (mc *MiddlewareComposer) ExtendContext(baseCtx context.Context, newCtxMetadata RequestContextMetadata) context.Context {
+	return context.WithValue(baseCtx, middlewareComposerContextMetadataKey{}, newCtxMetadata)
+}
+
+// Register middleware with filter, see route package for filter syntax and order of execution
+func (mc *MiddlewareComposer) Register(filter string, mw ...Middleware) {
+	mc.RegisterWithPriority(filter, 0, mw...)
+}
+
+// RegisterWithPriority middleware with filter, middlewares with higher priority run first
+func (mc *MiddlewareComposer) RegisterWithPriority(filter string, priority int, mw ...Middleware) {
+	mc.registerErr = errors.Join(mc.registerErr, mc.routes.Register(filter, priority, mw...))
+}
+
+// Err of middlewares registered with invalid filter, such middlewares are never called
+func (mc *MiddlewareComposer) Err() error {
+	return mc.registerErr
+}
+
+// Search middlewares of all filters that match request path in order of execution
+func (mc *MiddlewareComposer) Search(requestPath string) []Middleware {
+	return mc.routes.Search(requestPath)
+}
+
+// Filters of registered middlewares in order of execution
+func (mc *MiddlewareComposer) Filters() []string {
+	return mc.routes.Filters()
+}
+
+// PassToNext delegate to next middleware to execute
+func (mc *MiddlewareComposer) PassToNext(m ...Middleware) Middleware {
+	return func(handler http.Handler) http.Handler {
+		for i := len(m) - 1; i >= 0; i-- {
+			handler = m[i](handler)
+		}
+
+		return handler
+	}
+}
+
+// GetContextMetadata will try get form context.Context metadata about request from middleware during interception
+func GetContextMetadata(baseCtx context.Context) (meta RequestContextMetadata, isExist bool) {
+	meta, isExist = baseCtx.Value(middlewareComposerContextMetadataKey{}).(RequestContextMetadata)
+	return
+}
@@ -0,0 +1,53 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Clink-n-Clank/Brokkr/component/background/route"
+)
+
+var testingPassToMiddleware int
+
+func TestRegisterAndSearchOfMiddlewares(t *testing.T) {
+	mc := NewMiddlewareComposer()
+	mc.Register("*", testingMiddleware)
+	mc.Register("/api/*", testingMiddleware)
+	mc.Register("/api/v1/*", testingMiddleware, testingMiddleware)
+	mc.Register("/api/v1/users", testingMiddleware, testingMiddleware, testingMiddleware)
+
+	assert.Len(t, mc.Search("/health"), 1)
+	assert.Len(t, mc.Search("/api/v2/users"), 2)
+	assert.Len(t, mc.Search("/api/v1/orders"), 4, "all matched prefixes expected to be used")
+	assert.Len(t, mc.Search("/api/v1/users"), 7, "exact route expected to be composed with prefixes")
+	assert.Equal(t, []string{"*", "/api/*", "/api/v1/*", "/api/v1/users"}, mc.Filters())
+	assert.NoError(t, mc.Err())
+}
+
+func TestInvalidFilterIsReportedOnStart(t *testing.T) {
+	srv := NewServer(NewServerOptionsBuilder().AddCustomMiddlewares("~(", testingMiddleware))
+	assert.ErrorIs(t, srv.OnStart(context.Background()), route.ErrInvalidFilter)
+}
+
+func TestPassToNext(t *testing.T) {
+	testingPassToMiddleware = 0
+
+	mc := NewMiddlewareComposer()
+	handler := mc.PassToNext(testingMiddleware, testingMiddleware)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		testingPassToMiddleware++
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, 3, testingPassToMiddleware)
+}
+
+func testingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		testingPassToMiddleware++
+		next.ServeHTTP(w, r)
+	})
+}
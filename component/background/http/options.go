@@ -0,0 +1,97 @@
+package http
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"time"
+)
+
+// Options sets options such as timeouts, routes, etc.
+type Options func(o *BackgroundServer)
+
+// ServerOptionsBuilder sets options such as timeouts, routes, etc, related to HTTP server
+type ServerOptionsBuilder struct {
+	srvOpts []Options
+}
+
+// NewServerOptionsBuilder for HTTP server configuration
+func NewServerOptionsBuilder() *ServerOptionsBuilder {
+	return &ServerOptionsBuilder{srvOpts: make([]Options, 0)}
+}
+
+// AddNetwork type that will be used in HTTP server
+func (b *ServerOptionsBuilder) AddNetwork(n string) *ServerOptionsBuilder {
+	b.srvOpts = append(b.srvOpts, func(s *BackgroundServer) { s.network = n })
+	return b
+}
+
+// AddAddress that will be used in HTTP server endpoint
+func (b *ServerOptionsBuilder) AddAddress(a string) *ServerOptionsBuilder {
+	b.srvOpts = append(b.srvOpts, func(s *BackgroundServer) { s.address = a })
+	return b
+}
+
+// AddListener custom value
+func (b *ServerOptionsBuilder) AddListener(l net.Listener) *ServerOptionsBuilder {
+	b.srvOpts = append(b.srvOpts, func(s *BackgroundServer) { s.listener = l })
+	return b
+}
+
+// AddTLSConfig to serve HTTPS, listener is wrapped with TLS
+func (b *ServerOptionsBuilder) AddTLSConfig(cfg *tls.Config) *ServerOptionsBuilder {
+	b.srvOpts = append(b.srvOpts, func(s *BackgroundServer) { s.tlsConfig = cfg })
+	return b
+}
+
+// AddShutdownTimeout for HTTP server, active requests are awaited until timeout
+func (b *ServerOptionsBuilder) AddShutdownTimeout(t time.Duration) *ServerOptionsBuilder {
+	b.srvOpts = append(b.srvOpts, func(s *BackgroundServer) { s.timeout = t })
+	return b
+}
+
+// AddReadTimeout maximum duration for reading the entire request
+func (b *ServerOptionsBuilder) AddReadTimeout(t time.Duration) *ServerOptionsBuilder {
+	b.srvOpts = append(b.srvOpts, func(s *BackgroundServer) { s.Server.ReadTimeout = t })
+	return b
+}
+
+// AddWriteTimeout maximum duration before timing out writes of the response
+func (b *ServerOptionsBuilder) AddWriteTimeout(t time.Duration) *ServerOptionsBuilder {
+	b.srvOpts = append(b.srvOpts, func(s *BackgroundServer) { s.Server.WriteTimeout = t })
+	return b
+}
+
+// AddIdleTimeout maximum amount of time to wait for the next request when keep-alives are enabled
+func (b *ServerOptionsBuilder) AddIdleTimeout(t time.Duration) *ServerOptionsBuilder {
+	b.srvOpts = append(b.srvOpts, func(s *BackgroundServer) { s.Server.IdleTimeout = t })
+	return b
+}
+
+// AddHandler for route pattern, patterns are the same as in http.ServeMux
+func (b *ServerOptionsBuilder) AddHandler(pattern string, h http.Handler) *ServerOptionsBuilder {
+	b.srvOpts = append(b.srvOpts, func(s *BackgroundServer) { s.Handle(pattern, h) })
+	return b
+}
+
+// AddHandlerFunc for route pattern, patterns are the same as in http.ServeMux
+func (b *ServerOptionsBuilder) AddHandlerFunc(pattern string, h func(http.ResponseWriter, *http.Request)) *ServerOptionsBuilder {
+	b.srvOpts = append(b.srvOpts, func(s *BackgroundServer) { s.Handle(pattern, http.HandlerFunc(h)) })
+	return b
+}
+
+// AddCustomMiddlewares that have first priority to intercepted request in the middlewares and forwards it to handler if needed
+// filter used for calling middleware for example:
+// - /api/v1/*                 - Middleware will be executed for all endpoints under "/api/v1/"
+// - /api/v1/OnlyThatEndpoint  - Middleware will be executed only for "OnlyThatEndpoint"
+// - !/health                  - Middleware will be executed for all endpoints except health check
+// glob and regex filters are supported as well, middlewares of all matched filters are executed, see route package
+func (b *ServerOptionsBuilder) AddCustomMiddlewares(filter string, mwList ...Middleware) *ServerOptionsBuilder {
+	b.srvOpts = append(b.srvOpts, func(s *BackgroundServer) { s.middlewareComposer.Register(filter, mwList...) })
+	return b
+}
+
+// Build will make sure that all needed options prepared for server
+func (b *ServerOptionsBuilder) Build() []Options {
+	return b.srvOpts
+}
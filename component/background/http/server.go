@@ -0,0 +1,148 @@
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/Clink-n-Clank/Brokkr/component/background"
+)
+
+// BackgroundServer wrapper
+type BackgroundServer struct {
+	*http.Server
+	mux                *http.ServeMux
+	middlewareComposer *MiddlewareComposer
+
+	network string
+	address string
+	timeout time.Duration
+
+	listener    net.Listener
+	listenerErr error
+	tlsConfig   *tls.Config
+}
+
+const (
+	processName = "HTTP Server"
+	netProtocol = "tcp"
+	netAddress  = ":0"
+)
+
+// NewServer instance
+func NewServer(builder *ServerOptionsBuilder) *BackgroundServer {
+	// Set defaults for new process wrapper
+	serv := &BackgroundServer{
+		Server:             &http.Server{ReadHeaderTimeout: 10 * time.Second},
+		mux:                http.NewServeMux(),
+		middlewareComposer: NewMiddlewareComposer(),
+		network:            netProtocol,
+		address:            netAddress,
+		timeout:            30 * time.Second,
+	}
+
+	// Load additional http server options
+	for _, o := range builder.Build() {
+		o(serv)
+	}
+
+	serv.Server.Handler = http.HandlerFunc(serv.serveWithMiddlewares)
+	serv.listenerErr = serv.listen()
+	if serv.listenerErr == nil && serv.tlsConfig != nil {
+		serv.listener = tls.NewListener(serv.listener, serv.tlsConfig)
+	}
+
+	return serv
+}
+
+// GetName of the task
+func (s *BackgroundServer) GetName() string {
+	return processName
+}
+
+// GetSeverity of the task
+func (s *BackgroundServer) GetSeverity() background.ProcessSeverity {
+	return background.TaskSeverityMajor
+}
+
+// Handle registers handler for route pattern, patterns are the same as in http.ServeMux
+func (s *BackgroundServer) Handle(pattern string, h http.Handler) {
+	s.mux.Handle(pattern, h)
+}
+
+// ListenerAddr of the listener that server is serving on
+func (s *BackgroundServer) ListenerAddr() net.Addr {
+	if s.listener == nil {
+		return nil
+	}
+
+	return s.listener.Addr()
+}
+
+// OnStart event to be called when main loop will be started
+func (s *BackgroundServer) OnStart(_ context.Context) error {
+	if s.listenerErr != nil {
+		return s.listenerErr
+	}
+
+	if err := s.middlewareComposer.Err(); err != nil {
+		return err
+	}
+
+	if err := s.Serve(s.listener); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
+
+// OnStop event to be called when main loop will be stopped, active requests are awaited until shutdown timeout
+func (s *BackgroundServer) OnStop(ctx context.Context) error {
+	shutdownCtx, shutdownCtxCancel := context.WithTimeout(ctx, s.timeout)
+	defer shutdownCtxCancel()
+
+	if err := s.Shutdown(shutdownCtx); err != nil {
+		return errors.Join(err, s.Close())
+	}
+
+	return nil
+}
+
+// Listen network traffic for service handling
+func (s *BackgroundServer) listen() error {
+	if s.listener == nil {
+		lis, err := net.Listen(s.network, s.address)
+		if err != nil {
+			return err
+		}
+
+		s.listener = lis
+	}
+
+	return nil
+}
+
+// serveWithMiddlewares managing HTTP request interception to delegate it to Middleware
+func (s *BackgroundServer) serveWithMiddlewares(w http.ResponseWriter, r *http.Request) {
+	ctx := s.middlewareComposer.ExtendContext(r.Context(), RequestContextMetadata{
+		Header:     r.Header,
+		Method:     r.Method,
+		Path:       r.URL.Path,
+		RemoteAddr: r.RemoteAddr,
+	})
+
+	//
+	// Look up for registered middlewares
+	//
+	var handler http.Handler = s.mux
+
+	affectedMiddlewares := s.middlewareComposer.Search(r.URL.Path)
+	if len(affectedMiddlewares) > 0 {
+		handler = s.middlewareComposer.PassToNext(affectedMiddlewares...)(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
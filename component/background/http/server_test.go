@@ -0,0 +1,93 @@
+package http
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Clink-n-Clank/Brokkr/component/background"
+)
+
+func TestServer(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	srv := NewServer(
+		NewServerOptionsBuilder().
+			AddListener(lis).
+			AddHandlerFunc("/api/v1/users", func(w http.ResponseWriter, r *http.Request) {
+				meta, isExist := GetContextMetadata(r.Context())
+				assert.True(t, isExist)
+
+				_, _ = w.Write([]byte(meta.Method + " " + meta.Path + " " + meta.Header.Get("X-Unit")))
+			}).
+			AddCustomMiddlewares("/api/*", func(next http.Handler) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.Header().Set("X-Middleware", "api")
+					next.ServeHTTP(w, r)
+				})
+			}),
+	)
+
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- srv.OnStart(context.Background()) }()
+
+	req, err := http.NewRequest(http.MethodGet, "http://"+srv.ListenerAddr().String()+"/api/v1/users", nil)
+	assert.NoError(t, err)
+	req.Header.Set("X-Unit", "test")
+
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	_ = resp.Body.Close()
+
+	assert.Equal(t, "GET /api/v1/users test", string(body))
+	assert.Equal(t, "api", resp.Header.Get("X-Middleware"))
+
+	assert.NoError(t, srv.OnStop(context.Background()))
+	assert.NoError(t, <-serveErr, "server closed by stop is not an error")
+	assert.Equal(t, processName, srv.GetName())
+	assert.Equal(t, background.TaskSeverityMajor, srv.GetSeverity())
+}
+
+func TestServerGracefulShutdown(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	requestStarted := make(chan struct{})
+	srv := NewServer(
+		NewServerOptionsBuilder().
+			AddListener(lis).
+			AddShutdownTimeout(20*time.Millisecond).
+			AddHandlerFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+				close(requestStarted)
+				time.Sleep(time.Second)
+			}),
+	)
+	go func() { _ = srv.OnStart(context.Background()) }()
+
+	go func() {
+		resp, err := http.Get("http://" + srv.ListenerAddr().String() + "/slow")
+		if err == nil {
+			_ = resp.Body.Close()
+		}
+	}()
+	<-requestStarted
+
+	startedAt := time.Now()
+	assert.Error(t, srv.OnStop(context.Background()), "active request expected to exceed shutdown timeout")
+	assert.Less(t, time.Since(startedAt), time.Second)
+}
+
+func TestListenerError(t *testing.T) {
+	srv := NewServer(NewServerOptionsBuilder().AddNetwork("unknown"))
+
+	assert.Error(t, srv.OnStart(context.Background()))
+}
@@ -0,0 +1,371 @@
+package leader
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Clink-n-Clank/Brokkr/component/background"
+	"github.com/Clink-n-Clank/Brokkr/component/behavior/lease"
+)
+
+type (
+	// Elector a process that campaigns for leadership using lease and keeps it until it's lost or stopped
+	Elector struct {
+		name     string
+		severity background.ProcessSeverity
+
+		locker        lease.Locker
+		key           string
+		owner         string
+		leaseTTL      time.Duration
+		retryInterval time.Duration
+
+		onElected        func(ctx context.Context)
+		onRevoked        func()
+		onProcessError   func(p background.Process, err error)
+		processFactories []ProcessFactory
+
+		state electorState
+	}
+
+	// electorState of the leadership
+	electorState struct {
+		lease            lease.Lease
+		isLeader         bool
+		isStopped        bool
+		leaderCtx        context.Context
+		leaderCtxCancel  func()
+		processes        []background.Process
+		runningProcesses sync.WaitGroup
+		stopped          chan struct{}
+		stopOnce         sync.Once
+		// resignation of the leadership term identified by its context
+		resignation chan context.Context
+
+		sync.Mutex
+	}
+
+	// Option for elector configuration
+	Option func(e *Elector)
+
+	// ProcessFactory creates new instance of the process for each leadership term, since stopped processes can't be restarted
+	ProcessFactory func() background.Process
+)
+
+// SetKey of the lease, name of the elector is used by default
+func SetKey(key string) Option {
+	return func(e *Elector) {
+		e.key = key
+	}
+}
+
+// SetOwner identity of this replica, random UUID is used by default
+func SetOwner(owner string) Option {
+	return func(e *Elector) {
+		e.owner = owner
+	}
+}
+
+// SetLeaseTTL how long leadership is kept without renewal
+func SetLeaseTTL(ttl time.Duration) Option {
+	return func(e *Elector) {
+		e.leaseTTL = ttl
+	}
+}
+
+// SetRetryInterval how often follower tries to become a leader
+func SetRetryInterval(interval time.Duration) Option {
+	return func(e *Elector) {
+		e.retryInterval = interval
+	}
+}
+
+// SetOnElected callback with context that is canceled when leadership is lost,
+// it's called in own goroutine, so slow callback doesn't block renewal of the lease
+func SetOnElected(h func(ctx context.Context)) Option {
+	return func(e *Elector) {
+		e.onElected = h
+	}
+}
+
+// SetOnRevoked callback when leadership is lost or given up on stop
+func SetOnRevoked(h func()) Option {
+	return func(e *Elector) {
+		e.onRevoked = h
+	}
+}
+
+// SetProcessErrorHandler called when process fails to start or stops with error during leadership,
+// leadership is given up after the failure, so another replica could take over
+func SetProcessErrorHandler(h func(p background.Process, err error)) Option {
+	return func(e *Elector) {
+		e.onProcessError = h
+	}
+}
+
+// SetSeverity of how important for the application to run this elector
+func SetSeverity(s background.ProcessSeverity) Option {
+	return func(e *Elector) {
+		e.severity = s
+	}
+}
+
+// NewElector instance that campaigns with given locker
+func NewElector(name string, locker lease.Locker, opts ...Option) *Elector {
+	e := &Elector{
+		name:           name,
+		severity:       background.TaskSeverityMajor,
+		locker:         locker,
+		key:            name,
+		owner:          uuid.NewString(),
+		leaseTTL:       15 * time.Second,
+		retryInterval:  5 * time.Second,
+		onElected:      func(context.Context) {},
+		onRevoked:      func() {},
+		onProcessError: func(background.Process, error) {},
+	}
+
+	for _, o := range opts {
+		o(e)
+	}
+
+	e.state.stopped = make(chan struct{})
+	e.state.resignation = make(chan context.Context, 1)
+	e.state.leaderCtx, e.state.leaderCtxCancel = context.WithCancel(context.Background())
+	e.state.leaderCtxCancel()
+
+	return e
+}
+
+// AddProcesses that will be created and started when elected and stopped when leadership is lost
+func (e *Elector) AddProcesses(f ...ProcessFactory) {
+	e.state.Lock()
+	defer e.state.Unlock()
+
+	e.processFactories = append(e.processFactories, f...)
+}
+
+// GetName of the elector
+func (e *Elector) GetName() string {
+	return e.name
+}
+
+// GetSeverity of the elector
+func (e *Elector) GetSeverity() background.ProcessSeverity {
+	return e.severity
+}
+
+// IsLeader if this replica holds leadership
+func (e *Elector) IsLeader() bool {
+	e.state.Lock()
+	defer e.state.Unlock()
+
+	return e.state.isLeader
+}
+
+// LeaderContext that is canceled when leadership is lost, it's already canceled if replica is not a leader
+func (e *Elector) LeaderContext() context.Context {
+	e.state.Lock()
+	defer e.state.Unlock()
+
+	return e.state.leaderCtx
+}
+
+// Lease held by the leader, fencing token of it can be used to protect shared resources
+func (e *Elector) Lease() (lease.Lease, bool) {
+	e.state.Lock()
+	defer e.state.Unlock()
+
+	return e.state.lease, e.state.isLeader
+}
+
+// OnStart campaigns for leadership until stop
+func (e *Elector) OnStart(_ context.Context) error {
+	for {
+		select {
+		case <-e.state.stopped:
+			return nil
+		default:
+		}
+
+		e.campaign()
+
+		interval := e.retryInterval
+		if e.IsLeader() {
+			interval = e.leaseTTL / 3
+		}
+
+		select {
+		case <-e.state.stopped:
+			return nil
+		case leaderCtx := <-e.state.resignation:
+			e.resign(leaderCtx)
+
+			// Replica waits as follower, so another replica could take over
+			select {
+			case <-e.state.stopped:
+				return nil
+			case <-time.After(e.retryInterval):
+			}
+		case <-time.After(interval):
+		}
+	}
+}
+
+// OnStop gives up leadership and stops processes that depend on it
+func (e *Elector) OnStop(ctx context.Context) error {
+	e.state.stopOnce.Do(func() { close(e.state.stopped) })
+
+	e.state.Lock()
+	e.state.isStopped = true
+	e.state.Unlock()
+
+	current, isLeader := e.Lease()
+	if !isLeader {
+		return nil
+	}
+
+	stopErr := e.revoke(ctx)
+
+	return errors.Join(stopErr, e.locker.Release(ctx, current))
+}
+
+// campaign by acquiring or renewing the lease
+func (e *Elector) campaign() {
+	ctx, ctxCancel := context.WithTimeout(context.Background(), e.leaseTTL)
+	defer ctxCancel()
+
+	current, isLeader := e.Lease()
+	if !isLeader {
+		acquired, err := e.locker.Acquire(ctx, e.key, e.owner, e.leaseTTL)
+		if err == nil && !e.elect(acquired) {
+			_ = e.locker.Release(ctx, acquired)
+		}
+
+		return
+	}
+
+	renewed, err := e.locker.Renew(ctx, current, e.leaseTTL)
+	if err == nil {
+		e.state.Lock()
+		e.state.lease = renewed
+		e.state.Unlock()
+
+		return
+	}
+
+	// Transient errors are tolerated while lease is still valid
+	if errors.Is(err, lease.ErrLeaseLost) || !current.IsValid(time.Now().Add(e.leaseTTL/3)) {
+		revokeCtx, revokeCtxCancel := context.WithTimeout(context.Background(), e.leaseTTL)
+		defer revokeCtxCancel()
+
+		_ = e.revoke(revokeCtx)
+	}
+}
+
+// elect this replica as leader and start dependent processes, returns false if elector is already stopped
+func (e *Elector) elect(l lease.Lease) bool {
+	e.state.Lock()
+	if e.state.isStopped {
+		e.state.Unlock()
+		return false
+	}
+
+	e.state.lease = l
+	e.state.isLeader = true
+	e.state.leaderCtx, e.state.leaderCtxCancel = context.WithCancel(context.Background())
+	leaderCtx := e.state.leaderCtx
+
+	processes := make([]background.Process, 0, len(e.processFactories))
+	for _, newProcess := range e.processFactories {
+		processes = append(processes, newProcess())
+	}
+	e.state.processes = processes
+	e.state.Unlock()
+
+	for _, p := range processes {
+		process := p
+
+		e.state.runningProcesses.Add(1)
+		go func() {
+			defer e.state.runningProcesses.Done()
+
+			// Process stopped by revoke is not a failure
+			if err := process.OnStart(leaderCtx); err != nil && leaderCtx.Err() == nil {
+				e.onProcessError(process, err)
+				e.requestResignation(leaderCtx)
+			}
+		}()
+	}
+
+	e.state.runningProcesses.Add(1)
+	go func() {
+		defer e.state.runningProcesses.Done()
+		e.onElected(leaderCtx)
+	}()
+
+	return true
+}
+
+// requestResignation of leadership term from campaign loop, revoke can't be called by process since it awaits processes
+func (e *Elector) requestResignation(leaderCtx context.Context) {
+	select {
+	case e.state.resignation <- leaderCtx:
+	default: // Resignation is already requested
+	}
+}
+
+// resign leadership term if it's still current, so another replica could take over
+func (e *Elector) resign(leaderCtx context.Context) {
+	current, isLeader := e.Lease()
+	if !isLeader || e.LeaderContext() != leaderCtx {
+		return
+	}
+
+	ctx, ctxCancel := context.WithTimeout(context.Background(), e.leaseTTL)
+	defer ctxCancel()
+
+	_ = e.revoke(ctx)
+	_ = e.locker.Release(ctx, current)
+}
+
+// revoke leadership, cancel leader context and stop dependent processes
+func (e *Elector) revoke(ctx context.Context) error {
+	e.state.Lock()
+	if !e.state.isLeader {
+		e.state.Unlock()
+		return nil
+	}
+
+	e.state.isLeader = false
+	e.state.lease = lease.Lease{}
+	e.state.leaderCtxCancel()
+	processes := e.state.processes
+	e.state.processes = nil
+	e.state.Unlock()
+
+	var stopErr error
+	for _, p := range processes {
+		stopErr = errors.Join(stopErr, p.OnStop(ctx))
+	}
+
+	processesDone := make(chan struct{})
+	go func() {
+		e.state.runningProcesses.Wait()
+		close(processesDone)
+	}()
+
+	select {
+	case <-processesDone:
+	case <-ctx.Done():
+		stopErr = errors.Join(stopErr, ctx.Err())
+	}
+
+	e.onRevoked()
+
+	return stopErr
+}
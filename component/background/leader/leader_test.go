@@ -0,0 +1,238 @@
+package leader
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Clink-n-Clank/Brokkr/component/background"
+	"github.com/Clink-n-Clank/Brokkr/component/background/task"
+	"github.com/Clink-n-Clank/Brokkr/component/behavior/lease"
+)
+
+func TestElector_Failover(t *testing.T) {
+	locker := lease.NewMemoryLocker()
+	elected := make(chan string, 2)
+	revoked := make(chan string, 2)
+
+	newReplica := func(owner string) *Elector {
+		return NewElector(
+			"UnitTestElector",
+			locker,
+			SetOwner(owner),
+			SetLeaseTTL(30*time.Millisecond),
+			SetRetryInterval(5*time.Millisecond),
+			SetOnElected(func(context.Context) { elected <- owner }),
+			SetOnRevoked(func() { revoked <- owner }),
+		)
+	}
+
+	replicaA := newReplica("replica-a")
+	go func() { _ = replicaA.OnStart(context.Background()) }()
+	assert.Equal(t, "replica-a", <-elected)
+	assert.True(t, replicaA.IsLeader())
+
+	leaderCtx := replicaA.LeaderContext()
+	assert.NoError(t, leaderCtx.Err())
+
+	replicaB := newReplica("replica-b")
+	go func() { _ = replicaB.OnStart(context.Background()) }()
+
+	time.Sleep(50 * time.Millisecond)
+	assert.False(t, replicaB.IsLeader(), "leader renews its lease, follower must stay follower")
+
+	assert.NoError(t, replicaA.OnStop(context.Background()))
+	assert.Equal(t, "replica-a", <-revoked)
+	assert.ErrorIs(t, leaderCtx.Err(), context.Canceled)
+	assert.False(t, replicaA.IsLeader())
+
+	assert.Equal(t, "replica-b", <-elected)
+	l, isLeader := replicaB.Lease()
+	assert.True(t, isLeader)
+	assert.Equal(t, uint64(2), l.Token)
+
+	assert.NoError(t, replicaB.OnStop(context.Background()))
+	assert.Equal(t, "replica-b", <-revoked)
+}
+
+func TestElector_LostLeadership(t *testing.T) {
+	locker := lease.NewMemoryLocker()
+	revoked := make(chan struct{})
+
+	e := NewElector(
+		"UnitTestElector",
+		locker,
+		SetLeaseTTL(30*time.Millisecond),
+		SetRetryInterval(time.Hour),
+		SetOnRevoked(func() { close(revoked) }),
+	)
+	go func() { _ = e.OnStart(context.Background()) }()
+
+	assert.Eventually(t, e.IsLeader, time.Second, time.Millisecond)
+	leaderCtx := e.LeaderContext()
+
+	// Lease is taken over by another owner behind the back of the leader
+	current, _ := e.Lease()
+	assert.NoError(t, locker.Release(context.Background(), current))
+	_, err := locker.Acquire(context.Background(), "UnitTestElector", "intruder", time.Minute)
+	assert.NoError(t, err)
+
+	<-revoked
+	<-leaderCtx.Done()
+	assert.False(t, e.IsLeader())
+	assert.NoError(t, e.OnStop(context.Background()))
+}
+
+func TestElector_LeaderOnlyProcesses(t *testing.T) {
+	p := &testProcess{}
+	e := NewElector("UnitTestElector", lease.NewMemoryLocker(), SetLeaseTTL(time.Minute))
+	e.AddProcesses(func() background.Process { return p })
+
+	go func() { _ = e.OnStart(context.Background()) }()
+	assert.Eventually(t, func() bool { return p.started.Load() == 1 }, time.Second, time.Millisecond)
+
+	assert.NoError(t, e.OnStop(context.Background()))
+	assert.Equal(t, int32(1), p.stopped.Load())
+	assert.Equal(t, "UnitTestElector", e.GetName())
+	assert.Equal(t, background.TaskSeverityMajor, e.GetSeverity())
+}
+
+func TestElector_RestartProcessesOnRegainedLeadership(t *testing.T) {
+	locker := lease.NewMemoryLocker()
+	handled := make(chan struct{}, 10)
+	elected := make(chan struct{}, 2)
+	revoked := make(chan struct{}, 2)
+
+	e := NewElector(
+		"UnitTestElector",
+		locker,
+		SetLeaseTTL(30*time.Millisecond),
+		SetRetryInterval(5*time.Millisecond),
+		SetOnElected(func(context.Context) { elected <- struct{}{} }),
+		SetOnRevoked(func() { revoked <- struct{}{} }),
+	)
+	e.AddProcesses(func() background.Process {
+		return task.NewBackgroundTask(
+			"UnitTestNightly",
+			func() {},
+			task.SetExecInterval(time.Hour),
+			task.SetHandler(func() error {
+				handled <- struct{}{}
+				return nil
+			}),
+		)
+	})
+	go func() { _ = e.OnStart(context.Background()) }()
+
+	<-elected
+	<-handled
+
+	// Lease is taken over by another owner until it expires
+	current, _ := e.Lease()
+	assert.NoError(t, locker.Release(context.Background(), current))
+	_, err := locker.Acquire(context.Background(), "UnitTestElector", "intruder", 20*time.Millisecond)
+	assert.NoError(t, err)
+
+	select {
+	case <-revoked:
+	case <-time.After(time.Second):
+		assert.Fail(t, "task must be stopped when leadership is lost")
+	}
+
+	// New task instance is started for the next leadership term
+	<-elected
+	select {
+	case <-handled:
+	case <-time.After(time.Second):
+		assert.Fail(t, "task must be started again when leadership is regained")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, e.OnStop(ctx))
+}
+
+func TestElector_ProcessStartFailureResigns(t *testing.T) {
+	failures := make(chan error, 1)
+	revoked := make(chan struct{})
+
+	e := NewElector(
+		"UnitTestElector",
+		lease.NewMemoryLocker(),
+		SetLeaseTTL(time.Minute),
+		SetRetryInterval(time.Hour),
+		SetOnRevoked(func() { close(revoked) }),
+		SetProcessErrorHandler(func(_ background.Process, err error) { failures <- err }),
+	)
+	e.AddProcesses(func() background.Process { return &testProcess{startErr: errors.New("broken config")} })
+	go func() { _ = e.OnStart(context.Background()) }()
+
+	assert.EqualError(t, <-failures, "broken config")
+
+	select {
+	case <-revoked:
+	case <-time.After(time.Second):
+		assert.Fail(t, "leadership must be given up when process fails to start")
+	}
+
+	assert.False(t, e.IsLeader())
+	assert.NoError(t, e.OnStop(context.Background()))
+}
+
+func TestElector_SlowOnElectedKeepsLeadership(t *testing.T) {
+	revoked := make(chan struct{}, 1)
+
+	e := NewElector(
+		"UnitTestElector",
+		lease.NewMemoryLocker(),
+		SetLeaseTTL(30*time.Millisecond),
+		SetRetryInterval(5*time.Millisecond),
+		SetOnElected(func(ctx context.Context) { <-ctx.Done() }),
+		SetOnRevoked(func() { revoked <- struct{}{} }),
+	)
+	go func() { _ = e.OnStart(context.Background()) }()
+
+	assert.Eventually(t, e.IsLeader, time.Second, time.Millisecond)
+
+	// Lease is renewed while callback is running
+	time.Sleep(100 * time.Millisecond)
+	assert.True(t, e.IsLeader())
+	assert.Empty(t, revoked)
+
+	assert.NoError(t, e.OnStop(context.Background()))
+}
+
+type testProcess struct {
+	startErr error
+
+	started atomic.Int32
+	stopped atomic.Int32
+}
+
+func (p *testProcess) GetName() string {
+	return "test"
+}
+
+func (p *testProcess) GetSeverity() background.ProcessSeverity {
+	return background.TaskSeverityMinor
+}
+
+func (p *testProcess) OnStart(ctx context.Context) error {
+	p.started.Add(1)
+	if p.startErr != nil {
+		return p.startErr
+	}
+
+	<-ctx.Done()
+
+	return nil
+}
+
+func (p *testProcess) OnStop(context.Context) error {
+	p.stopped.Add(1)
+	return nil
+}
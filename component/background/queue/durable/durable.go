@@ -0,0 +1,698 @@
+package durable
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Clink-n-Clank/Brokkr/component/background"
+)
+
+var (
+	// ErrQueueClosed is returned when queue is stopped and can't be used anymore
+	ErrQueueClosed = errors.New("durable queue is closed")
+	// ErrMessageNotFound is returned when message is not received or lease of it already expired
+	ErrMessageNotFound = errors.New("message is not in flight")
+	// ErrInvalidVisibilityTimeout is returned on start when visibility timeout is not positive
+	ErrInvalidVisibilityTimeout = errors.New("visibility timeout must be positive")
+)
+
+// minReleaseInterval of expired messages, it keeps ticker valid for very short visibility timeouts
+const minReleaseInterval = time.Millisecond
+
+type (
+	// Message stored in the queue
+	Message struct {
+		ID         string
+		Payload    []byte
+		Attempts   uint
+		EnqueuedAt time.Time
+		// ReceiptHandle of the delivery, it must be passed to Ack or Nack, so stale delivery can't acknowledge redelivered message
+		ReceiptHandle string
+		// LastError reason of the last negative acknowledgement
+		LastError string
+	}
+
+	// Queue persistent queue backed by append-only log on local disk, delivers messages at least once
+	Queue struct {
+		name     string
+		path     string
+		severity background.ProcessSeverity
+
+		visibilityTimeout   time.Duration
+		maxAttempts         uint
+		compactionThreshold int
+		syncOnWrite         bool
+		onDeadLetter        func(m Message)
+
+		state queueState
+	}
+
+	// queueState of messages and the log file
+	queueState struct {
+		log       *os.File
+		logWriter *bufio.Writer
+
+		messages map[string]*Message
+		ready    []string
+		inFlight map[string]delivery
+		dead     map[string]*Message
+		// garbage number of records in the log that will be removed by compaction
+		garbage int
+
+		isReady    chan struct{}
+		isClosed   chan struct{}
+		hasMessage chan struct{}
+		closeOnce  sync.Once
+
+		sync.Mutex
+	}
+
+	// delivery of in-flight message
+	delivery struct {
+		receiptHandle string
+		visibleAt     time.Time
+	}
+
+	// Option for queue configuration, visibility timeout, attempts etc.
+	Option func(q *Queue)
+)
+
+// SetVisibilityTimeout how long received message is hidden from other receivers before it will be delivered again
+func SetVisibilityTimeout(t time.Duration) Option {
+	return func(q *Queue) {
+		q.visibilityTimeout = t
+	}
+}
+
+// SetMaxAttempts of message delivery before it will be moved to dead letters
+func SetMaxAttempts(n uint) Option {
+	return func(q *Queue) {
+		q.maxAttempts = n
+	}
+}
+
+// SetCompactionThreshold number of obsolete records in the log that triggers compaction
+func SetCompactionThreshold(n int) Option {
+	return func(q *Queue) {
+		q.compactionThreshold = n
+	}
+}
+
+// SetSyncOnWrite will fsync log after each write, otherwise log is synced on stop and compaction
+func SetSyncOnWrite(sync bool) Option {
+	return func(q *Queue) {
+		q.syncOnWrite = sync
+	}
+}
+
+// SetDeadLetterHandler called when message is moved to dead letters
+func SetDeadLetterHandler(h func(m Message)) Option {
+	return func(q *Queue) {
+		q.onDeadLetter = h
+	}
+}
+
+// SetSeverity of how important for the application to run this queue
+func SetSeverity(s background.ProcessSeverity) Option {
+	return func(q *Queue) {
+		q.severity = s
+	}
+}
+
+// NewQueue instance that keeps its log in path, log is recovered on start
+func NewQueue(name, path string, opts ...Option) *Queue {
+	q := &Queue{
+		name:                name,
+		path:                path,
+		severity:            background.TaskSeverityMajor,
+		visibilityTimeout:   30 * time.Second,
+		maxAttempts:         5,
+		compactionThreshold: 1000,
+		onDeadLetter:        func(Message) {},
+	}
+
+	for _, o := range opts {
+		o(q)
+	}
+
+	q.state = queueState{
+		messages:   map[string]*Message{},
+		ready:      make([]string, 0),
+		inFlight:   map[string]delivery{},
+		dead:       map[string]*Message{},
+		isReady:    make(chan struct{}),
+		isClosed:   make(chan struct{}),
+		hasMessage: make(chan struct{}, 1),
+	}
+
+	return q
+}
+
+// GetName of the queue
+func (q *Queue) GetName() string {
+	return q.name
+}
+
+// GetSeverity of the queue
+func (q *Queue) GetSeverity() background.ProcessSeverity {
+	return q.severity
+}
+
+// OnStart recovers queue from the log and returns expired messages back to the queue until stop
+func (q *Queue) OnStart(_ context.Context) error {
+	if q.visibilityTimeout <= 0 {
+		return ErrInvalidVisibilityTimeout
+	}
+
+	if err := q.recover(); err != nil {
+		return err
+	}
+
+	close(q.state.isReady)
+
+	releaseInterval := q.visibilityTimeout / 2
+	if releaseInterval < minReleaseInterval {
+		releaseInterval = minReleaseInterval
+	}
+
+	ticker := time.NewTicker(releaseInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := q.releaseExpired(time.Now()); err != nil {
+				return err
+			}
+		case <-q.state.isClosed:
+			return nil
+		}
+	}
+}
+
+// OnStop flushes log to the disk and closes the queue, not acknowledged messages will be delivered after restart
+func (q *Queue) OnStop(_ context.Context) error {
+	q.state.Lock()
+	defer q.state.Unlock()
+
+	q.state.closeOnce.Do(func() { close(q.state.isClosed) })
+
+	if q.state.log == nil {
+		return nil
+	}
+
+	syncErr := q.syncLog()
+	closeErr := q.state.log.Close()
+	q.state.log = nil
+
+	return errors.Join(syncErr, closeErr)
+}
+
+// Publish payload to the queue, returns ID of the message
+func (q *Queue) Publish(ctx context.Context, payload []byte) (string, error) {
+	if err := q.waitReady(ctx); err != nil {
+		return "", err
+	}
+
+	q.state.Lock()
+	defer q.state.Unlock()
+
+	if q.state.log == nil {
+		return "", ErrQueueClosed
+	}
+
+	m := &Message{ID: uuid.NewString(), Payload: payload, EnqueuedAt: time.Now()}
+	if err := q.write(record{Op: opPut, ID: m.ID, Payload: m.Payload, At: m.EnqueuedAt}); err != nil {
+		return "", err
+	}
+
+	q.state.messages[m.ID] = m
+	q.pushReady(m.ID)
+
+	return m.ID, nil
+}
+
+// Receive next message, blocks until message is available, message must be acknowledged before visibility timeout
+func (q *Queue) Receive(ctx context.Context) (Message, error) {
+	if err := q.waitReady(ctx); err != nil {
+		return Message{}, err
+	}
+
+	for {
+		m, ok, err := q.tryReceive()
+		if err != nil || ok {
+			return m, err
+		}
+
+		select {
+		case <-q.state.hasMessage:
+		case <-q.state.isClosed:
+			return Message{}, ErrQueueClosed
+		case <-ctx.Done():
+			return Message{}, ctx.Err()
+		}
+	}
+}
+
+// Ack message by receipt handle of the delivery as handled, it will be never delivered again
+func (q *Queue) Ack(receiptHandle string) error {
+	q.state.Lock()
+	defer q.state.Unlock()
+
+	id, err := q.inFlightID(receiptHandle)
+	if err != nil {
+		return err
+	}
+
+	if err = q.write(record{Op: opAck, ID: id}); err != nil {
+		return err
+	}
+
+	delete(q.state.inFlight, id)
+	delete(q.state.messages, id)
+	q.state.garbage += 2
+
+	// Ack is already durable, failed compaction is retried on next write
+	_ = q.compactIfNeeded()
+
+	return nil
+}
+
+// Nack message by receipt handle of the delivery as failed,
+// it will be delivered again or moved to dead letters when attempts are exceeded
+func (q *Queue) Nack(receiptHandle string, reason error) error {
+	q.state.Lock()
+	defer q.state.Unlock()
+
+	id, err := q.inFlightID(receiptHandle)
+	if err != nil {
+		return err
+	}
+
+	m := q.state.messages[id]
+	lastError := m.LastError
+	if reason != nil {
+		lastError = reason.Error()
+	}
+
+	return q.retryOrBury(m, lastError)
+}
+
+// Len of messages that are waiting for delivery
+func (q *Queue) Len() int {
+	q.state.Lock()
+	defer q.state.Unlock()
+
+	return len(q.state.ready)
+}
+
+// DeadLetters messages that exceeded delivery attempts
+func (q *Queue) DeadLetters() []Message {
+	q.state.Lock()
+	defer q.state.Unlock()
+
+	dl := make([]Message, 0, len(q.state.dead))
+	for _, m := range q.state.dead {
+		dl = append(dl, *m)
+	}
+
+	return dl
+}
+
+// Compact log by rewriting it with only live messages and dead letters
+func (q *Queue) Compact() error {
+	q.state.Lock()
+	defer q.state.Unlock()
+
+	if q.state.log == nil {
+		return ErrQueueClosed
+	}
+
+	return q.compact()
+}
+
+// inFlightID of the message by receipt handle of its current delivery
+func (q *Queue) inFlightID(receiptHandle string) (string, error) {
+	if q.state.log == nil {
+		return "", ErrQueueClosed
+	}
+
+	id, _, _ := strings.Cut(receiptHandle, receiptHandleSeparator)
+	if d, ok := q.state.inFlight[id]; !ok || d.receiptHandle != receiptHandle {
+		return "", ErrMessageNotFound
+	}
+
+	return id, nil
+}
+
+// waitReady until log is recovered
+func (q *Queue) waitReady(ctx context.Context) error {
+	select {
+	case <-q.state.isReady:
+		return nil
+	case <-q.state.isClosed:
+		return ErrQueueClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// tryReceive message from ready list without waiting
+func (q *Queue) tryReceive() (Message, bool, error) {
+	q.state.Lock()
+	defer q.state.Unlock()
+
+	if q.state.log == nil {
+		return Message{}, false, ErrQueueClosed
+	}
+
+	if len(q.state.ready) == 0 {
+		return Message{}, false, nil
+	}
+
+	id := q.state.ready[0]
+	m := q.state.messages[id]
+
+	if err := q.write(record{Op: opReceive, ID: id}); err != nil {
+		return Message{}, false, err
+	}
+
+	q.state.ready = q.state.ready[1:]
+	q.state.garbage++
+	m.Attempts++
+
+	// Attempts are unique for each delivery of the message, also after recovery
+	m.ReceiptHandle = m.ID + receiptHandleSeparator + strconv.FormatUint(uint64(m.Attempts), 10)
+	q.state.inFlight[id] = delivery{receiptHandle: m.ReceiptHandle, visibleAt: time.Now().Add(q.visibilityTimeout)}
+
+	// Wake up next receiver if there is more messages
+	if len(q.state.ready) > 0 {
+		q.notifyReceivers()
+	}
+
+	return *m, true, nil
+}
+
+// releaseExpired messages which visibility timeout is over
+func (q *Queue) releaseExpired(now time.Time) error {
+	q.state.Lock()
+	defer q.state.Unlock()
+
+	if q.state.log == nil {
+		return nil
+	}
+
+	for id, d := range q.state.inFlight {
+		if now.Before(d.visibleAt) {
+			continue
+		}
+
+		if err := q.retryOrBury(q.state.messages[id], "visibility timeout expired"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// retryOrBury in-flight message by returning it back to ready list or moving to dead letters,
+// message stays in flight if it can't be written to the log
+func (q *Queue) retryOrBury(m *Message, lastError string) error {
+	if m.Attempts < q.maxAttempts {
+		if err := q.write(record{Op: opNack, ID: m.ID, Reason: lastError}); err != nil {
+			return err
+		}
+
+		delete(q.state.inFlight, m.ID)
+		m.LastError = lastError
+		q.state.garbage++
+		q.pushReady(m.ID)
+
+		return nil
+	}
+
+	if err := q.write(record{Op: opDead, ID: m.ID, Reason: lastError}); err != nil {
+		return err
+	}
+
+	delete(q.state.inFlight, m.ID)
+	delete(q.state.messages, m.ID)
+	m.LastError = lastError
+	q.state.dead[m.ID] = m
+	q.onDeadLetter(*m)
+
+	// Dead letter is already durable, failed compaction is retried on next write
+	_ = q.compactIfNeeded()
+
+	return nil
+}
+
+// pushReady message to the end of ready list
+func (q *Queue) pushReady(id string) {
+	q.state.ready = append(q.state.ready, id)
+	q.notifyReceivers()
+}
+
+// notifyReceivers that there is message to receive
+func (q *Queue) notifyReceivers() {
+	select {
+	case q.state.hasMessage <- struct{}{}:
+	default:
+	}
+}
+
+// recover queue state from the log
+func (q *Queue) recover() error {
+	q.state.Lock()
+	defer q.state.Unlock()
+
+	select {
+	case <-q.state.isClosed:
+		return ErrQueueClosed
+	default:
+	}
+
+	if err := os.MkdirAll(filepath.Dir(q.path), 0o750); err != nil {
+		return fmt.Errorf("unable to create durable queue directory: %w", err)
+	}
+
+	logFile, err := os.OpenFile(q.path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o640)
+	if err != nil {
+		return fmt.Errorf("unable to open durable queue log: %w", err)
+	}
+
+	validSize, err := q.replay(logFile)
+	if err == nil {
+		// Torn record of interrupted append is removed, so next records are not appended to it
+		err = truncateLog(logFile, validSize)
+	}
+
+	if err != nil {
+		_ = logFile.Close()
+		return err
+	}
+
+	q.state.log = logFile
+	q.state.logWriter = bufio.NewWriter(logFile)
+
+	return q.compactIfNeeded()
+}
+
+// replay records from the log, messages that were in flight will be delivered again.
+// Returns size of the log without torn last record, that is left by crash during append
+func (q *Queue) replay(r io.Reader) (int64, error) {
+	reader := bufio.NewReader(r)
+
+	var validSize int64
+	order := make([]string, 0)
+	for line := 1; ; line++ {
+		raw, err := readRecordLine(reader)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return 0, fmt.Errorf("unable to read durable queue log: %w", err)
+		}
+
+		var rec record
+		if err = json.Unmarshal(raw, &rec); err != nil || raw[len(raw)-1] != '\n' {
+			if _, peekErr := reader.Peek(1); errors.Is(peekErr, io.EOF) {
+				break
+			}
+
+			return 0, fmt.Errorf("corrupted durable queue log at line %d: %w", line, err)
+		}
+
+		validSize += int64(len(raw))
+
+		switch rec.Op {
+		case opPut:
+			q.state.messages[rec.ID] = &Message{ID: rec.ID, Payload: rec.Payload, Attempts: rec.Attempts, EnqueuedAt: rec.At}
+			order = append(order, rec.ID)
+		case opReceive:
+			if m, ok := q.state.messages[rec.ID]; ok {
+				m.Attempts++
+			}
+			q.state.garbage++
+		case opNack:
+			if m, ok := q.state.messages[rec.ID]; ok {
+				m.LastError = rec.Reason
+			}
+			q.state.garbage++
+		case opAck:
+			delete(q.state.messages, rec.ID)
+			q.state.garbage += 2
+		case opDead:
+			if m, ok := q.state.messages[rec.ID]; ok {
+				m.LastError = rec.Reason
+				q.state.dead[rec.ID] = m
+				delete(q.state.messages, rec.ID)
+			}
+		}
+	}
+
+	for _, id := range order {
+		if _, ok := q.state.messages[id]; ok {
+			q.state.ready = append(q.state.ready, id)
+		}
+	}
+
+	if len(q.state.ready) > 0 {
+		q.notifyReceivers()
+	}
+
+	return validSize, nil
+}
+
+// truncateLog to the size if it has torn record at the end
+func truncateLog(logFile *os.File, size int64) error {
+	info, err := logFile.Stat()
+	if err != nil {
+		return fmt.Errorf("unable to read durable queue log: %w", err)
+	}
+
+	if info.Size() == size {
+		return nil
+	}
+
+	if err = logFile.Truncate(size); err != nil {
+		return fmt.Errorf("unable to truncate torn record of durable queue log: %w", err)
+	}
+
+	return nil
+}
+
+// compactIfNeeded when obsolete records exceeded threshold
+func (q *Queue) compactIfNeeded() error {
+	if q.state.garbage < q.compactionThreshold {
+		return nil
+	}
+
+	return q.compact()
+}
+
+// compact log into temporary file and replace current log with it
+func (q *Queue) compact() error {
+	if err := q.state.logWriter.Flush(); err != nil {
+		return err
+	}
+
+	tmpPath := q.path + ".compact"
+	tmpFile, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o640)
+	if err != nil {
+		return fmt.Errorf("unable to create compacted durable queue log: %w", err)
+	}
+
+	tmpWriter := bufio.NewWriter(tmpFile)
+	writeErr := func() error {
+		live := make([]string, 0, len(q.state.messages))
+		live = append(live, q.state.ready...)
+		for id := range q.state.inFlight {
+			live = append(live, id)
+		}
+
+		for _, id := range live {
+			m := q.state.messages[id]
+			if err := writeRecord(tmpWriter, record{Op: opPut, ID: m.ID, Payload: m.Payload, Attempts: m.Attempts, At: m.EnqueuedAt}); err != nil {
+				return err
+			}
+		}
+
+		for _, m := range q.state.dead {
+			if err := writeRecord(tmpWriter, record{Op: opPut, ID: m.ID, Payload: m.Payload, Attempts: m.Attempts, At: m.EnqueuedAt}); err != nil {
+				return err
+			}
+			if err := writeRecord(tmpWriter, record{Op: opDead, ID: m.ID, Reason: m.LastError}); err != nil {
+				return err
+			}
+		}
+
+		if err := tmpWriter.Flush(); err != nil {
+			return err
+		}
+
+		return tmpFile.Sync()
+	}()
+
+	if closeErr := tmpFile.Close(); writeErr != nil || closeErr != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("unable to write compacted durable queue log: %w", errors.Join(writeErr, closeErr))
+	}
+
+	if err = os.Rename(tmpPath, q.path); err != nil {
+		return fmt.Errorf("unable to replace durable queue log: %w", err)
+	}
+
+	_ = q.state.log.Close()
+
+	logFile, err := os.OpenFile(q.path, os.O_APPEND|os.O_WRONLY, 0o640)
+	if err != nil {
+		q.state.log = nil
+		return fmt.Errorf("unable to reopen durable queue log: %w", err)
+	}
+
+	q.state.log = logFile
+	q.state.logWriter = bufio.NewWriter(logFile)
+	q.state.garbage = 0
+
+	return nil
+}
+
+// write record to the log
+func (q *Queue) write(rec record) error {
+	if err := writeRecord(q.state.logWriter, rec); err != nil {
+		return fmt.Errorf("unable to write to durable queue log: %w", err)
+	}
+
+	if err := q.state.logWriter.Flush(); err != nil {
+		return fmt.Errorf("unable to flush durable queue log: %w", err)
+	}
+
+	if q.syncOnWrite {
+		return q.state.log.Sync()
+	}
+
+	return nil
+}
+
+// syncLog flushes buffered records and commits them to the disk
+func (q *Queue) syncLog() error {
+	if err := q.state.logWriter.Flush(); err != nil {
+		return err
+	}
+
+	return q.state.log.Sync()
+}
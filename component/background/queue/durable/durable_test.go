@@ -0,0 +1,263 @@
+package durable
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func startQueue(t *testing.T, path string, opts ...Option) *Queue {
+	q := NewQueue("UnitTestDurableQueue", path, opts...)
+	go func() { assert.NoError(t, q.OnStart(context.Background())) }()
+
+	return q
+}
+
+func TestQueue_PublishReceiveAck(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	q := startQueue(t, filepath.Join(t.TempDir(), "queue.log"))
+	defer func() { assert.NoError(t, q.OnStop(ctx)) }()
+
+	id, err := q.Publish(ctx, []byte("unit"))
+	assert.NoError(t, err)
+
+	m, err := q.Receive(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, id, m.ID)
+	assert.Equal(t, []byte("unit"), m.Payload)
+	assert.Equal(t, uint(1), m.Attempts)
+
+	assert.NoError(t, q.Ack(m.ReceiptHandle))
+	assert.ErrorIs(t, q.Ack(m.ReceiptHandle), ErrMessageNotFound)
+	assert.Equal(t, 0, q.Len())
+}
+
+func TestQueue_SurvivesRestart(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	path := filepath.Join(t.TempDir(), "queue.log")
+
+	q := startQueue(t, path)
+	_, err := q.Publish(ctx, []byte("acked"))
+	assert.NoError(t, err)
+	_, err = q.Publish(ctx, []byte("in-flight"))
+	assert.NoError(t, err)
+	_, err = q.Publish(ctx, []byte("pending"))
+	assert.NoError(t, err)
+
+	m, _ := q.Receive(ctx)
+	assert.NoError(t, q.Ack(m.ReceiptHandle))
+	_, _ = q.Receive(ctx)
+	assert.NoError(t, q.OnStop(ctx))
+
+	_, err = q.Publish(ctx, []byte("closed"))
+	assert.ErrorIs(t, err, ErrQueueClosed)
+
+	restarted := startQueue(t, path)
+	defer func() { assert.NoError(t, restarted.OnStop(ctx)) }()
+
+	m, err = restarted.Receive(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "in-flight", string(m.Payload))
+	assert.Equal(t, uint(2), m.Attempts)
+
+	m, err = restarted.Receive(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "pending", string(m.Payload))
+}
+
+func TestQueue_NackAndDeadLetter(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var buried []Message
+	q := startQueue(
+		t,
+		filepath.Join(t.TempDir(), "queue.log"),
+		SetMaxAttempts(2),
+		SetDeadLetterHandler(func(m Message) { buried = append(buried, m) }),
+	)
+	defer func() { assert.NoError(t, q.OnStop(ctx)) }()
+
+	_, err := q.Publish(ctx, []byte("poison"))
+	assert.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		m, receiveErr := q.Receive(ctx)
+		assert.NoError(t, receiveErr)
+		assert.NoError(t, q.Nack(m.ReceiptHandle, errors.New("unit failure")))
+	}
+
+	assert.Len(t, buried, 1)
+	assert.Equal(t, "unit failure", buried[0].LastError)
+	assert.Len(t, q.DeadLetters(), 1)
+	assert.Equal(t, 0, q.Len())
+}
+
+func TestQueue_VisibilityTimeout(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	q := startQueue(t, filepath.Join(t.TempDir(), "queue.log"), SetVisibilityTimeout(20*time.Millisecond))
+	defer func() { assert.NoError(t, q.OnStop(ctx)) }()
+
+	id, _ := q.Publish(ctx, []byte("unit"))
+	first, err := q.Receive(ctx)
+	assert.NoError(t, err)
+
+	second, err := q.Receive(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, id, first.ID)
+	assert.Equal(t, id, second.ID)
+	assert.Equal(t, uint(2), second.Attempts)
+	assert.Equal(t, "visibility timeout expired", second.LastError)
+}
+
+func TestQueue_Compaction(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	path := filepath.Join(t.TempDir(), "queue.log")
+	q := startQueue(t, path, SetCompactionThreshold(4), SetMaxAttempts(1))
+
+	for i := 0; i < 3; i++ {
+		_, err := q.Publish(ctx, []byte("unit"))
+		assert.NoError(t, err)
+	}
+
+	m, _ := q.Receive(ctx)
+	assert.NoError(t, q.Ack(m.ReceiptHandle))
+	m, _ = q.Receive(ctx)
+	assert.NoError(t, q.Nack(m.ReceiptHandle, errors.New("dead")))
+	assert.NoError(t, q.Compact())
+	assert.NoError(t, q.OnStop(ctx))
+
+	restarted := startQueue(t, path)
+	defer func() { assert.NoError(t, restarted.OnStop(ctx)) }()
+
+	assert.NoError(t, restarted.waitReady(ctx))
+	assert.Equal(t, 1, restarted.Len())
+	assert.Len(t, restarted.DeadLetters(), 1)
+}
+
+func TestQueue_StaleReceiptHandle(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	q := startQueue(t, filepath.Join(t.TempDir(), "queue.log"), SetVisibilityTimeout(20*time.Millisecond))
+
+	_, _ = q.Publish(ctx, []byte("unit"))
+	expired, err := q.Receive(ctx)
+	assert.NoError(t, err)
+
+	redelivered, err := q.Receive(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, expired.ID, redelivered.ID)
+	assert.NotEqual(t, expired.ReceiptHandle, redelivered.ReceiptHandle)
+
+	// Consumer of expired delivery can't acknowledge redelivered message
+	assert.ErrorIs(t, q.Ack(expired.ReceiptHandle), ErrMessageNotFound)
+	assert.ErrorIs(t, q.Nack(expired.ReceiptHandle, nil), ErrMessageNotFound)
+	assert.NoError(t, q.OnStop(ctx))
+
+	// Closed queue is reported instead of write failure
+	assert.ErrorIs(t, q.Ack(redelivered.ReceiptHandle), ErrQueueClosed)
+	assert.ErrorIs(t, q.Nack(redelivered.ReceiptHandle, nil), ErrQueueClosed)
+}
+
+func TestQueue_InvalidVisibilityTimeout(t *testing.T) {
+	q := NewQueue("UnitTestDurableQueue", filepath.Join(t.TempDir(), "queue.log"), SetVisibilityTimeout(0))
+	assert.ErrorIs(t, q.OnStart(context.Background()), ErrInvalidVisibilityTimeout)
+
+	// Very short timeout doesn't break release of expired messages
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	q = startQueue(t, filepath.Join(t.TempDir(), "queue.log"), SetVisibilityTimeout(time.Nanosecond))
+	defer func() { assert.NoError(t, q.OnStop(ctx)) }()
+
+	_, _ = q.Publish(ctx, []byte("unit"))
+	_, _ = q.Receive(ctx)
+
+	m, err := q.Receive(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(2), m.Attempts)
+}
+
+func TestQueue_TornLastRecord(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	path := filepath.Join(t.TempDir(), "queue.log")
+
+	q := startQueue(t, path)
+	_, err := q.Publish(ctx, []byte("first"))
+	assert.NoError(t, err)
+	assert.NoError(t, q.OnStop(ctx))
+
+	// Crash during append leaves partial record at the end of the log
+	logFile, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o640)
+	assert.NoError(t, err)
+	_, err = logFile.WriteString(`{"op":"put","id":"torn","pay`)
+	assert.NoError(t, err)
+	assert.NoError(t, logFile.Close())
+
+	restarted := startQueue(t, path)
+	_, err = restarted.Publish(ctx, []byte("second"))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, restarted.Len())
+	assert.NoError(t, restarted.OnStop(ctx))
+
+	// Records appended after recovery are readable
+	again := startQueue(t, path)
+	defer func() { assert.NoError(t, again.OnStop(ctx)) }()
+
+	for _, payload := range []string{"first", "second"} {
+		m, err := again.Receive(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, payload, string(m.Payload))
+	}
+}
+
+func TestQueue_CorruptedRecordInTheMiddle(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.log")
+	assert.NoError(t, os.WriteFile(path, []byte("{\"op\":\"put\",\"id\nnot json\n{\"op\":\"put\",\"id\":\"a\"}\n"), 0o640))
+
+	q := NewQueue("UnitTestDurableQueue", path)
+	assert.ErrorContains(t, q.OnStart(context.Background()), "corrupted durable queue log at line 1")
+}
+
+func TestQueue_NackKeepsMessageInFlightOnWriteError(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	q := startQueue(t, filepath.Join(t.TempDir(), "queue.log"))
+	defer func() { _ = q.OnStop(ctx) }()
+
+	_, err := q.Publish(ctx, []byte("unit"))
+	assert.NoError(t, err)
+	m, err := q.Receive(ctx)
+	assert.NoError(t, err)
+
+	// Log can't be written anymore
+	q.state.Lock()
+	assert.NoError(t, q.state.log.Close())
+	q.state.Unlock()
+
+	assert.Error(t, q.Nack(m.ReceiptHandle, errors.New("failed")))
+
+	q.state.Lock()
+	defer q.state.Unlock()
+
+	assert.Contains(t, q.state.inFlight, m.ID)
+	assert.Empty(t, q.state.messages[m.ID].LastError)
+	assert.Empty(t, q.state.ready)
+}
@@ -0,0 +1,75 @@
+package durable
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+)
+
+const (
+	// maxRecordSize of single record in the log
+	maxRecordSize = 16 * 1024 * 1024
+	// receiptHandleSeparator between message ID and delivery attempt in receipt handle
+	receiptHandleSeparator = "#"
+)
+
+// ErrRecordTooLarge is returned when record of the log exceeds 16MB
+var ErrRecordTooLarge = errors.New("durable queue record is too large")
+
+// recordOp operation stored in the log
+type recordOp string
+
+const (
+	opPut     recordOp = "put"
+	opReceive recordOp = "recv"
+	opNack    recordOp = "nack"
+	opAck     recordOp = "ack"
+	opDead    recordOp = "dead"
+)
+
+// record single line of the append-only log
+type record struct {
+	Op       recordOp  `json:"op"`
+	ID       string    `json:"id"`
+	Payload  []byte    `json:"payload,omitempty"`
+	Attempts uint      `json:"attempts,omitempty"`
+	Reason   string    `json:"reason,omitempty"`
+	At       time.Time `json:"at,omitempty"`
+}
+
+// readRecordLine with trailing new line, last line without new line is returned as torn record,
+// io.EOF is returned when there is no more records
+func readRecordLine(r *bufio.Reader) ([]byte, error) {
+	var line []byte
+	for {
+		chunk, err := r.ReadSlice('\n')
+		line = append(line, chunk...)
+
+		if len(line) > maxRecordSize {
+			return nil, ErrRecordTooLarge
+		}
+
+		switch {
+		case errors.Is(err, bufio.ErrBufferFull):
+			continue
+		case errors.Is(err, io.EOF) && len(line) > 0:
+			return line, nil
+		default:
+			return line, err
+		}
+	}
+}
+
+// writeRecord as JSON line
+func writeRecord(w io.Writer, rec record) error {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(append(line, '\n'))
+
+	return err
+}
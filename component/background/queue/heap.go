@@ -0,0 +1,40 @@
+package queue
+
+// queuedJob wrapper of the job with position in the queue
+type queuedJob struct {
+	job      Job
+	sequence uint64
+}
+
+// jobHeap keeps jobs ordered by priority and FIFO for the same priority, implements heap.Interface
+type jobHeap []*queuedJob
+
+func (h jobHeap) Len() int {
+	return len(h)
+}
+
+func (h jobHeap) Less(i, j int) bool {
+	if h[i].job.Priority != h[j].job.Priority {
+		return h[i].job.Priority > h[j].job.Priority
+	}
+
+	return h[i].sequence < h[j].sequence
+}
+
+func (h jobHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+}
+
+func (h *jobHeap) Push(x any) {
+	*h = append(*h, x.(*queuedJob))
+}
+
+func (h *jobHeap) Pop() any {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+
+	return item
+}
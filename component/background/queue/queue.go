@@ -0,0 +1,333 @@
+package queue
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Clink-n-Clank/Brokkr/component/background"
+	"github.com/Clink-n-Clank/Brokkr/component/execution"
+)
+
+var (
+	// ErrQueueFull is returned when queue reached its capacity and can't accept new jobs
+	ErrQueueFull = errors.New("job queue is full")
+	// ErrQueueClosed is returned when queue is stopping or stopped and can't accept new jobs
+	ErrQueueClosed = errors.New("job queue is closed")
+	// ErrInvalidJob is returned when job has no handler
+	ErrInvalidJob = errors.New("job has no handler")
+	// ErrJobPanic is returned for attempt of the job which handler panicked
+	ErrJobPanic = errors.New("job handler panicked")
+	// ErrDrainTimeout is returned when queued jobs were not handled before stop deadline
+	ErrDrainTimeout = errors.New("job queue was not drained before stop deadline")
+)
+
+// Priority of the job, jobs with higher priority are handled first
+type Priority int
+
+const (
+	PriorityLow      Priority = -1
+	PriorityNormal   Priority = 0
+	PriorityHigh     Priority = 1
+	PriorityCritical Priority = 2
+)
+
+type (
+	// Job unit of work that will be handled by one of the queue workers
+	Job struct {
+		// ID of the job, optional, used to identify it in failure handler
+		ID string
+		// Priority of the job, default is PriorityNormal
+		Priority Priority
+		// Timeout for single attempt, queue default is used if not set
+		Timeout time.Duration
+		// Attempts to handle the job, queue default is used if not set
+		Attempts uint
+		// Backoff between attempts, queue default is used if not set
+		Backoff time.Duration
+		// Handler logic of the job, it must return when ctx is done, otherwise it keeps running after attempt timeout
+		Handler func(ctx context.Context) error
+	}
+
+	// JobQueue a bounded in-memory queue with pool of workers that handles jobs in the background
+	JobQueue struct {
+		name     string
+		severity background.ProcessSeverity
+
+		workers   int
+		capacity  int
+		timeout   time.Duration
+		attempts  uint
+		backoff   time.Duration
+		onFailure func(job Job, err error)
+
+		state queueState
+	}
+
+	// queueState of the workers and pending jobs
+	queueState struct {
+		pending  jobHeap
+		sequence uint64
+		inFlight int
+		closed   bool
+
+		startOnce     sync.Once
+		jobsAvailable *sync.Cond
+		workersDone   chan struct{}
+
+		jobsCtx       context.Context
+		jobsCtxCancel func()
+
+		sync.Mutex
+	}
+
+	// Option for queue configuration, workers, capacity, timeouts etc.
+	Option func(q *JobQueue)
+)
+
+// SetWorkers number of concurrent job handlers
+func SetWorkers(n int) Option {
+	return func(q *JobQueue) {
+		q.workers = n
+	}
+}
+
+// SetCapacity maximum number of pending jobs in the queue
+func SetCapacity(n int) Option {
+	return func(q *JobQueue) {
+		q.capacity = n
+	}
+}
+
+// SetJobTimeout default timeout for single job attempt
+func SetJobTimeout(t time.Duration) Option {
+	return func(q *JobQueue) {
+		q.timeout = t
+	}
+}
+
+// SetRetry default attempts and backoff for job handling
+func SetRetry(attempts uint, backoff time.Duration) Option {
+	return func(q *JobQueue) {
+		q.attempts = attempts
+		q.backoff = backoff
+	}
+}
+
+// SetFailureHandler called when job failed in all attempts
+func SetFailureHandler(h func(job Job, err error)) Option {
+	return func(q *JobQueue) {
+		q.onFailure = h
+	}
+}
+
+// SetSeverity of how important for the application to run this queue
+func SetSeverity(s background.ProcessSeverity) Option {
+	return func(q *JobQueue) {
+		q.severity = s
+	}
+}
+
+// NewJobQueue a new instance
+func NewJobQueue(name string, opts ...Option) *JobQueue {
+	q := &JobQueue{
+		name:      name,
+		severity:  background.TaskSeverityMajor,
+		workers:   1,
+		capacity:  100,
+		timeout:   30 * time.Second,
+		attempts:  1,
+		backoff:   100 * time.Millisecond,
+		onFailure: func(Job, error) {},
+	}
+
+	for _, o := range opts {
+		o(q)
+	}
+
+	if q.workers < 1 {
+		q.workers = 1
+	}
+
+	q.state.jobsAvailable = sync.NewCond(&q.state.Mutex)
+	q.state.workersDone = make(chan struct{})
+	q.state.jobsCtx, q.state.jobsCtxCancel = context.WithCancel(context.Background())
+
+	return q
+}
+
+// GetName of the queue
+func (q *JobQueue) GetName() string {
+	return q.name
+}
+
+// GetSeverity of the queue
+func (q *JobQueue) GetSeverity() background.ProcessSeverity {
+	return q.severity
+}
+
+// Enqueue job to be handled by workers, returns ErrQueueFull when queue reached its capacity
+func (q *JobQueue) Enqueue(ctx context.Context, job Job) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if job.Handler == nil {
+		return ErrInvalidJob
+	}
+
+	q.state.Lock()
+	defer q.state.Unlock()
+
+	if q.state.closed {
+		return ErrQueueClosed
+	}
+
+	if len(q.state.pending) >= q.capacity {
+		return ErrQueueFull
+	}
+
+	q.state.sequence++
+	heap.Push(&q.state.pending, &queuedJob{job: job, sequence: q.state.sequence})
+	q.state.jobsAvailable.Signal()
+
+	return nil
+}
+
+// Len of pending jobs in the queue
+func (q *JobQueue) Len() int {
+	q.state.Lock()
+	defer q.state.Unlock()
+
+	return len(q.state.pending)
+}
+
+// InFlight number of jobs handled by workers right now
+func (q *JobQueue) InFlight() int {
+	q.state.Lock()
+	defer q.state.Unlock()
+
+	return q.state.inFlight
+}
+
+// OnStart event to be called when main loop will be started
+func (q *JobQueue) OnStart(_ context.Context) error {
+	q.startWorkers()
+	<-q.state.workersDone
+
+	return nil
+}
+
+// OnStop event to be called when main loop will be stopped, waits for queued jobs until context deadline
+func (q *JobQueue) OnStop(ctx context.Context) error {
+	q.state.Lock()
+	q.state.closed = true
+	q.state.jobsAvailable.Broadcast()
+	q.state.Unlock()
+
+	// Queued jobs must be drained even if queue was never started
+	q.startWorkers()
+
+	select {
+	case <-q.state.workersDone:
+		q.state.jobsCtxCancel()
+		return nil
+	case <-ctx.Done():
+		q.state.jobsCtxCancel()
+		return q.dropPending()
+	}
+}
+
+// startWorkers once, workersDone will be closed when all workers are finished
+func (q *JobQueue) startWorkers() {
+	q.state.startOnce.Do(func() {
+		var wg sync.WaitGroup
+		for i := 0; i < q.workers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				q.work()
+			}()
+		}
+
+		go func() {
+			wg.Wait()
+			close(q.state.workersDone)
+		}()
+	})
+}
+
+// dropPending jobs that will never be handled
+func (q *JobQueue) dropPending() error {
+	q.state.Lock()
+	defer q.state.Unlock()
+
+	dropped := len(q.state.pending)
+	q.state.pending = q.state.pending[:0]
+
+	if dropped == 0 && q.state.inFlight == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %d pending and %d in flight jobs", ErrDrainTimeout, dropped, q.state.inFlight)
+}
+
+// work takes jobs from the queue until it's closed and empty
+func (q *JobQueue) work() {
+	for {
+		q.state.Lock()
+		for len(q.state.pending) == 0 && !q.state.closed {
+			q.state.jobsAvailable.Wait()
+		}
+
+		if len(q.state.pending) == 0 {
+			q.state.Unlock()
+			return
+		}
+
+		next := heap.Pop(&q.state.pending).(*queuedJob)
+		q.state.inFlight++
+		q.state.Unlock()
+
+		if err := q.handle(next.job); err != nil {
+			q.onFailure(next.job, err)
+		}
+
+		q.state.Lock()
+		q.state.inFlight--
+		q.state.Unlock()
+	}
+}
+
+// handle job with retries and timeout for each attempt
+func (q *JobQueue) handle(job Job) error {
+	timeout, attempts, backoff := job.Timeout, job.Attempts, job.Backoff
+	if timeout <= 0 {
+		timeout = q.timeout
+	}
+	if attempts == 0 {
+		attempts = q.attempts
+	}
+	if backoff <= 0 {
+		backoff = q.backoff
+	}
+
+	return execution.RunWithRetryContext(q.state.jobsCtx, attempts, backoff, func(ctx context.Context) (err error) {
+		attemptCtx, attemptCtxCancel := context.WithTimeout(ctx, timeout)
+		defer attemptCtxCancel()
+
+		// Panic fails the attempt instead of the whole process
+		defer func() {
+			if p := recover(); p != nil {
+				err = fmt.Errorf("%w: %v", ErrJobPanic, p)
+			}
+		}()
+
+		return execution.RunWithTimeout(attemptCtx, timeout, func() error {
+			return job.Handler(attemptCtx)
+		})
+	})
+}
@@ -0,0 +1,158 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Clink-n-Clank/Brokkr/component/background"
+)
+
+func TestJobQueue_EnqueueAndHandle(t *testing.T) {
+	var handled sync.WaitGroup
+	q := NewJobQueue("UnitTestQueue", SetWorkers(3), SetCapacity(10))
+
+	go func() { _ = q.OnStart(context.Background()) }()
+
+	for i := 0; i < 5; i++ {
+		handled.Add(1)
+		assert.NoError(t, q.Enqueue(context.Background(), Job{Handler: func(context.Context) error {
+			handled.Done()
+			return nil
+		}}))
+	}
+
+	handled.Wait()
+
+	stopCtx, stopCtxCancel := context.WithTimeout(context.Background(), time.Second)
+	defer stopCtxCancel()
+
+	assert.NoError(t, q.OnStop(stopCtx))
+	assert.ErrorIs(t, q.Enqueue(context.Background(), Job{Handler: func(context.Context) error { return nil }}), ErrQueueClosed)
+	assert.Equal(t, "UnitTestQueue", q.GetName())
+	assert.Equal(t, background.TaskSeverityMajor, q.GetSeverity())
+}
+
+func TestJobQueue_Backpressure(t *testing.T) {
+	q := NewJobQueue("UnitTestQueue", SetCapacity(1))
+	job := Job{Handler: func(context.Context) error { return nil }}
+
+	assert.NoError(t, q.Enqueue(context.Background(), job))
+	assert.ErrorIs(t, q.Enqueue(context.Background(), job), ErrQueueFull)
+	assert.ErrorIs(t, q.Enqueue(context.Background(), Job{}), ErrInvalidJob)
+	assert.Equal(t, 1, q.Len())
+}
+
+func TestJobQueue_Priority(t *testing.T) {
+	var order []string
+	q := NewJobQueue("UnitTestQueue")
+
+	for _, j := range []Job{
+		{ID: "low", Priority: PriorityLow},
+		{ID: "normal-1", Priority: PriorityNormal},
+		{ID: "critical", Priority: PriorityCritical},
+		{ID: "normal-2", Priority: PriorityNormal},
+		{ID: "high", Priority: PriorityHigh},
+	} {
+		id := j.ID
+		j.Handler = func(context.Context) error {
+			order = append(order, id)
+			return nil
+		}
+		assert.NoError(t, q.Enqueue(context.Background(), j))
+	}
+
+	go func() { _ = q.OnStart(context.Background()) }()
+
+	stopCtx, stopCtxCancel := context.WithTimeout(context.Background(), time.Second)
+	defer stopCtxCancel()
+
+	assert.NoError(t, q.OnStop(stopCtx))
+	assert.Equal(t, []string{"critical", "high", "normal-1", "normal-2", "low"}, order)
+}
+
+func TestJobQueue_RetryAndTimeout(t *testing.T) {
+	var failed error
+	var attempts atomic.Int32
+	failedHandled := make(chan struct{})
+
+	q := NewJobQueue(
+		"UnitTestQueue",
+		SetRetry(3, time.Nanosecond),
+		SetJobTimeout(10*time.Millisecond),
+		SetFailureHandler(func(job Job, err error) {
+			failed = err
+			close(failedHandled)
+		}),
+	)
+
+	go func() { _ = q.OnStart(context.Background()) }()
+
+	assert.NoError(t, q.Enqueue(context.Background(), Job{ID: "slow", Handler: func(ctx context.Context) error {
+		attempts.Add(1)
+		<-ctx.Done()
+		return ctx.Err()
+	}}))
+
+	<-failedHandled
+	assert.Equal(t, int32(3), attempts.Load())
+	assert.True(t, errors.Is(failed, context.DeadlineExceeded))
+
+	assert.NoError(t, q.OnStop(context.Background()))
+}
+
+func TestJobQueue_PanicFailsAttempt(t *testing.T) {
+	var failed error
+	var attempts atomic.Int32
+	failedHandled := make(chan struct{})
+
+	q := NewJobQueue(
+		"UnitTestQueue",
+		SetRetry(2, time.Nanosecond),
+		SetFailureHandler(func(job Job, err error) {
+			failed = err
+			close(failedHandled)
+		}),
+	)
+
+	go func() { _ = q.OnStart(context.Background()) }()
+
+	assert.NoError(t, q.Enqueue(context.Background(), Job{ID: "panic", Handler: func(ctx context.Context) error {
+		attempts.Add(1)
+		panic("boom")
+	}}))
+
+	<-failedHandled
+	assert.Equal(t, int32(2), attempts.Load())
+	assert.ErrorIs(t, failed, ErrJobPanic)
+
+	assert.NoError(t, q.OnStop(context.Background()))
+}
+
+func TestJobQueue_DrainTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	q := NewJobQueue("UnitTestQueue", SetJobTimeout(time.Minute))
+	go func() { _ = q.OnStart(context.Background()) }()
+
+	blocking := Job{Handler: func(ctx context.Context) error {
+		select {
+		case <-release:
+		case <-ctx.Done():
+		}
+		return nil
+	}}
+	assert.NoError(t, q.Enqueue(context.Background(), blocking))
+	assert.NoError(t, q.Enqueue(context.Background(), blocking))
+
+	stopCtx, stopCtxCancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer stopCtxCancel()
+
+	assert.ErrorIs(t, q.OnStop(stopCtx), ErrDrainTimeout)
+}
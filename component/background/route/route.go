@@ -0,0 +1,222 @@
+package route
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Route matching of middlewares filters, it's shared by gRPC full methods and HTTP paths
+//
+// Filter syntax:
+// - "" or "*"                     - global, matches every route
+// - "/myapp.v1.MyAppAPI/*"        - prefix, matches every route that starts with "/myapp.v1.MyAppAPI/"
+// - "/myapp.v1.MyAppAPI/Get"      - exact route
+// - "/myapp.v1.*/Get*"            - glob pattern with path.Match syntax, * doesn't match "/"
+// - "~^/myapp\.v[0-9]+\..*$"      - regular expression
+// - "!/grpc.health.v1.Health/*"   - exclusion, matches every route that is not matched by filter after "!"
+//
+// All matched filters are composed in order:
+// 1. by priority, higher priority runs first
+// 2. by specificity: global and exclusion, prefix (shorter prefix first), glob and regex, exact
+// 3. by registration order
+
+const (
+	// globalFilterEmpty is the same as "*"
+	globalFilterEmpty = ""
+
+	exclusionPrefix = "!"
+	regexPrefix     = "~"
+)
+
+// ErrInvalidFilter is returned when filter has invalid glob pattern or regular expression
+var ErrInvalidFilter = errors.New("invalid route filter")
+
+// kind defines specificity of the filter
+type kind byte
+
+const (
+	kindGlobal kind = iota
+	kindPrefix
+	kindPattern
+	kindExact
+)
+
+type (
+	// Matcher compiled from filter, it's compiled once and used for each request
+	Matcher struct {
+		kind        kind
+		value       string
+		re          *regexp.Regexp
+		isExclusion bool
+	}
+
+	// route of middlewares registered with filter and priority
+	route[M any] struct {
+		filter   string
+		matcher  Matcher
+		priority int
+		seq      int
+		mws      []M
+	}
+
+	// Table keeps routes sorted in execution order
+	Table[M any] struct {
+		routes []route[M]
+	}
+)
+
+// Compile filter to matcher
+func Compile(filter string) (Matcher, error) {
+	m, err := compile(filter)
+	if err != nil {
+		return Matcher{}, fmt.Errorf("%w %s: %w", ErrInvalidFilter, filter, err)
+	}
+
+	return m, nil
+}
+
+// MustCompile filter to matcher, panics on invalid filter
+func MustCompile(filter string) Matcher {
+	m, err := Compile(filter)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return m
+}
+
+func compile(filter string) (Matcher, error) {
+	if strings.HasPrefix(filter, exclusionPrefix) {
+		m, err := compile(strings.TrimPrefix(filter, exclusionPrefix))
+		m.isExclusion = !m.isExclusion
+
+		return m, err
+	}
+
+	if strings.HasPrefix(filter, regexPrefix) {
+		re, err := regexp.Compile(strings.TrimPrefix(filter, regexPrefix))
+		return Matcher{kind: kindPattern, re: re}, err
+	}
+
+	if filter == globalFilterEmpty || filter == "*" {
+		return Matcher{kind: kindGlobal}, nil
+	}
+
+	withoutSuffix := strings.TrimSuffix(filter, "*")
+	if !strings.ContainsAny(withoutSuffix, "*?[") {
+		if withoutSuffix != filter {
+			return Matcher{kind: kindPrefix, value: withoutSuffix}, nil
+		}
+
+		return Matcher{kind: kindExact, value: filter}, nil
+	}
+
+	if _, err := path.Match(filter, ""); err != nil {
+		return Matcher{}, err
+	}
+
+	return Matcher{kind: kindPattern, value: filter}, nil
+}
+
+// Match route path
+func (m Matcher) Match(routePath string) bool {
+	var isMatched bool
+
+	switch m.kind {
+	case kindGlobal:
+		isMatched = true
+	case kindPrefix:
+		isMatched = strings.HasPrefix(routePath, m.value)
+	case kindExact:
+		isMatched = routePath == m.value
+	case kindPattern:
+		if m.re != nil {
+			isMatched = m.re.MatchString(routePath)
+		} else {
+			isMatched, _ = path.Match(m.value, routePath)
+		}
+	}
+
+	return isMatched != m.isExclusion
+}
+
+// rank of the matcher in execution order, exclusion is applied as broadly as global filter
+func (m Matcher) rank() kind {
+	if m.isExclusion {
+		return kindGlobal
+	}
+
+	return m.kind
+}
+
+// Register middlewares with filter, middlewares of the same filter and priority are appended
+func (t *Table[M]) Register(filter string, priority int, mw ...M) error {
+	if filter == globalFilterEmpty {
+		filter = "*"
+	}
+
+	for i, r := range t.routes {
+		if r.filter == filter && r.priority == priority {
+			t.routes[i].mws = append(t.routes[i].mws, mw...)
+			return nil
+		}
+	}
+
+	m, err := Compile(filter)
+	if err != nil {
+		return err
+	}
+
+	t.routes = append(t.routes, route[M]{
+		filter:   filter,
+		matcher:  m,
+		priority: priority,
+		seq:      len(t.routes),
+		mws:      append([]M{}, mw...),
+	})
+
+	sort.SliceStable(t.routes, func(i, j int) bool {
+		ri, rj := t.routes[i], t.routes[j]
+		if ri.priority != rj.priority {
+			return ri.priority > rj.priority
+		}
+
+		if ri.matcher.rank() != rj.matcher.rank() {
+			return ri.matcher.rank() < rj.matcher.rank()
+		}
+
+		if ri.matcher.rank() == kindPrefix && len(ri.matcher.value) != len(rj.matcher.value) {
+			return len(ri.matcher.value) < len(rj.matcher.value)
+		}
+
+		return ri.seq < rj.seq
+	})
+
+	return nil
+}
+
+// Search middlewares of all filters that match route path in execution order
+func (t *Table[M]) Search(routePath string) []M {
+	ms := make([]M, 0)
+	for _, r := range t.routes {
+		if r.matcher.Match(routePath) {
+			ms = append(ms, r.mws...)
+		}
+	}
+
+	return ms
+}
+
+// Filters registered in the table in execution order
+func (t *Table[M]) Filters() []string {
+	filters := make([]string, 0, len(t.routes))
+	for _, r := range t.routes {
+		filters = append(filters, r.filter)
+	}
+
+	return filters
+}
@@ -0,0 +1,57 @@
+package route
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatcher_Match(t *testing.T) {
+	tests := []struct {
+		filter    string
+		routePath string
+		isMatch   bool
+	}{
+		{"", "/svc.A/Get", true},
+		{"*", "/svc.A/Get", true},
+		{"/svc.A/*", "/svc.A/Get", true},
+		{"/svc.A/*", "/svc.B/Get", false},
+		{"/svc.A/Get", "/svc.A/Get", true},
+		{"/svc.A/Get", "/svc.A/GetAll", false},
+		{"/svc.*/Get*", "/svc.B/GetAll", true},
+		{"/svc.*/Get*", "/svc.B/List", false},
+		{"~^/svc\\.v[0-9]+\\.", "/svc.v2.API/Get", true},
+		{"~^/svc\\.v[0-9]+\\.", "/svc.API/Get", false},
+		{"!/grpc.health.v1.Health/*", "/grpc.health.v1.Health/Check", false},
+		{"!/grpc.health.v1.Health/*", "/svc.A/Get", true},
+	}
+
+	for _, tt := range tests {
+		m, err := Compile(tt.filter)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.isMatch, m.Match(tt.routePath), "%s -> %s", tt.filter, tt.routePath)
+	}
+}
+
+func TestCompile_InvalidFilter(t *testing.T) {
+	for _, filter := range []string{"~(", "!~(", "/svc.[/Get*"} {
+		_, err := Compile(filter)
+		assert.ErrorIs(t, err, ErrInvalidFilter, filter)
+	}
+
+	assert.Panics(t, func() { MustCompile("~(") })
+}
+
+func TestTable_RegisterAndSearch(t *testing.T) {
+	var table Table[string]
+	assert.NoError(t, table.Register("/svc.A/Get", 0, "exact"))
+	assert.NoError(t, table.Register("/svc.A/*", 0, "service"))
+	assert.NoError(t, table.Register("/*", 0, "all-services"))
+	assert.NoError(t, table.Register("", 0, "global"))
+	assert.NoError(t, table.Register("*", 0, "global-appended"))
+	assert.NoError(t, table.Register("/svc.A/Get", 10, "priority"))
+	assert.ErrorIs(t, table.Register("~(", 0, "invalid"), ErrInvalidFilter)
+
+	assert.Equal(t, []string{"priority", "global", "global-appended", "all-services", "service", "exact"}, table.Search("/svc.A/Get"))
+	assert.Equal(t, []string{"/svc.A/Get", "*", "/*", "/svc.A/*", "/svc.A/Get"}, table.Filters())
+}
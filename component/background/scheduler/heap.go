@@ -0,0 +1,41 @@
+package scheduler
+
+// scheduledJob wrapper of the job with position in the heap
+type scheduledJob struct {
+	job   Job
+	index int
+}
+
+// timerHeap keeps jobs ordered by execution time, implements heap.Interface
+type timerHeap []*scheduledJob
+
+func (h timerHeap) Len() int {
+	return len(h)
+}
+
+func (h timerHeap) Less(i, j int) bool {
+	return h[i].job.RunAt.Before(h[j].job.RunAt)
+}
+
+func (h timerHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *timerHeap) Push(x any) {
+	sj := x.(*scheduledJob)
+	sj.index = len(*h)
+	*h = append(*h, sj)
+}
+
+func (h *timerHeap) Pop() any {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	item.index = -1
+	*h = old[:n-1]
+
+	return item
+}
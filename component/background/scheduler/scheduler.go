@@ -0,0 +1,319 @@
+package scheduler
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Clink-n-Clank/Brokkr/component/background"
+	"github.com/Clink-n-Clank/Brokkr/component/execution"
+)
+
+var (
+	// ErrSchedulerClosed is returned when scheduler is stopped and can't accept new jobs
+	ErrSchedulerClosed = errors.New("scheduler is closed")
+	// ErrInvalidJob is returned when job has no handler
+	ErrInvalidJob = errors.New("job has no handler")
+	// ErrDuplicateJob is returned when job with the same ID is already scheduled
+	ErrDuplicateJob = errors.New("job with the same ID is already scheduled")
+	// ErrPendingJobs is returned on stop when there are jobs that were not executed and there is no pending jobs handler
+	ErrPendingJobs = errors.New("scheduler stopped with pending jobs")
+)
+
+type (
+	// Job that will be executed once at RunAt time
+	Job struct {
+		// ID of the job, generated if not set
+		ID string
+		// RunAt time when job must be executed
+		RunAt time.Time
+		// Handler logic of the job
+		Handler func(ctx context.Context) error
+	}
+
+	// Scheduler process that executes one-shot and delayed jobs
+	Scheduler struct {
+		name     string
+		severity background.ProcessSeverity
+
+		timeout          time.Duration
+		onFailure        func(job Job, err error)
+		onPendingAtClose func(jobs []Job) error
+
+		state schedulerState
+	}
+
+	// schedulerState of pending and running jobs
+	schedulerState struct {
+		pending timerHeap
+		byID    map[string]*scheduledJob
+		closed  bool
+
+		wakeUp  chan struct{}
+		stopped chan struct{}
+		running sync.WaitGroup
+
+		jobsCtx       context.Context
+		jobsCtxCancel func()
+
+		sync.Mutex
+	}
+
+	// Option for scheduler configuration
+	Option func(s *Scheduler)
+)
+
+// SetJobTimeout for each job execution
+func SetJobTimeout(t time.Duration) Option {
+	return func(s *Scheduler) {
+		s.timeout = t
+	}
+}
+
+// SetFailureHandler called when job returned error
+func SetFailureHandler(h func(job Job, err error)) Option {
+	return func(s *Scheduler) {
+		s.onFailure = h
+	}
+}
+
+// SetPendingJobsHandler persistence hook that receives not executed jobs on stop, jobs can be scheduled again after restart
+func SetPendingJobsHandler(h func(jobs []Job) error) Option {
+	return func(s *Scheduler) {
+		s.onPendingAtClose = h
+	}
+}
+
+// SetSeverity of how important for the application to run this scheduler
+func SetSeverity(sv background.ProcessSeverity) Option {
+	return func(s *Scheduler) {
+		s.severity = sv
+	}
+}
+
+// NewScheduler a new instance
+func NewScheduler(name string, opts ...Option) *Scheduler {
+	s := &Scheduler{
+		name:      name,
+		severity:  background.TaskSeverityMajor,
+		timeout:   30 * time.Second,
+		onFailure: func(Job, error) {},
+	}
+
+	for _, o := range opts {
+		o(s)
+	}
+
+	s.state.byID = map[string]*scheduledJob{}
+	s.state.wakeUp = make(chan struct{}, 1)
+	s.state.stopped = make(chan struct{})
+	s.state.jobsCtx, s.state.jobsCtxCancel = context.WithCancel(context.Background())
+
+	return s
+}
+
+// GetName of the scheduler
+func (s *Scheduler) GetName() string {
+	return s.name
+}
+
+// GetSeverity of the scheduler
+func (s *Scheduler) GetSeverity() background.ProcessSeverity {
+	return s.severity
+}
+
+// ScheduleAt executes handler once at given time, returns ID of the job
+func (s *Scheduler) ScheduleAt(runAt time.Time, handler func(ctx context.Context) error) (string, error) {
+	return s.Schedule(Job{RunAt: runAt, Handler: handler})
+}
+
+// ScheduleAfter executes handler once after delay, returns ID of the job
+func (s *Scheduler) ScheduleAfter(delay time.Duration, handler func(ctx context.Context) error) (string, error) {
+	return s.Schedule(Job{RunAt: time.Now().Add(delay), Handler: handler})
+}
+
+// Schedule job, returns ID of the job
+func (s *Scheduler) Schedule(job Job) (string, error) {
+	if job.Handler == nil {
+		return "", ErrInvalidJob
+	}
+
+	if job.ID == "" {
+		job.ID = uuid.NewString()
+	}
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	if s.state.closed {
+		return "", ErrSchedulerClosed
+	}
+
+	if _, exist := s.state.byID[job.ID]; exist {
+		return "", ErrDuplicateJob
+	}
+
+	sj := &scheduledJob{job: job}
+	heap.Push(&s.state.pending, sj)
+	s.state.byID[job.ID] = sj
+
+	// Wake up loop if new job must be executed earlier than others
+	if sj.index == 0 {
+		s.wakeUpLoop()
+	}
+
+	return job.ID, nil
+}
+
+// Cancel pending job by ID, returns false if job is already executed or not found
+func (s *Scheduler) Cancel(id string) bool {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	sj, exist := s.state.byID[id]
+	if !exist {
+		return false
+	}
+
+	heap.Remove(&s.state.pending, sj.index)
+	delete(s.state.byID, id)
+	s.wakeUpLoop()
+
+	return true
+}
+
+// Pending jobs ordered by execution time
+func (s *Scheduler) Pending() []Job {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	return s.pendingJobs()
+}
+
+// OnStart event to be called when main loop will be started
+func (s *Scheduler) OnStart(_ context.Context) error {
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+
+	for {
+		nextRunIn, hasNext := s.executeDue(time.Now())
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		if hasNext {
+			timer.Reset(nextRunIn)
+		}
+
+		select {
+		case <-timer.C:
+		case <-s.state.wakeUp:
+		case <-s.state.stopped:
+			return nil
+		}
+	}
+}
+
+// OnStop event to be called when main loop will be stopped, running jobs are awaited until context deadline
+func (s *Scheduler) OnStop(ctx context.Context) error {
+	s.state.Lock()
+	if s.state.closed {
+		s.state.Unlock()
+		return nil
+	}
+
+	s.state.closed = true
+	close(s.state.stopped)
+	pending := s.pendingJobs()
+	s.state.Unlock()
+
+	runningDone := make(chan struct{})
+	go func() {
+		s.state.running.Wait()
+		close(runningDone)
+	}()
+
+	select {
+	case <-runningDone:
+	case <-ctx.Done():
+	}
+	s.state.jobsCtxCancel()
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	if s.onPendingAtClose != nil {
+		return s.onPendingAtClose(pending)
+	}
+
+	return fmt.Errorf("%w: %d", ErrPendingJobs, len(pending))
+}
+
+// executeDue jobs and returns duration until next job
+func (s *Scheduler) executeDue(now time.Time) (time.Duration, bool) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	for len(s.state.pending) > 0 && !s.state.closed {
+		next := s.state.pending[0]
+		if next.job.RunAt.After(now) {
+			return next.job.RunAt.Sub(now), true
+		}
+
+		heap.Pop(&s.state.pending)
+		delete(s.state.byID, next.job.ID)
+
+		s.state.running.Add(1)
+		go s.execute(next.job)
+	}
+
+	return 0, false
+}
+
+// execute job with timeout
+func (s *Scheduler) execute(job Job) {
+	defer s.state.running.Done()
+
+	jobCtx, jobCtxCancel := context.WithTimeout(s.state.jobsCtx, s.timeout)
+	defer jobCtxCancel()
+
+	err := execution.RunWithTimeout(jobCtx, s.timeout, func() error {
+		return job.Handler(jobCtx)
+	})
+
+	if err != nil {
+		s.onFailure(job, err)
+	}
+}
+
+// pendingJobs snapshot ordered by execution time
+func (s *Scheduler) pendingJobs() []Job {
+	jobs := make([]Job, 0, len(s.state.pending))
+	for _, sj := range s.state.pending {
+		jobs = append(jobs, sj.job)
+	}
+
+	sort.SliceStable(jobs, func(i, j int) bool {
+		return jobs[i].RunAt.Before(jobs[j].RunAt)
+	})
+
+	return jobs
+}
+
+// wakeUpLoop to recalculate next execution time
+func (s *Scheduler) wakeUpLoop() {
+	select {
+	case s.state.wakeUp <- struct{}{}:
+	default:
+	}
+}
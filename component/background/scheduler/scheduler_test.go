@@ -0,0 +1,123 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Clink-n-Clank/Brokkr/component/background"
+)
+
+func TestScheduler_ExecutesInOrder(t *testing.T) {
+	var mu sync.Mutex
+	var order []string
+	var wg sync.WaitGroup
+
+	s := NewScheduler("UnitTestScheduler")
+	go func() { _ = s.OnStart(context.Background()) }()
+
+	record := func(name string) func(context.Context) error {
+		wg.Add(1)
+		return func(context.Context) error {
+			defer wg.Done()
+
+			mu.Lock()
+			order = append(order, name)
+			mu.Unlock()
+
+			return nil
+		}
+	}
+
+	_, err := s.ScheduleAfter(60*time.Millisecond, record("last"))
+	assert.NoError(t, err)
+	_, err = s.ScheduleAfter(20*time.Millisecond, record("first"))
+	assert.NoError(t, err)
+	_, err = s.ScheduleAt(time.Now().Add(40*time.Millisecond), record("second"))
+	assert.NoError(t, err)
+
+	wg.Wait()
+	assert.Equal(t, []string{"first", "second", "last"}, order)
+	assert.Empty(t, s.Pending())
+
+	assert.NoError(t, s.OnStop(context.Background()))
+	assert.Equal(t, "UnitTestScheduler", s.GetName())
+	assert.Equal(t, background.TaskSeverityMajor, s.GetSeverity())
+}
+
+func TestScheduler_Cancel(t *testing.T) {
+	s := NewScheduler("UnitTestScheduler")
+	go func() { _ = s.OnStart(context.Background()) }()
+
+	executed := make(chan struct{}, 1)
+	id, err := s.ScheduleAfter(20*time.Millisecond, func(context.Context) error {
+		executed <- struct{}{}
+		return nil
+	})
+	assert.NoError(t, err)
+
+	assert.True(t, s.Cancel(id))
+	assert.False(t, s.Cancel(id))
+
+	select {
+	case <-executed:
+		t.Error("canceled job must not be executed")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	assert.NoError(t, s.OnStop(context.Background()))
+}
+
+func TestScheduler_PendingJobsOnStop(t *testing.T) {
+	noop := func(context.Context) error { return nil }
+
+	s := NewScheduler("UnitTestScheduler")
+	go func() { _ = s.OnStart(context.Background()) }()
+
+	_, err := s.Schedule(Job{ID: "reservation", RunAt: time.Now().Add(time.Hour), Handler: noop})
+	assert.NoError(t, err)
+	_, err = s.Schedule(Job{ID: "reservation", RunAt: time.Now().Add(time.Hour), Handler: noop})
+	assert.ErrorIs(t, err, ErrDuplicateJob)
+
+	assert.ErrorIs(t, s.OnStop(context.Background()), ErrPendingJobs)
+	_, err = s.ScheduleAfter(time.Second, noop)
+	assert.ErrorIs(t, err, ErrSchedulerClosed)
+
+	var persisted []Job
+	persistent := NewScheduler("UnitTestScheduler", SetPendingJobsHandler(func(jobs []Job) error {
+		persisted = jobs
+		return nil
+	}))
+
+	_, _ = persistent.Schedule(Job{ID: "later", RunAt: time.Now().Add(2 * time.Hour), Handler: noop})
+	_, _ = persistent.Schedule(Job{ID: "sooner", RunAt: time.Now().Add(time.Hour), Handler: noop})
+
+	assert.NoError(t, persistent.OnStop(context.Background()))
+	assert.Len(t, persisted, 2)
+	assert.Equal(t, "sooner", persisted[0].ID)
+	assert.Equal(t, "later", persisted[1].ID)
+}
+
+func TestScheduler_FailureHandler(t *testing.T) {
+	failed := make(chan Job, 1)
+	s := NewScheduler(
+		"UnitTestScheduler",
+		SetJobTimeout(10*time.Millisecond),
+		SetFailureHandler(func(job Job, err error) {
+			assert.ErrorIs(t, err, context.DeadlineExceeded)
+			failed <- job
+		}),
+	)
+	go func() { _ = s.OnStart(context.Background()) }()
+
+	id, _ := s.ScheduleAfter(0, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	assert.Equal(t, id, (<-failed).ID)
+	assert.NoError(t, s.OnStop(context.Background()))
+}
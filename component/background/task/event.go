@@ -0,0 +1,309 @@
+package task
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Clink-n-Clank/Brokkr/component/background"
+)
+
+type (
+	// EventTask a process that executes job handling when events are received instead of ticker
+	EventTask[E any] struct {
+		name     string
+		settings eventSettings
+
+		source  <-chan E
+		events  chan E
+		handler func(events []E) error
+		stats   *runStats
+
+		state eventState
+	}
+
+	// eventState of the worker
+	eventState struct {
+		pendingToShutdown bool
+		isRunning         bool
+		// stopped is closed when loop is finished and pending events are flushed
+		stopped chan struct{}
+
+		gracefulShutdown         chan struct{}
+		gracefulShutdownCallback func()
+
+		sync.Mutex
+	}
+
+	// eventSettings of event task
+	eventSettings struct {
+		severity    background.ProcessSeverity
+		debounce    time.Duration
+		maxBatch    int
+		bufferSize  int
+		historySize int
+	}
+
+	// EventOption for event task execution, debounce, batching etc.
+	EventOption func(s *eventSettings)
+)
+
+// SetDebounce waits for quiet period without new events before job is executed, so burst is handled once
+func SetDebounce(d time.Duration) EventOption {
+	return func(s *eventSettings) {
+		s.debounce = d
+	}
+}
+
+// SetMaxBatch maximum number of coalesced events passed to single job execution
+func SetMaxBatch(n int) EventOption {
+	return func(s *eventSettings) {
+		s.maxBatch = n
+	}
+}
+
+// SetEventBuffer size of in-process events buffer used by Notify
+func SetEventBuffer(n int) EventOption {
+	return func(s *eventSettings) {
+		s.bufferSize = n
+	}
+}
+
+// SetEventSeverity of how important for the application to run this task
+func SetEventSeverity(sv background.ProcessSeverity) EventOption {
+	return func(s *eventSettings) {
+		s.severity = sv
+	}
+}
+
+// SetEventHistorySize how many recent runs will be kept in task stats
+func SetEventHistorySize(size int) EventOption {
+	return func(s *eventSettings) {
+		s.historySize = size
+	}
+}
+
+// NewEventTask a new instance, job is executed with events received from source channel or Notify, source could be nil
+func NewEventTask[E any](
+	TaskName string,
+	source <-chan E,
+	GracefulShutdownCallback func(),
+	handler func(events []E) error,
+	opts ...EventOption,
+) *EventTask[E] {
+	settings := eventSettings{
+		severity:    background.TaskSeverityMajor,
+		maxBatch:    100,
+		bufferSize:  100,
+		historySize: 10,
+	}
+
+	for _, o := range opts {
+		o(&settings)
+	}
+
+	if settings.maxBatch < 1 {
+		settings.maxBatch = 1
+	}
+
+	return &EventTask[E]{
+		name:     TaskName,
+		settings: settings,
+		source:   source,
+		events:   make(chan E, settings.bufferSize),
+		handler:  handler,
+		stats:    newRunStats(settings.historySize),
+		state: eventState{
+			stopped:                  make(chan struct{}),
+			gracefulShutdown:         make(chan struct{}, 1),
+			gracefulShutdownCallback: GracefulShutdownCallback,
+		},
+	}
+}
+
+// GetName of the task
+func (t *EventTask[E]) GetName() string {
+	return t.name
+}
+
+// GetSeverity of the task
+func (t *EventTask[E]) GetSeverity() background.ProcessSeverity {
+	return t.settings.severity
+}
+
+// Notify task with in-process event, returns false if event buffer is full or task is pending to shutdown
+func (t *EventTask[E]) Notify(e E) bool {
+	t.state.Lock()
+	defer t.state.Unlock()
+
+	// Events buffer is closed under the same lock, so accepted event is always flushed before shutdown
+	if t.state.pendingToShutdown {
+		return false
+	}
+
+	select {
+	case t.events <- e:
+		return true
+	default:
+		return false
+	}
+}
+
+// Stats of the task executions
+func (t *EventTask[E]) Stats() Stats {
+	return t.stats.snapshot()
+}
+
+// OnStart event to be called when main loop will be started
+func (t *EventTask[E]) OnStart(ctx context.Context) error {
+	t.state.Lock()
+	t.state.isRunning = true
+	t.state.Unlock()
+	defer close(t.state.stopped)
+
+	var debounceC <-chan time.Time
+	debounce := time.NewTimer(t.settings.debounce)
+	debounce.Stop()
+	defer debounce.Stop()
+
+	source, events := t.source, t.events
+	batch := make([]E, 0, t.settings.maxBatch)
+
+	for {
+		select {
+		case e, isOpen := <-source:
+			if !isOpen {
+				source = nil
+				continue
+			}
+			batch = append(batch, e)
+		case e, isOpen := <-events:
+			// Events buffer is closed only on shutdown
+			if !isOpen {
+				events = nil
+				break
+			}
+			batch = append(batch, e)
+		case <-debounceC:
+			debounceC = nil
+			batch = t.processJob(batch)
+			continue
+		case <-t.state.gracefulShutdown:
+		}
+
+		if t.IsPendingToShutdown() {
+			// Events that were already accepted are handled before shutdown
+			for batch = t.drain(nil, t.events, batch); len(batch) > 0; batch = t.drain(nil, t.events, batch) {
+				batch = t.processJob(batch)
+			}
+
+			t.state.gracefulShutdownCallback()
+			ctx.Done()
+
+			return nil
+		}
+
+		if len(batch) >= t.settings.maxBatch {
+			stopDebounce(debounce)
+			debounceC = nil
+			batch = t.processJob(batch)
+			continue
+		}
+
+		if t.settings.debounce > 0 {
+			stopDebounce(debounce)
+			debounce.Reset(t.settings.debounce)
+			debounceC = debounce.C
+			continue
+		}
+
+		// Coalesce events that are already waiting in the channels
+		batch = t.drain(source, events, batch)
+		batch = t.processJob(batch)
+	}
+}
+
+// OnStop event to be called when main loop will be stopped, waits until running job is finished
+// and accepted events are flushed or ctx is done
+func (t *EventTask[E]) OnStop(ctx context.Context) error {
+	t.state.Lock()
+	if !t.state.pendingToShutdown {
+		t.state.pendingToShutdown = true
+		close(t.events)
+	}
+	isRunning := t.state.isRunning
+	t.state.Unlock()
+
+	select {
+	case t.state.gracefulShutdown <- struct{}{}:
+	default:
+	}
+
+	if !isRunning {
+		return nil
+	}
+
+	select {
+	case <-t.state.stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// IsPendingToShutdown a worker
+func (t *EventTask[E]) IsPendingToShutdown() bool {
+	t.state.Lock()
+	defer t.state.Unlock()
+
+	return t.state.pendingToShutdown
+}
+
+// stopDebounce timer and drop tick that already fired, so it's not handled for the next batch
+func stopDebounce(debounce *time.Timer) {
+	if !debounce.Stop() {
+		select {
+		case <-debounce.C:
+		default:
+		}
+	}
+}
+
+// drain events without waiting until batch is full
+func (t *EventTask[E]) drain(source, events <-chan E, batch []E) []E {
+	for len(batch) < t.settings.maxBatch {
+		select {
+		case e, isOpen := <-source:
+			if !isOpen {
+				source = nil
+				continue
+			}
+			batch = append(batch, e)
+		case e, isOpen := <-events:
+			if !isOpen {
+				events = nil
+				continue
+			}
+			batch = append(batch, e)
+		default:
+			return batch
+		}
+	}
+
+	return batch
+}
+
+// processJob with batch of events, returns empty batch for next events
+func (t *EventTask[E]) processJob(batch []E) []E {
+	if len(batch) == 0 {
+		return batch
+	}
+
+	run := Run{StartedAt: time.Now()}
+	run.Err = t.handler(batch)
+	run.FinishedAt = time.Now()
+	run.Duration = run.FinishedAt.Sub(run.StartedAt)
+	t.stats.record(run)
+
+	return make([]E, 0, t.settings.maxBatch)
+}
@@ -0,0 +1,150 @@
+package task
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventTask_SourceAndNotify(t *testing.T) {
+	source := make(chan string)
+	handled := make(chan []string, 10)
+
+	et := NewEventTask("UnitTestEvents", source, func() {}, func(events []string) error {
+		handled <- events
+		return nil
+	})
+
+	stopped := make(chan struct{})
+	go func() {
+		_ = et.OnStart(context.Background())
+		close(stopped)
+	}()
+
+	source <- "config-changed"
+	assert.Equal(t, []string{"config-changed"}, <-handled)
+
+	assert.True(t, et.Notify("cache-invalidated"))
+	assert.Equal(t, []string{"cache-invalidated"}, <-handled)
+
+	assert.NoError(t, et.OnStop(context.Background()))
+	<-stopped
+
+	assert.False(t, et.Notify("too-late"))
+	assert.Equal(t, uint64(2), et.Stats().Runs)
+	assert.Equal(t, "UnitTestEvents", et.GetName())
+}
+
+func TestEventTask_Debounce(t *testing.T) {
+	handled := make(chan []int, 10)
+	et := NewEventTask[int]("UnitTestEvents", nil, func() {}, func(events []int) error {
+		handled <- events
+		return nil
+	}, SetDebounce(30*time.Millisecond))
+
+	go func() { _ = et.OnStart(context.Background()) }()
+
+	for i := 0; i < 5; i++ {
+		assert.True(t, et.Notify(i))
+		time.Sleep(time.Millisecond)
+	}
+
+	assert.Equal(t, []int{0, 1, 2, 3, 4}, <-handled)
+	assert.NoError(t, et.OnStop(context.Background()))
+}
+
+func TestEventTask_MaxBatchAndShutdownFlush(t *testing.T) {
+	release := make(chan struct{})
+	handled := make(chan []int, 10)
+	stopCallback := make(chan struct{})
+
+	et := NewEventTask[int]("UnitTestEvents", nil, func() { close(stopCallback) }, func(events []int) error {
+		handled <- events
+		<-release
+		return nil
+	}, SetMaxBatch(2), SetDebounce(time.Hour))
+
+	go func() { _ = et.OnStart(context.Background()) }()
+
+	// Batch is full, so it's handled without waiting for debounce
+	et.Notify(1)
+	et.Notify(2)
+	assert.Equal(t, []int{1, 2}, <-handled)
+
+	// Events received during running job are coalesced and handled before shutdown
+	et.Notify(3)
+	stopped := make(chan error, 1)
+	go func() { stopped <- et.OnStop(context.Background()) }()
+	close(release)
+
+	// Stop waits until accepted events are flushed
+	assert.NoError(t, <-stopped)
+	<-stopCallback
+	assert.Equal(t, []int{3}, <-handled)
+	assert.False(t, et.Notify(4))
+}
+
+func TestEventTask_NotifyDuringShutdownIsFlushed(t *testing.T) {
+	for i := 0; i < 50; i++ {
+		var handled atomic.Int32
+		et := NewEventTask[int]("UnitTestEvents", nil, func() {}, func(events []int) error {
+			handled.Add(int32(len(events)))
+			return nil
+		}, SetEventBuffer(1000))
+
+		go func() { _ = et.OnStart(context.Background()) }()
+
+		// Task is running before shutdown
+		assert.True(t, et.Notify(-1))
+		assert.Eventually(t, func() bool { return handled.Load() == 1 }, time.Second, time.Millisecond)
+
+		var accepted atomic.Int32
+		accepted.Add(1)
+		notified := make(chan struct{})
+		go func() {
+			defer close(notified)
+			for n := 0; n < 100; n++ {
+				if et.Notify(n) {
+					accepted.Add(1)
+				}
+			}
+		}()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		assert.NoError(t, et.OnStop(ctx))
+		cancel()
+		<-notified
+
+		assert.Equal(t, accepted.Load(), handled.Load())
+	}
+}
+
+func TestEventTask_MaxBatchStopsDebounce(t *testing.T) {
+	handled := make(chan []int, 10)
+	et := NewEventTask[int]("UnitTestEvents", nil, func() {}, func(events []int) error {
+		handled <- events
+		return nil
+	}, SetMaxBatch(2), SetDebounce(50*time.Millisecond))
+
+	go func() { _ = et.OnStart(context.Background()) }()
+
+	assert.True(t, et.Notify(1))
+	assert.True(t, et.Notify(2))
+	assert.Equal(t, []int{1, 2}, <-handled)
+
+	// Debounce of flushed batch must not cut quiet period of the next one
+	time.Sleep(80 * time.Millisecond)
+	assert.True(t, et.Notify(3))
+
+	select {
+	case events := <-handled:
+		assert.Fail(t, "batch is flushed before debounce", "%v", events)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	assert.Equal(t, []int{3}, <-handled)
+	assert.NoError(t, et.OnStop(context.Background()))
+}
@@ -0,0 +1,123 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Clink-n-Clank/Brokkr/component/behavior/lease"
+)
+
+// SkippedReasonLeaseHeld job was not executed because lease is held by another replica
+const SkippedReasonLeaseHeld = "lease is held by another owner"
+
+// minLeaseTTL that leaves room for renewal of the lease three times per ttl
+const minLeaseTTL = 3 * time.Millisecond
+
+// ErrInvalidLeaseTTL is returned on start when lease ttl is shorter than 3ms
+var ErrInvalidLeaseTTL = errors.New("lease ttl must be at least 3ms")
+
+// SetLeaseLocker makes task singleton across replicas, job is executed only by the lease holder of the task name.
+// Lease is kept between runs, so with ttl longer than exec interval the same replica stays the holder until it stops.
+func SetLeaseLocker(locker lease.Locker, owner string, ttl time.Duration) Option {
+	return func(c *BackgroundTask) {
+		c.leaseLocker = locker
+		c.leaseOwner = owner
+		c.leaseTTL = ttl
+		c.leaseErr = nil
+
+		if ttl < minLeaseTTL {
+			c.leaseErr = fmt.Errorf("%w: %s", ErrInvalidLeaseTTL, ttl)
+		}
+	}
+}
+
+// Lease currently held by the task, fencing token of it can be used to protect shared resources
+func (t *BackgroundTask) Lease() (lease.Lease, bool) {
+	t.state.Lock()
+	defer t.state.Unlock()
+
+	if t.state.lease.IsValid(time.Now()) {
+		return t.state.lease, true
+	}
+
+	return lease.Lease{}, false
+}
+
+// holdLease by acquiring or renewing it
+func (t *BackgroundTask) holdLease() error {
+	ctx, ctxCancel := context.WithTimeout(context.Background(), t.leaseTTL)
+	defer ctxCancel()
+
+	current, isHeld := t.Lease()
+	if isHeld {
+		renewed, err := t.leaseLocker.Renew(ctx, current, t.leaseTTL)
+		if err == nil {
+			t.setLease(renewed)
+			return nil
+		}
+
+		if !errors.Is(err, lease.ErrLeaseLost) {
+			return err
+		}
+	}
+
+	acquired, err := t.leaseLocker.Acquire(ctx, t.name, t.leaseOwner, t.leaseTTL)
+	if err != nil {
+		t.setLease(lease.Lease{})
+		return err
+	}
+
+	t.setLease(acquired)
+
+	return nil
+}
+
+// keepLease renewed while job is processing, returns function to stop renewal
+func (t *BackgroundTask) keepLease() func() {
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+
+		ticker := time.NewTicker(t.leaseTTL / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				_ = t.holdLease()
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(stop)
+		<-stopped
+	}
+}
+
+// releaseLease on shutdown, so another replica can take over
+func (t *BackgroundTask) releaseLease() {
+	current, isHeld := t.Lease()
+	if t.leaseLocker == nil || !isHeld {
+		return
+	}
+
+	ctx, ctxCancel := context.WithTimeout(context.Background(), t.leaseTTL)
+	defer ctxCancel()
+
+	_ = t.leaseLocker.Release(ctx, current)
+	t.setLease(lease.Lease{})
+}
+
+func (t *BackgroundTask) setLease(l lease.Lease) {
+	t.state.Lock()
+	defer t.state.Unlock()
+
+	t.state.lease = l
+}
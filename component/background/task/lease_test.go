@@ -0,0 +1,75 @@
+package task
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Clink-n-Clank/Brokkr/component/behavior/lease"
+)
+
+func TestCronWorker_LeaseSingleton(t *testing.T) {
+	locker := lease.NewMemoryLocker()
+	runs := map[string]int{}
+
+	newReplica := func(owner string) *BackgroundTask {
+		return NewBackgroundTask(
+			"UnitTestNightly",
+			func() {},
+			SetExecInterval(time.Hour),
+			SetLeaseLocker(locker, owner, time.Minute),
+			SetHandler(func() error {
+				runs[owner]++
+				return nil
+			}),
+		)
+	}
+
+	replicaA, replicaB := newReplica("replica-a"), newReplica("replica-b")
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, replicaA.processJob(false))
+		assert.NoError(t, replicaB.processJob(false))
+	}
+
+	assert.Equal(t, 3, runs["replica-a"])
+	assert.Equal(t, 0, runs["replica-b"])
+	assert.Equal(t, uint64(3), replicaB.Stats().Skips)
+	assert.Equal(t, SkippedReasonLeaseHeld, replicaB.Stats().History[0].SkippedReason)
+
+	l, isHeld := replicaA.Lease()
+	assert.True(t, isHeld)
+	assert.Equal(t, uint64(1), l.Token)
+
+	// Holder releases lease on shutdown, another replica takes over with new fencing token
+	stopped := make(chan struct{})
+	go func() {
+		_ = replicaA.OnStart(context.Background())
+		close(stopped)
+	}()
+	assert.NoError(t, replicaA.OnStop(context.Background()))
+	<-stopped
+
+	_, isHeld = replicaA.Lease()
+	assert.False(t, isHeld)
+
+	assert.NoError(t, replicaB.processJob(false))
+	assert.Equal(t, 1, runs["replica-b"])
+
+	l, _ = replicaB.Lease()
+	assert.Equal(t, uint64(2), l.Token)
+}
+
+func TestCronWorker_LeaseInvalidTTL(t *testing.T) {
+	c := NewBackgroundTask(
+		"UnitTestNightly",
+		func() {},
+		SetExecInterval(time.Hour),
+		SetLeaseLocker(lease.NewMemoryLocker(), "replica-a", time.Nanosecond),
+		SetHandler(func() error { return nil }),
+	)
+
+	assert.ErrorIs(t, c.OnStart(context.Background()), ErrInvalidLeaseTTL)
+}
@@ -0,0 +1,129 @@
+package task
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	SkippedReasonPaused            = "task is paused"
+	SkippedReasonAlreadyProcessing = "previous job is still processing"
+	SkippedReasonPendingToShutdown = "task is pending to shutdown"
+)
+
+type (
+	// Run of the job in the task
+	Run struct {
+		StartedAt  time.Time
+		FinishedAt time.Time
+		Duration   time.Duration
+		Err        error
+		// SkippedReason is set when job was not executed
+		SkippedReason string
+		// IsTriggered when job was executed by manual trigger instead of schedule
+		IsTriggered bool
+	}
+
+	// Stats of the task executions
+	Stats struct {
+		Runs        uint64
+		Failures    uint64
+		Skips       uint64
+		AvgDuration time.Duration
+		MaxDuration time.Duration
+		// History of recent runs, the oldest is first
+		History []Run
+	}
+
+	// runStats keeps aggregated counters and bounded history of the runs
+	runStats struct {
+		runs          uint64
+		failures      uint64
+		skips         uint64
+		totalDuration time.Duration
+		maxDuration   time.Duration
+
+		history     []Run
+		historyNext int
+		historyFull bool
+
+		sync.Mutex
+	}
+)
+
+// SetHistorySize how many recent runs will be kept in task stats
+func SetHistorySize(size int) Option {
+	return func(c *BackgroundTask) {
+		c.historySize = size
+	}
+}
+
+// Stats of the task executions
+func (t *BackgroundTask) Stats() Stats {
+	return t.stats.snapshot()
+}
+
+// newRunStats with history capacity
+func newRunStats(historySize int) *runStats {
+	if historySize < 0 {
+		historySize = 0
+	}
+
+	return &runStats{history: make([]Run, historySize)}
+}
+
+// snapshot of counters and history, the oldest run is first
+func (s *runStats) snapshot() Stats {
+	s.Lock()
+	defer s.Unlock()
+
+	snap := Stats{
+		Runs:        s.runs,
+		Failures:    s.failures,
+		Skips:       s.skips,
+		MaxDuration: s.maxDuration,
+		History:     make([]Run, 0, len(s.history)),
+	}
+
+	if s.runs > 0 {
+		snap.AvgDuration = s.totalDuration / time.Duration(s.runs)
+	}
+
+	if s.historyFull {
+		snap.History = append(snap.History, s.history[s.historyNext:]...)
+	}
+	snap.History = append(snap.History, s.history[:s.historyNext]...)
+
+	return snap
+}
+
+// record run into counters and history
+func (s *runStats) record(r Run) {
+	s.Lock()
+	defer s.Unlock()
+
+	if r.SkippedReason != "" {
+		s.skips++
+	} else {
+		s.runs++
+		s.totalDuration += r.Duration
+
+		if r.Err != nil {
+			s.failures++
+		}
+
+		if r.Duration > s.maxDuration {
+			s.maxDuration = r.Duration
+		}
+	}
+
+	if len(s.history) == 0 {
+		return
+	}
+
+	s.history[s.historyNext] = r
+	s.historyNext = (s.historyNext + 1) % len(s.history)
+	if s.historyNext == 0 {
+		s.historyFull = true
+	}
+}
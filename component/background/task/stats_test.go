@@ -0,0 +1,78 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCronWorker_Stats(t *testing.T) {
+	handlerErr := errors.New("unit failure")
+	handled := make(chan struct{}, 10)
+	calls := 0
+
+	c := NewBackgroundTask(
+		"UnitTestCron",
+		func() {},
+		SetExecInterval(time.Hour),
+		SetHistorySize(2),
+		SetHandler(func() error {
+			defer func() { handled <- struct{}{} }()
+
+			calls++
+			time.Sleep(time.Millisecond)
+			if calls == 2 {
+				return handlerErr
+			}
+
+			return nil
+		}),
+	)
+
+	workerCtx := context.Background()
+	stopped := make(chan struct{})
+	go func() {
+		_ = c.OnStart(workerCtx)
+		close(stopped)
+	}()
+
+	<-handled
+	c.TriggerNow()
+	<-handled
+	c.TriggerNow()
+	<-handled
+
+	assert.NoError(t, c.OnStop(workerCtx))
+	<-stopped
+
+	s := c.Stats()
+	assert.Equal(t, uint64(3), s.Runs)
+	assert.Equal(t, uint64(1), s.Failures)
+	assert.Equal(t, uint64(0), s.Skips)
+	assert.GreaterOrEqual(t, s.MaxDuration, s.AvgDuration)
+	assert.GreaterOrEqual(t, s.AvgDuration, time.Millisecond)
+
+	assert.Len(t, s.History, 2)
+	assert.ErrorIs(t, s.History[0].Err, handlerErr)
+	assert.True(t, s.History[0].IsTriggered)
+	assert.NoError(t, s.History[1].Err)
+}
+
+func TestCronWorker_StatsSkipped(t *testing.T) {
+	c := NewBackgroundTask("UnitTestCron", func() {}, SetExecInterval(time.Hour), SetHandler(func() error { return nil }))
+
+	c.Pause()
+	assert.NoError(t, c.processJob(false))
+
+	c.setPendingToShutdown()
+	assert.NoError(t, c.processJob(true))
+
+	s := c.Stats()
+	assert.Equal(t, uint64(0), s.Runs)
+	assert.Equal(t, uint64(2), s.Skips)
+	assert.Equal(t, SkippedReasonPaused, s.History[0].SkippedReason)
+	assert.Equal(t, SkippedReasonPendingToShutdown, s.History[1].SkippedReason)
+}
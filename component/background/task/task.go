@@ -2,10 +2,12 @@ package task
 
 import (
 	"context"
+	"errors"
 	"sync"
 	"time"
 
 	"github.com/Clink-n-Clank/Brokkr/component/background"
+	"github.com/Clink-n-Clank/Brokkr/component/behavior/lease"
 )
 
 type (
@@ -19,13 +21,25 @@ type (
 		handler           func() error
 		execInterval      time.Duration
 		processingTimeout time.Duration
+
+		historySize int
+		stats       *runStats
+
+		leaseLocker lease.Locker
+		leaseOwner  string
+		leaseTTL    time.Duration
+		leaseErr    error
 	}
 
 	// processState of the worker
 	processState struct {
 		isRunningTask     bool
 		pendingToShutdown bool
+		isPaused          bool
+		lastTickAt        time.Time
+		lease             lease.Lease
 
+		trigger                  chan struct{}
 		gracefulShutdown         chan struct{}
 		gracefulShutdownCallback func()
 
@@ -67,17 +81,22 @@ func SetSeverity(s background.ProcessSeverity) Option {
 // NewBackgroundTask a new instance
 func NewBackgroundTask(TaskName string, GracefulShutdownCallback func(), opts ...Option) *BackgroundTask {
 	cw := &BackgroundTask{
-		name:     TaskName,
-		severity: background.TaskSeverityMajor,
+		name:        TaskName,
+		severity:    background.TaskSeverityMajor,
+		historySize: 10,
 	}
 
 	for _, o := range opts {
 		o(cw)
 	}
 
+	cw.stats = newRunStats(cw.historySize)
+
 	cw.ticker = time.NewTicker(cw.execInterval)
 	cw.state = processState{
-		gracefulShutdown:         make(chan struct{}),
+		lastTickAt:               time.Now(),
+		trigger:                  make(chan struct{}, 1),
+		gracefulShutdown:         make(chan struct{}, 1),
 		gracefulShutdownCallback: GracefulShutdownCallback,
 	}
 
@@ -96,16 +115,28 @@ func (t *BackgroundTask) GetSeverity() background.ProcessSeverity {
 
 // OnStart event to be called when main loop will be started
 func (t *BackgroundTask) OnStart(ctx context.Context) error {
-	if err := t.processJob(); err != nil {
+	if t.leaseErr != nil {
+		return t.leaseErr
+	}
+
+	if err := t.processJob(false); err != nil {
 		return err
 	}
 
 	for {
 		select {
-		case <-t.ticker.C:
-			_ = t.processJob()
+		case tick := <-t.ticker.C:
+			t.setLastTickAt(tick)
+			_ = t.processJob(false)
+		case <-t.state.trigger:
+			_ = t.processJob(true)
 		case <-t.state.gracefulShutdown:
-			close(t.state.gracefulShutdown)
+		}
+
+		// Job that was running during stop request must be finished before shutdown
+		if t.IsPendingToShutdown() {
+			t.ticker.Stop()
+			t.releaseLease()
 			t.state.gracefulShutdownCallback()
 			ctx.Done()
 
@@ -118,25 +149,113 @@ func (t *BackgroundTask) OnStart(ctx context.Context) error {
 func (t *BackgroundTask) OnStop(ctx context.Context) error {
 	defer ctx.Done()
 
-	t.togglePendingToShutdown()
+	t.setPendingToShutdown()
 
-	// If there is no running jobs, stop it now
-	if !t.IsProcessingJob() {
-		t.state.gracefulShutdown <- struct{}{}
+	// Loop is always woken up, running job is finished before loop checks pending shutdown
+	select {
+	case t.state.gracefulShutdown <- struct{}{}:
+	default:
 	}
 
 	return nil
 }
 
-func (t *BackgroundTask) processJob() error {
-	if t.IsPendingToShutdown() || t.IsProcessingJob() {
+// TriggerNow will execute job as soon as possible without waiting for next tick, even if task is paused
+func (t *BackgroundTask) TriggerNow() {
+	select {
+	case t.state.trigger <- struct{}{}:
+	default: // Already triggered and not yet handled
+	}
+}
+
+// Pause scheduled execution of the job, manual trigger still will be handled
+func (t *BackgroundTask) Pause() {
+	t.state.Lock()
+	defer t.state.Unlock()
+
+	t.state.isPaused = true
+}
+
+// Resume scheduled execution of the job
+func (t *BackgroundTask) Resume() {
+	t.state.Lock()
+	defer t.state.Unlock()
+
+	t.state.isPaused = false
+}
+
+// IsPaused scheduled execution of the job
+func (t *BackgroundTask) IsPaused() bool {
+	t.state.Lock()
+	defer t.state.Unlock()
+
+	return t.state.isPaused
+}
+
+// NextRunAt time when job will be executed by schedule, zero time if task is paused or pending to shutdown
+func (t *BackgroundTask) NextRunAt() time.Time {
+	t.state.Lock()
+	defer t.state.Unlock()
+
+	if t.state.isPaused || t.state.pendingToShutdown {
+		return time.Time{}
+	}
+
+	return t.state.lastTickAt.Add(t.execInterval)
+}
+
+func (t *BackgroundTask) setLastTickAt(tick time.Time) {
+	t.state.Lock()
+	defer t.state.Unlock()
+
+	t.state.lastTickAt = tick
+}
+
+func (t *BackgroundTask) processJob(isTriggered bool) error {
+	run := Run{StartedAt: time.Now(), IsTriggered: isTriggered}
+
+	switch {
+	case t.IsPendingToShutdown():
+		run.SkippedReason = SkippedReasonPendingToShutdown
+	case t.IsProcessingJob():
+		run.SkippedReason = SkippedReasonAlreadyProcessing
+	case !isTriggered && t.IsPaused():
+		run.SkippedReason = SkippedReasonPaused
+	}
+
+	if run.SkippedReason != "" {
+		run.FinishedAt = run.StartedAt
+		t.stats.record(run)
+
 		return nil
 	}
 
 	t.toggleIsProcessingJob()
 	defer t.toggleIsProcessingJob()
 
-	return t.handler()
+	if t.leaseLocker != nil {
+		if err := t.holdLease(); err != nil {
+			if errors.Is(err, lease.ErrLeaseHeld) {
+				run.SkippedReason = SkippedReasonLeaseHeld
+				err = nil
+			}
+
+			run.Err = err
+			run.FinishedAt = time.Now()
+			t.stats.record(run)
+
+			return err
+		}
+
+		defer t.keepLease()()
+	}
+
+	run.Err = t.handler()
+	run.FinishedAt = time.Now()
+	run.Duration = run.FinishedAt.Sub(run.StartedAt)
+	t.stats.record(run)
+
+	return run.Err
 }
 
 // IsPendingToShutdown a worker
@@ -147,11 +266,11 @@ func (t *BackgroundTask) IsPendingToShutdown() bool {
 	return t.state.pendingToShutdown
 }
 
-func (t *BackgroundTask) togglePendingToShutdown() {
+func (t *BackgroundTask) setPendingToShutdown() {
 	t.state.Lock()
 	defer t.state.Unlock()
 
-	t.state.pendingToShutdown = !t.state.pendingToShutdown
+	t.state.pendingToShutdown = true
 }
 
 // IsProcessingJob in worker cycle handling
@@ -94,3 +94,77 @@ func TestCronWorker_StartAndStop(t *testing.T) {
 		"Test didn't finish in time, possible dead lock in Cron loop",
 	)
 }
+
+func TestCronWorker_TriggerPauseResume(t *testing.T) {
+	handled := make(chan struct{}, 10)
+	c := NewBackgroundTask(
+		"UnitTestCron",
+		func() {},
+		SetExecInterval(time.Hour),
+		SetHandler(func() error {
+			handled <- struct{}{}
+			return nil
+		}),
+	)
+
+	workerCtx := context.Background()
+	stopped := make(chan struct{})
+	go func() {
+		_ = c.OnStart(workerCtx)
+		close(stopped)
+	}()
+
+	// First execution on start
+	<-handled
+	assert.WithinDuration(t, time.Now().Add(time.Hour), c.NextRunAt(), time.Minute)
+
+	c.Pause()
+	assert.True(t, c.IsPaused())
+	assert.True(t, c.NextRunAt().IsZero())
+
+	// Manual trigger is handled even for paused task
+	c.TriggerNow()
+	<-handled
+
+	c.Resume()
+	assert.False(t, c.IsPaused())
+	assert.False(t, c.NextRunAt().IsZero())
+
+	c.TriggerNow()
+	<-handled
+
+	assert.NoError(t, c.OnStop(workerCtx))
+	<-stopped
+	assert.True(t, c.NextRunAt().IsZero())
+}
+
+func TestCronWorker_StopDuringFirstJob(t *testing.T) {
+	inHandler, unblock := make(chan struct{}), make(chan struct{})
+	c := NewBackgroundTask(
+		"UnitTestCron",
+		func() {},
+		SetExecInterval(time.Hour),
+		SetHandler(func() error {
+			close(inHandler)
+			<-unblock
+			return nil
+		}),
+	)
+
+	workerCtx := context.Background()
+	stopped := make(chan struct{})
+	go func() {
+		_ = c.OnStart(workerCtx)
+		close(stopped)
+	}()
+
+	<-inHandler
+	assert.NoError(t, c.OnStop(workerCtx))
+	close(unblock)
+
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		assert.Fail(t, "task must stop after first job without waiting for next tick")
+	}
+}
@@ -0,0 +1,103 @@
+package concurrency
+
+import (
+	"math"
+	"time"
+)
+
+type (
+	// Sample of finished request
+	Sample struct {
+		// RTT latency of the request
+		RTT time.Duration
+		// InFlight requests when request was started
+		InFlight int
+		// IsDropped if request timed out or failed because of overload
+		IsDropped bool
+	}
+
+	// Algorithm adjusts concurrency limit from observed samples, it's called under lock of the limiter
+	Algorithm interface {
+		// Update limit with sample, new limit is returned
+		Update(limit int, s Sample) int
+	}
+
+	// AIMD additive increase, multiplicative decrease, limit is decreased on drops or latency above timeout
+	AIMD struct {
+		// BackoffRatio of the limit on drop, 0.9 by default
+		BackoffRatio float64
+		// Timeout latency that is treated as drop, disabled if zero
+		Timeout time.Duration
+	}
+
+	// Gradient adjusts limit by ratio of no load latency to current latency like TCP Vegas
+	Gradient struct {
+		// Smoothing of limit changes from 0 to 1, 0.2 by default
+		Smoothing float64
+		// ProbeInterval of samples to reset no load latency, 1000 by default
+		ProbeInterval int
+
+		minRTT  time.Duration
+		samples int
+	}
+)
+
+// Update limit, it grows by one when at least half of limit is used
+func (a AIMD) Update(limit int, s Sample) int {
+	ratio := a.BackoffRatio
+	if ratio <= 0 || ratio >= 1 {
+		ratio = 0.9
+	}
+
+	if s.IsDropped || (a.Timeout > 0 && s.RTT > a.Timeout) {
+		return int(float64(limit) * ratio)
+	}
+
+	if s.InFlight*2 >= limit {
+		return limit + 1
+	}
+
+	return limit
+}
+
+// Update limit, square root of limit is allowed to be queued above estimated capacity
+func (g *Gradient) Update(limit int, s Sample) int {
+	smoothing := g.Smoothing
+	if smoothing <= 0 || smoothing > 1 {
+		smoothing = 0.2
+	}
+
+	probeInterval := g.ProbeInterval
+	if probeInterval <= 0 {
+		probeInterval = 1000
+	}
+
+	if s.IsDropped {
+		return int(float64(limit) * (1 - smoothing/2))
+	}
+
+	if s.RTT <= 0 {
+		return limit
+	}
+
+	// No load latency could change, for example after deployment, so it's probed again from time to time
+	g.samples++
+	if g.samples >= probeInterval {
+		g.samples, g.minRTT = 0, 0
+	}
+
+	if g.minRTT == 0 || s.RTT < g.minRTT {
+		g.minRTT = s.RTT
+	}
+
+	// Limit is not increased when it's not used, otherwise it grows without control
+	if s.InFlight*2 < limit {
+		return limit
+	}
+
+	gradient := math.Max(0.5, math.Min(1, float64(g.minRTT)/float64(s.RTT)))
+	queueSize := math.Sqrt(float64(limit))
+	estimated := float64(limit)*gradient + queueSize
+
+	return int(math.Round(float64(limit)*(1-smoothing) + estimated*smoothing))
+}
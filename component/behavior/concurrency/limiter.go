@@ -0,0 +1,181 @@
+package concurrency
+
+import (
+	"sync"
+	"time"
+)
+
+// Priority of the request, requests with lower priority are shed first
+type Priority byte
+
+const (
+	// PriorityLow requests are allowed while less than 75% of limit is used
+	PriorityLow Priority = iota
+	// PriorityNormal requests are allowed while less than 90% of limit is used
+	PriorityNormal
+	// PriorityCritical requests are allowed until limit is reached, for example health checks
+	PriorityCritical
+)
+
+// priorityShare of the limit that is available for priority
+var priorityShare = map[Priority]float64{
+	PriorityLow:      0.75,
+	PriorityNormal:   0.9,
+	PriorityCritical: 1,
+}
+
+type (
+	// Stats of the limiter for observability
+	Stats struct {
+		Limit    int
+		InFlight int
+		// Shed requests by priority since start
+		Shed map[Priority]uint64
+	}
+
+	// ReleaseFunc must be called when request is finished, isDropped reports timeout or overload failure
+	ReleaseFunc func(isDropped bool)
+
+	// Limiter of concurrent requests, limit is adjusted by algorithm after each finished request
+	Limiter struct {
+		sync.Mutex
+		algorithm     Algorithm
+		limit         int
+		minLimit      int
+		maxLimit      int
+		inFlight      int
+		shed          map[Priority]uint64
+		now           func() time.Time
+		onLimitChange func(limit int)
+	}
+
+	// Option for limiter configuration
+	Option func(l *Limiter)
+)
+
+// SetInitialLimit of concurrent requests, 20 by default
+func SetInitialLimit(limit int) Option {
+	return func(l *Limiter) {
+		l.limit = limit
+	}
+}
+
+// SetLimitBounds that algorithm can't cross, 1 and 1000 by default
+func SetLimitBounds(minLimit, maxLimit int) Option {
+	return func(l *Limiter) {
+		l.minLimit = minLimit
+		l.maxLimit = maxLimit
+	}
+}
+
+// SetLimitChangeHandler that is called with new limit each time it's changed
+func SetLimitChangeHandler(h func(limit int)) Option {
+	return func(l *Limiter) {
+		l.onLimitChange = h
+	}
+}
+
+// SetClock used to measure latency
+func SetClock(now func() time.Time) Option {
+	return func(l *Limiter) {
+		l.now = now
+	}
+}
+
+// NewLimiter with algorithm, for example AIMD or Gradient
+func NewLimiter(algorithm Algorithm, opts ...Option) *Limiter {
+	l := &Limiter{
+		algorithm:     algorithm,
+		limit:         20,
+		minLimit:      1,
+		maxLimit:      1000,
+		shed:          map[Priority]uint64{},
+		now:           time.Now,
+		onLimitChange: func(int) {},
+	}
+
+	for _, o := range opts {
+		o(l)
+	}
+
+	l.limit = l.bound(l.limit)
+
+	return l
+}
+
+// Acquire slot for request, false is returned when request must be shed
+func (l *Limiter) Acquire(priority Priority) (ReleaseFunc, bool) {
+	l.Lock()
+	defer l.Unlock()
+
+	share, isKnown := priorityShare[priority]
+	if !isKnown {
+		share = priorityShare[PriorityNormal]
+	}
+
+	// Critical requests can use the whole limit, others leave room for more important requests,
+	// each priority has at least one slot, so small limit doesn't shed everything and can grow back
+	available := int(float64(l.limit) * share)
+	if available < 1 {
+		available = 1
+	}
+
+	if l.inFlight >= available || l.inFlight >= l.limit {
+		l.shed[priority]++
+		return nil, false
+	}
+
+	l.inFlight++
+	inFlight := l.inFlight
+	startedAt := l.now()
+
+	var once sync.Once
+
+	return func(isDropped bool) {
+		once.Do(func() {
+			l.release(Sample{RTT: l.now().Sub(startedAt), InFlight: inFlight, IsDropped: isDropped})
+		})
+	}, true
+}
+
+// Stats of the limiter
+func (l *Limiter) Stats() Stats {
+	l.Lock()
+	defer l.Unlock()
+
+	shed := make(map[Priority]uint64, len(l.shed))
+	for p, n := range l.shed {
+		shed[p] = n
+	}
+
+	return Stats{Limit: l.limit, InFlight: l.inFlight, Shed: shed}
+}
+
+// release slot and update limit with sample
+func (l *Limiter) release(s Sample) {
+	l.Lock()
+
+	l.inFlight--
+	prev := l.limit
+	l.limit = l.bound(l.algorithm.Update(l.limit, s))
+	limit := l.limit
+
+	l.Unlock()
+
+	if limit != prev {
+		l.onLimitChange(limit)
+	}
+}
+
+// bound limit by min and max
+func (l *Limiter) bound(limit int) int {
+	if limit < l.minLimit {
+		return l.minLimit
+	}
+
+	if limit > l.maxLimit {
+		return l.maxLimit
+	}
+
+	return limit
+}
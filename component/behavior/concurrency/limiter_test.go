@@ -0,0 +1,139 @@
+package concurrency
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimiter_Priorities(t *testing.T) {
+	l := NewLimiter(AIMD{}, SetInitialLimit(10))
+
+	releases := make([]ReleaseFunc, 0)
+	for i := 0; i < 7; i++ {
+		release, isAcquired := l.Acquire(PriorityLow)
+		assert.True(t, isAcquired)
+		releases = append(releases, release)
+	}
+
+	// Low priority is shed at 75% of limit
+	_, isAcquired := l.Acquire(PriorityLow)
+	assert.False(t, isAcquired)
+
+	for i := 0; i < 2; i++ {
+		release, isNormalAcquired := l.Acquire(PriorityNormal)
+		assert.True(t, isNormalAcquired)
+		releases = append(releases, release)
+	}
+
+	_, isAcquired = l.Acquire(PriorityNormal)
+	assert.False(t, isAcquired)
+
+	release, isAcquired := l.Acquire(PriorityCritical)
+	assert.True(t, isAcquired)
+	releases = append(releases, release)
+
+	_, isAcquired = l.Acquire(PriorityCritical)
+	assert.False(t, isAcquired)
+
+	stats := l.Stats()
+	assert.Equal(t, 10, stats.Limit)
+	assert.Equal(t, 10, stats.InFlight)
+	assert.Equal(t, map[Priority]uint64{PriorityLow: 1, PriorityNormal: 1, PriorityCritical: 1}, stats.Shed)
+
+	for _, r := range releases {
+		r(false)
+		r(false)
+	}
+
+	stats = l.Stats()
+	assert.Equal(t, 0, stats.InFlight)
+	assert.Greater(t, stats.Limit, 10)
+}
+
+func TestLimiter_MinimalLimit(t *testing.T) {
+	l := NewLimiter(AIMD{}, SetInitialLimit(1), SetLimitBounds(1, 1))
+
+	// Each priority has at least one slot at limit 1
+	for _, p := range []Priority{PriorityLow, PriorityNormal, PriorityCritical} {
+		release, isAcquired := l.Acquire(p)
+		assert.True(t, isAcquired)
+
+		_, isAcquired = l.Acquire(PriorityCritical)
+		assert.False(t, isAcquired)
+
+		release(false)
+	}
+
+	// Limit recovers from the minimum with low priority requests only
+	l = NewLimiter(AIMD{}, SetInitialLimit(1), SetLimitBounds(1, 10))
+	release, isAcquired := l.Acquire(PriorityLow)
+	assert.True(t, isAcquired)
+	release(false)
+	assert.Equal(t, 2, l.Stats().Limit)
+}
+
+func TestLimiter_AIMD(t *testing.T) {
+	var changes []int
+	l := NewLimiter(AIMD{BackoffRatio: 0.5}, SetInitialLimit(10), SetLimitBounds(4, 11), SetLimitChangeHandler(func(limit int) {
+		changes = append(changes, limit)
+	}))
+
+	held := make([]ReleaseFunc, 0)
+	for i := 0; i < 6; i++ {
+		release, _ := l.Acquire(PriorityCritical)
+		held = append(held, release)
+	}
+
+	release, _ := l.Acquire(PriorityCritical)
+	release(false)
+	assert.Equal(t, 11, l.Stats().Limit)
+
+	// Max bound is kept
+	release, _ = l.Acquire(PriorityCritical)
+	release(false)
+	assert.Equal(t, 11, l.Stats().Limit)
+
+	for _, r := range held {
+		r(false)
+	}
+
+	release, _ = l.Acquire(PriorityCritical)
+	release(true)
+	assert.Equal(t, 5, l.Stats().Limit)
+
+	// Min bound is kept
+	release, _ = l.Acquire(PriorityCritical)
+	release(true)
+	assert.Equal(t, 4, l.Stats().Limit)
+
+	assert.Equal(t, []int{11, 5, 4}, changes)
+}
+
+func TestGradient_Update(t *testing.T) {
+	g := &Gradient{Smoothing: 1}
+
+	// No load latency is learned from first sample, limit grows by queue size
+	assert.Equal(t, 110, g.Update(100, Sample{RTT: 10 * time.Millisecond, InFlight: 100}))
+	assert.Equal(t, 10*time.Millisecond, g.minRTT)
+
+	// Latency doubled, limit is halved
+	assert.Equal(t, 60, g.Update(100, Sample{RTT: 20 * time.Millisecond, InFlight: 100}))
+
+	// Unused limit is kept
+	assert.Equal(t, 100, g.Update(100, Sample{RTT: 10 * time.Millisecond, InFlight: 10}))
+
+	assert.Equal(t, 50, g.Update(100, Sample{IsDropped: true}))
+}
+
+func TestLimiter_Latency(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	l := NewLimiter(AIMD{Timeout: time.Second}, SetInitialLimit(10), SetClock(func() time.Time { return now }))
+
+	release, _ := l.Acquire(PriorityNormal)
+	now = now.Add(2 * time.Second)
+	release(false)
+
+	assert.Equal(t, 9, l.Stats().Limit)
+}
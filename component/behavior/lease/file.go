@@ -0,0 +1,164 @@
+package lease
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// fileLockRetryInterval between attempts to take guard file
+	fileLockRetryInterval = 5 * time.Millisecond
+	// fileLockStaleTimeout after which guard file left by crashed process is removed
+	fileLockStaleTimeout = 10 * time.Second
+)
+
+// FileLocker keeps leases in files of the directory, it's suitable for processes running on the same host
+type FileLocker struct {
+	dir string
+}
+
+// NewFileLocker instance with leases stored in dir
+func NewFileLocker(dir string) (*FileLocker, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("unable to create lease directory: %w", err)
+	}
+
+	return &FileLocker{dir: dir}, nil
+}
+
+// Acquire lease for the key, returns ErrLeaseHeld if it's held by another owner
+func (f *FileLocker) Acquire(ctx context.Context, key, owner string, ttl time.Duration) (l Lease, err error) {
+	err = f.update(ctx, key, func(current record) (record, error) {
+		var next record
+		next, l, err = acquire(current, key, owner, ttl, time.Now())
+
+		return next, err
+	})
+
+	return l, err
+}
+
+// Renew lease for next ttl, returns ErrLeaseLost if lease expired or was taken by another owner
+func (f *FileLocker) Renew(ctx context.Context, l Lease, ttl time.Duration) (renewed Lease, err error) {
+	err = f.update(ctx, l.Key, func(current record) (record, error) {
+		var next record
+		next, renewed, err = renew(current, l, ttl, time.Now())
+
+		return next, err
+	})
+
+	return renewed, err
+}
+
+// Release lease, so it can be acquired by another owner
+func (f *FileLocker) Release(ctx context.Context, l Lease) error {
+	return f.update(ctx, l.Key, func(current record) (record, error) {
+		next, _ := release(current, l, time.Now())
+		return next, nil
+	})
+}
+
+// update lease record of the key under guard file
+func (f *FileLocker) update(ctx context.Context, key string, change func(current record) (record, error)) error {
+	leasePath := filepath.Join(f.dir, url.PathEscape(key)+".lease")
+	guardPath := leasePath + ".lock"
+
+	if err := f.lock(ctx, guardPath); err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(guardPath) }()
+
+	var current record
+	content, err := os.ReadFile(leasePath)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return fmt.Errorf("unable to read lease: %w", err)
+	default:
+		if err = json.Unmarshal(content, &current); err != nil {
+			return fmt.Errorf("corrupted lease file %s: %w", leasePath, err)
+		}
+	}
+
+	next, changeErr := change(current)
+	if next == current {
+		return changeErr
+	}
+
+	content, err = json.Marshal(next)
+	if err != nil {
+		return err
+	}
+
+	tmpPath := leasePath + ".tmp"
+	if err = os.WriteFile(tmpPath, content, 0o640); err != nil {
+		return fmt.Errorf("unable to write lease: %w", err)
+	}
+
+	if err = os.Rename(tmpPath, leasePath); err != nil {
+		return fmt.Errorf("unable to replace lease: %w", err)
+	}
+
+	return changeErr
+}
+
+// lock by exclusive creation of the guard file
+func (f *FileLocker) lock(ctx context.Context, guardPath string) error {
+	for {
+		guard, err := os.OpenFile(guardPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o640)
+		if err == nil {
+			return guard.Close()
+		}
+
+		if !errors.Is(err, os.ErrExist) {
+			return fmt.Errorf("unable to create lease guard: %w", err)
+		}
+
+		if info, statErr := os.Stat(guardPath); statErr == nil && isStale(info) && removeStaleGuard(guardPath, info) {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(fileLockRetryInterval):
+		}
+	}
+}
+
+// removeStaleGuard under breaker file, guard is removed only if it's still the same stale file,
+// so fresh guard created by another process after the check is kept
+func removeStaleGuard(guardPath string, stale os.FileInfo) bool {
+	breakerPath := guardPath + ".break"
+
+	breaker, err := os.OpenFile(breakerPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o640)
+	if err != nil {
+		// Breaker is held only for a moment, stale one is left by crashed process
+		if info, statErr := os.Stat(breakerPath); statErr == nil && isStale(info) {
+			_ = os.Remove(breakerPath)
+		}
+
+		return false
+	}
+
+	_ = breaker.Close()
+	defer func() { _ = os.Remove(breakerPath) }()
+
+	current, err := os.Stat(guardPath)
+	if err != nil || !os.SameFile(stale, current) || !isStale(current) {
+		return false
+	}
+
+	return os.Remove(guardPath) == nil
+}
+
+// isStale guard file that is older than stale timeout
+func isStale(info os.FileInfo) bool {
+	return time.Since(info.ModTime()) > fileLockStaleTimeout
+}
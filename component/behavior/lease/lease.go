@@ -0,0 +1,90 @@
+package lease
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrLeaseHeld is returned when lease is held by another owner
+	ErrLeaseHeld = errors.New("lease is held by another owner")
+	// ErrLeaseLost is returned when lease expired or was taken by another owner
+	ErrLeaseLost = errors.New("lease is lost")
+)
+
+// Lease grants exclusive ownership of the key until it expires
+type Lease struct {
+	Key   string
+	Owner string
+	// Token fencing token, it's increased each time lease is granted to new holder,
+	// protected resources must reject operations with token lower than already seen
+	Token     uint64
+	ExpiresAt time.Time
+}
+
+// IsValid if lease is not expired at given time
+func (l Lease) IsValid(now time.Time) bool {
+	return now.Before(l.ExpiresAt)
+}
+
+// Locker grants leases for keys, it could be implemented on top of etcd, Redis, Postgres etc.
+type Locker interface {
+	// Acquire lease for the key, returns ErrLeaseHeld if it's held by another owner
+	Acquire(ctx context.Context, key, owner string, ttl time.Duration) (Lease, error)
+	// Renew lease for next ttl, returns ErrLeaseLost if lease expired or was taken by another owner
+	Renew(ctx context.Context, l Lease, ttl time.Duration) (Lease, error)
+	// Release lease, so it can be acquired by another owner
+	Release(ctx context.Context, l Lease) error
+}
+
+// record of the lease in the storage
+type record struct {
+	Owner     string    `json:"owner"`
+	Token     uint64    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// acquire lease from current record, same owner will keep its token
+func acquire(current record, key, owner string, ttl time.Duration, now time.Time) (record, Lease, error) {
+	if current.Owner != owner && now.Before(current.ExpiresAt) {
+		return current, Lease{}, ErrLeaseHeld
+	}
+
+	next := record{Owner: owner, Token: current.Token, ExpiresAt: now.Add(ttl)}
+	if current.Owner != owner || !now.Before(current.ExpiresAt) {
+		next.Token++
+	}
+
+	return next, next.toLease(key), nil
+}
+
+// renew lease if it's still held by the same owner and token
+func renew(current record, l Lease, ttl time.Duration, now time.Time) (record, Lease, error) {
+	if !current.isHeldBy(l, now) {
+		return current, Lease{}, ErrLeaseLost
+	}
+
+	current.ExpiresAt = now.Add(ttl)
+
+	return current, current.toLease(l.Key), nil
+}
+
+// release lease if it's still held, token is kept to be increased for next owner
+func release(current record, l Lease, now time.Time) (record, bool) {
+	if !current.isHeldBy(l, now) {
+		return current, false
+	}
+
+	current.ExpiresAt = time.Time{}
+
+	return current, true
+}
+
+func (r record) isHeldBy(l Lease, now time.Time) bool {
+	return r.Owner == l.Owner && r.Token == l.Token && now.Before(r.ExpiresAt)
+}
+
+func (r record) toLease(key string) Lease {
+	return Lease{Key: key, Owner: r.Owner, Token: r.Token, ExpiresAt: r.ExpiresAt}
+}
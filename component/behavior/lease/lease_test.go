@@ -0,0 +1,132 @@
+package lease
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testLockers(t *testing.T) map[string]Locker {
+	fileLocker, err := NewFileLocker(t.TempDir())
+	assert.NoError(t, err)
+
+	return map[string]Locker{
+		"memory": NewMemoryLocker(),
+		"file":   fileLocker,
+	}
+}
+
+func TestLocker_AcquireRenewRelease(t *testing.T) {
+	ctx := context.Background()
+
+	for name, locker := range testLockers(t) {
+		t.Run(name, func(t *testing.T) {
+			first, err := locker.Acquire(ctx, "nightly", "replica-a", time.Minute)
+			assert.NoError(t, err)
+			assert.Equal(t, uint64(1), first.Token)
+			assert.True(t, first.IsValid(time.Now()))
+
+			_, err = locker.Acquire(ctx, "nightly", "replica-b", time.Minute)
+			assert.ErrorIs(t, err, ErrLeaseHeld)
+
+			// Same owner keeps fencing token
+			again, err := locker.Acquire(ctx, "nightly", "replica-a", time.Minute)
+			assert.NoError(t, err)
+			assert.Equal(t, first.Token, again.Token)
+
+			renewed, err := locker.Renew(ctx, again, 2*time.Minute)
+			assert.NoError(t, err)
+			assert.True(t, renewed.ExpiresAt.After(first.ExpiresAt))
+
+			assert.NoError(t, locker.Release(ctx, renewed))
+			_, err = locker.Renew(ctx, renewed, time.Minute)
+			assert.ErrorIs(t, err, ErrLeaseLost)
+
+			second, err := locker.Acquire(ctx, "nightly", "replica-b", time.Minute)
+			assert.NoError(t, err)
+			assert.Equal(t, uint64(2), second.Token)
+		})
+	}
+}
+
+func TestLocker_Expiration(t *testing.T) {
+	ctx := context.Background()
+
+	for name, locker := range testLockers(t) {
+		t.Run(name, func(t *testing.T) {
+			first, err := locker.Acquire(ctx, "nightly", "replica-a", 10*time.Millisecond)
+			assert.NoError(t, err)
+
+			time.Sleep(20 * time.Millisecond)
+
+			second, err := locker.Acquire(ctx, "nightly", "replica-b", time.Minute)
+			assert.NoError(t, err)
+			assert.Greater(t, second.Token, first.Token)
+
+			_, err = locker.Renew(ctx, first, time.Minute)
+			assert.ErrorIs(t, err, ErrLeaseLost)
+		})
+	}
+}
+
+func TestLocker_SingleHolder(t *testing.T) {
+	ctx := context.Background()
+
+	for name, locker := range testLockers(t) {
+		t.Run(name, func(t *testing.T) {
+			var wg sync.WaitGroup
+			var holders atomic.Int32
+
+			for i := 0; i < 10; i++ {
+				wg.Add(1)
+				go func(owner int) {
+					defer wg.Done()
+
+					if _, err := locker.Acquire(ctx, "nightly", string(rune('a'+owner)), time.Minute); err == nil {
+						holders.Add(1)
+					}
+				}(i)
+			}
+
+			wg.Wait()
+			assert.Equal(t, int32(1), holders.Load())
+		})
+	}
+}
+
+func TestFileLocker_StaleGuard(t *testing.T) {
+	dir := t.TempDir()
+	guardPath := filepath.Join(dir, "nightly.lease.lock")
+	staleAt := time.Now().Add(-2 * fileLockStaleTimeout)
+
+	assert.NoError(t, os.WriteFile(guardPath, nil, 0o640))
+	assert.NoError(t, os.Chtimes(guardPath, staleAt, staleAt))
+	stale, err := os.Stat(guardPath)
+	assert.NoError(t, err)
+
+	// Another process broke the stale guard and created a fresh one, it must be kept
+	assert.NoError(t, os.Remove(guardPath))
+	assert.NoError(t, os.WriteFile(guardPath, nil, 0o640))
+	assert.False(t, removeStaleGuard(guardPath, stale))
+	assert.FileExists(t, guardPath)
+
+	// Guard left by crashed process is removed, so lease can be acquired
+	assert.NoError(t, os.Chtimes(guardPath, staleAt, staleAt))
+
+	locker, err := NewFileLocker(dir)
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	_, err = locker.Acquire(ctx, "nightly", "replica-a", time.Minute)
+	assert.NoError(t, err)
+	assert.NoFileExists(t, guardPath)
+	assert.NoFileExists(t, guardPath+".break")
+}
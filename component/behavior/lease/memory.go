@@ -0,0 +1,52 @@
+package lease
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryLocker keeps leases in memory, useful for tests and single process setups
+type MemoryLocker struct {
+	mu     sync.Mutex
+	leases map[string]record
+}
+
+// NewMemoryLocker instance
+func NewMemoryLocker() *MemoryLocker {
+	return &MemoryLocker{leases: map[string]record{}}
+}
+
+// Acquire lease for the key, returns ErrLeaseHeld if it's held by another owner
+func (m *MemoryLocker) Acquire(_ context.Context, key, owner string, ttl time.Duration) (Lease, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	next, l, err := acquire(m.leases[key], key, owner, ttl, time.Now())
+	m.leases[key] = next
+
+	return l, err
+}
+
+// Renew lease for next ttl, returns ErrLeaseLost if lease expired or was taken by another owner
+func (m *MemoryLocker) Renew(_ context.Context, l Lease, ttl time.Duration) (Lease, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	next, renewed, err := renew(m.leases[l.Key], l, ttl, time.Now())
+	m.leases[l.Key] = next
+
+	return renewed, err
+}
+
+// Release lease, so it can be acquired by another owner
+func (m *MemoryLocker) Release(_ context.Context, l Lease) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if next, isReleased := release(m.leases[l.Key], l, time.Now()); isReleased {
+		m.leases[l.Key] = next
+	}
+
+	return nil
+}
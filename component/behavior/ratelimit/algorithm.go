@@ -0,0 +1,70 @@
+package ratelimit
+
+import "time"
+
+// tokenBucket takes one token from the bucket that is refilled since last request
+func tokenBucket(s State, isExist bool, lim Limit, now time.Time) (State, Result) {
+	capacity := float64(lim.Burst)
+	if !isExist {
+		s = State{Tokens: capacity, At: now}
+	}
+
+	if elapsed := now.Sub(s.At); elapsed > 0 {
+		s.Tokens += float64(elapsed) / float64(lim.interval())
+		if s.Tokens > capacity {
+			s.Tokens = capacity
+		}
+
+		s.At = now
+	}
+
+	if s.Tokens < 1 {
+		return s, Result{RetryAfter: time.Duration((1 - s.Tokens) * float64(lim.interval()))}
+	}
+
+	s.Tokens--
+
+	return s, Result{IsAllowed: true, Remaining: int(s.Tokens)}
+}
+
+// slidingWindowLog allows request if there are fewer than Rate requests in the log within last Period
+func slidingWindowLog(s State, lim Limit, now time.Time) (State, Result) {
+	windowStart := now.Add(-lim.Period)
+
+	kept := s.Log[:0]
+	for _, at := range s.Log {
+		if at.After(windowStart) {
+			kept = append(kept, at)
+		}
+	}
+
+	s.Log = kept
+	if len(s.Log) >= lim.Rate {
+		return s, Result{RetryAfter: s.Log[len(s.Log)-lim.Rate].Sub(windowStart)}
+	}
+
+	s.Log = append(s.Log, now)
+
+	return s, Result{IsAllowed: true, Remaining: lim.Rate - len(s.Log)}
+}
+
+// gcra allows request if its theoretical arrival time is within burst tolerance, At is theoretical arrival time
+func gcra(s State, isExist bool, lim Limit, now time.Time) (State, Result) {
+	interval := lim.interval()
+	tolerance := interval * time.Duration(lim.Burst)
+
+	tat := s.At
+	if !isExist || tat.Before(now) {
+		tat = now
+	}
+
+	nextTAT := tat.Add(interval)
+	allowAt := nextTAT.Add(-tolerance)
+	if now.Before(allowAt) {
+		return s, Result{RetryAfter: allowAt.Sub(now)}
+	}
+
+	s.At = nextTAT
+
+	return s, Result{IsAllowed: true, Remaining: int(now.Sub(allowAt) / interval)}
+}
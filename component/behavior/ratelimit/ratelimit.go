@@ -0,0 +1,138 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrInvalidLimit is returned when limit has no rate or period, or rate is higher than one request per nanosecond
+var ErrInvalidLimit = errors.New("invalid rate limit")
+
+// Algorithm of rate limiting
+type Algorithm byte
+
+const (
+	// TokenBucket refills bucket of Burst tokens with Rate tokens per Period, each request takes one token
+	TokenBucket Algorithm = iota
+	// SlidingWindowLog keeps timestamps of requests and allows at most Rate requests within any Period
+	SlidingWindowLog
+	// GCRA generic cell rate algorithm, it behaves like token bucket but keeps only theoretical arrival time
+	GCRA
+)
+
+type (
+	// Limit of requests, for example Rate 100 and Period time.Minute, Burst is equal to Rate if it's not set
+	Limit struct {
+		Rate   int
+		Period time.Duration
+		Burst  int
+	}
+
+	// Result of the rate limit check
+	Result struct {
+		IsAllowed bool
+		// Remaining requests that are allowed right now
+		Remaining int
+		// RetryAfter duration when next request could be allowed, zero if request is allowed
+		RetryAfter time.Duration
+	}
+
+	// Limiter checks requests of the keys against the limit
+	Limiter struct {
+		algorithm Algorithm
+		limit     Limit
+		store     Store
+		now       func() time.Time
+	}
+
+	// Option for limiter configuration
+	Option func(l *Limiter)
+)
+
+// SetStore where state of the keys is kept, MemoryStore is used by default
+func SetStore(s Store) Option {
+	return func(l *Limiter) {
+		l.store = s
+	}
+}
+
+// SetClock used to calculate limits
+func SetClock(now func() time.Time) Option {
+	return func(l *Limiter) {
+		l.now = now
+	}
+}
+
+// NewLimiter with algorithm and limit
+func NewLimiter(algorithm Algorithm, limit Limit, opts ...Option) (*Limiter, error) {
+	if limit.Rate <= 0 || limit.Period <= 0 || limit.Burst < 0 {
+		return nil, ErrInvalidLimit
+	}
+
+	// Algorithms distribute requests evenly with interval that can't be shorter than nanosecond
+	if limit.interval() == 0 {
+		return nil, fmt.Errorf("%w: rate %d exceeds resolution of period %s", ErrInvalidLimit, limit.Rate, limit.Period)
+	}
+
+	if limit.Burst == 0 {
+		limit.Burst = limit.Rate
+	}
+
+	l := &Limiter{
+		algorithm: algorithm,
+		limit:     limit,
+		now:       time.Now,
+	}
+
+	for _, o := range opts {
+		o(l)
+	}
+
+	if l.store == nil {
+		l.store = NewMemoryStore()
+	}
+
+	return l, nil
+}
+
+// Allow request of the key, denied requests are not counted against the limit
+func (l *Limiter) Allow(ctx context.Context, key string) (Result, error) {
+	var res Result
+
+	now := l.now()
+	err := l.store.Update(ctx, key, now, l.ttl(), func(s State, isExist bool) State {
+		switch l.algorithm {
+		case SlidingWindowLog:
+			s, res = slidingWindowLog(s, l.limit, now)
+		case GCRA:
+			s, res = gcra(s, isExist, l.limit, now)
+		default:
+			s, res = tokenBucket(s, isExist, l.limit, now)
+		}
+
+		return s
+	})
+
+	return res, err
+}
+
+// Limit of the limiter
+func (l *Limiter) Limit() Limit {
+	return l.limit
+}
+
+// ttl of the key state, after it state of the key is the same as for new key
+func (l *Limiter) ttl() time.Duration {
+	if l.algorithm == SlidingWindowLog {
+		return l.limit.Period
+	}
+
+	return l.limit.interval() * time.Duration(l.limit.Burst)
+}
+
+// interval between requests when rate is evenly distributed within period
+func (lim Limit) interval() time.Duration {
+	return lim.Period / time.Duration(lim.Rate)
+}
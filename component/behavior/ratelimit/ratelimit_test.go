@@ -0,0 +1,137 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	return c.now
+}
+
+func (c *testClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func TestLimiter_Algorithms(t *testing.T) {
+	algorithms := map[string]Algorithm{
+		"token bucket":       TokenBucket,
+		"sliding window log": SlidingWindowLog,
+		"gcra":               GCRA,
+	}
+
+	for name, algorithm := range algorithms {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			clock := &testClock{now: time.Unix(1700000000, 0)}
+
+			l, err := NewLimiter(algorithm, Limit{Rate: 3, Period: 3 * time.Second}, SetClock(clock.Now))
+			assert.NoError(t, err)
+
+			for i := 2; i >= 0; i-- {
+				res, allowErr := l.Allow(ctx, "tenant-a")
+				assert.NoError(t, allowErr)
+				assert.True(t, res.IsAllowed)
+				assert.Equal(t, i, res.Remaining)
+			}
+
+			res, err := l.Allow(ctx, "tenant-a")
+			assert.NoError(t, err)
+			assert.False(t, res.IsAllowed)
+			assert.Greater(t, res.RetryAfter, time.Duration(0))
+			assert.LessOrEqual(t, res.RetryAfter, 3*time.Second)
+
+			// Other keys are not affected
+			res, err = l.Allow(ctx, "tenant-b")
+			assert.NoError(t, err)
+			assert.True(t, res.IsAllowed)
+
+			clock.Advance(res.RetryAfter + 3*time.Second)
+			res, err = l.Allow(ctx, "tenant-a")
+			assert.NoError(t, err)
+			assert.True(t, res.IsAllowed)
+		})
+	}
+}
+
+func TestLimiter_RetryAfter(t *testing.T) {
+	ctx := context.Background()
+	clock := &testClock{now: time.Unix(1700000000, 0)}
+
+	// Sliding window log waits until oldest request leaves the window
+	l, err := NewLimiter(SlidingWindowLog, Limit{Rate: 2, Period: 10 * time.Second}, SetClock(clock.Now))
+	assert.NoError(t, err)
+
+	_, _ = l.Allow(ctx, "k")
+	clock.Advance(4 * time.Second)
+	_, _ = l.Allow(ctx, "k")
+
+	res, _ := l.Allow(ctx, "k")
+	assert.False(t, res.IsAllowed)
+	assert.Equal(t, 6*time.Second, res.RetryAfter)
+
+	clock.Advance(res.RetryAfter)
+	res, _ = l.Allow(ctx, "k")
+	assert.True(t, res.IsAllowed)
+
+	// Token bucket and GCRA refill one request per interval
+	for _, algorithm := range []Algorithm{TokenBucket, GCRA} {
+		l, err = NewLimiter(algorithm, Limit{Rate: 1, Period: time.Second, Burst: 2}, SetClock(clock.Now))
+		assert.NoError(t, err)
+
+		_, _ = l.Allow(ctx, "k")
+		_, _ = l.Allow(ctx, "k")
+		clock.Advance(250 * time.Millisecond)
+
+		res, _ = l.Allow(ctx, "k")
+		assert.False(t, res.IsAllowed)
+		assert.Equal(t, 750*time.Millisecond, res.RetryAfter)
+
+		clock.Advance(res.RetryAfter)
+		res, _ = l.Allow(ctx, "k")
+		assert.True(t, res.IsAllowed)
+		assert.Equal(t, 0, res.Remaining)
+	}
+}
+
+func TestNewLimiter_InvalidLimit(t *testing.T) {
+	_, err := NewLimiter(TokenBucket, Limit{Rate: 0, Period: time.Second})
+	assert.ErrorIs(t, err, ErrInvalidLimit)
+
+	_, err = NewLimiter(GCRA, Limit{Rate: 1})
+	assert.ErrorIs(t, err, ErrInvalidLimit)
+
+	// Interval between requests would be zero
+	_, err = NewLimiter(TokenBucket, Limit{Rate: 1000, Period: time.Microsecond / 2})
+	assert.ErrorIs(t, err, ErrInvalidLimit)
+}
+
+func TestMemoryStore_Expiration(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore()
+	now := time.Now()
+
+	update := func(key string) (isExist bool) {
+		assert.NoError(t, s.Update(ctx, key, now, 10*time.Millisecond, func(st State, exist bool) State {
+			isExist = exist
+			return st
+		}))
+
+		return isExist
+	}
+
+	assert.False(t, update("a"))
+	assert.True(t, update("a"))
+
+	// Expiration follows clock of the limiter, not wall clock
+	now = now.Add(20 * time.Millisecond)
+	assert.False(t, update("b"))
+	assert.Equal(t, 1, s.Len())
+}
@@ -0,0 +1,82 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type (
+	// State of the key, each algorithm uses own fields
+	State struct {
+		// Tokens left in the bucket
+		Tokens float64 `json:"tokens,omitempty"`
+		// At time of last refill for token bucket or theoretical arrival time for GCRA
+		At time.Time `json:"at,omitempty"`
+		// Log of allowed requests for sliding window
+		Log []time.Time `json:"log,omitempty"`
+	}
+
+	// Store of limiter state per key, limiters of all replicas that share the store enforce one limit
+	Store interface {
+		// Update state of the key atomically at now of limiter clock, state is removed after ttl since last update
+		Update(ctx context.Context, key string, now time.Time, ttl time.Duration, fn func(s State, isExist bool) State) error
+	}
+
+	// memoryRecord of the key state
+	memoryRecord struct {
+		state     State
+		expiresAt time.Time
+	}
+)
+
+// MemoryStore keeps limiter state in process memory, so each replica has own limit
+type MemoryStore struct {
+	mu        sync.Mutex
+	records   map[string]memoryRecord
+	lastSweep time.Time
+}
+
+// NewMemoryStore instance
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{records: map[string]memoryRecord{}}
+}
+
+// Update state of the key atomically, expired keys are removed from time to time
+func (m *MemoryStore) Update(_ context.Context, key string, now time.Time, ttl time.Duration, fn func(s State, isExist bool) State) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sweep(now, ttl)
+
+	r, isExist := m.records[key]
+	if isExist && !now.Before(r.expiresAt) {
+		r, isExist = memoryRecord{}, false
+	}
+
+	m.records[key] = memoryRecord{state: fn(r.state, isExist), expiresAt: now.Add(ttl)}
+
+	return nil
+}
+
+// Len of the keys in the store including expired ones that are not removed yet
+func (m *MemoryStore) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return len(m.records)
+}
+
+// sweep expired records not more often than once per ttl
+func (m *MemoryStore) sweep(now time.Time, ttl time.Duration) {
+	if now.Sub(m.lastSweep) < ttl {
+		return
+	}
+
+	m.lastSweep = now
+	for key, r := range m.records {
+		if !now.Before(r.expiresAt) {
+			delete(m.records, key)
+		}
+	}
+}
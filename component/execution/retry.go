@@ -1,6 +1,8 @@
 package execution
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"time"
 )
@@ -18,3 +20,26 @@ func RunWithRetry(attempts uint, backoff time.Duration, exec func() error) (exec
 
 	return fmt.Errorf("failed to execute function in (%d) attempts, last error: %s", attempts, execErr)
 }
+
+// RunWithRetryContext of function with backoff between attempts, waiting stops once context is done
+func RunWithRetryContext(ctx context.Context, attempts uint, backoff time.Duration, exec func(ctx context.Context) error) (execErr error) {
+	for i := uint(0); i < attempts; i++ {
+		if execErr = exec(ctx); execErr == nil {
+			return nil
+		}
+
+		if i+1 == attempts {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("execution stopped after (%d) attempts: %w", i+1, errors.Join(ctx.Err(), execErr))
+		case <-time.After(backoff):
+		}
+
+		backoff <<= 2
+	}
+
+	return fmt.Errorf("failed to execute function in (%d) attempts, last error: %w", attempts, execErr)
+}
@@ -1,6 +1,8 @@
 package execution
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"testing"
 	"time"
@@ -8,6 +10,8 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+var errUnit = errors.New("unit error")
+
 func TestRunWithRetry(t *testing.T) {
 	execTimes := 0
 	unitFunc := func() error {
@@ -25,3 +29,37 @@ func TestRunWithRetry(t *testing.T) {
 		"expected no error from function on 2 attempt",
 	)
 }
+
+func TestRunWithRetryContext(t *testing.T) {
+	execTimes := 0
+	unitFunc := func(ctx context.Context) error {
+		execTimes++
+		if execTimes == 3 {
+			return nil
+		}
+
+		return errUnit
+	}
+
+	assert.NoError(t, RunWithRetryContext(context.Background(), 3, time.Nanosecond, unitFunc))
+	assert.Equal(t, 3, execTimes)
+
+	execTimes = 0
+	execErr := RunWithRetryContext(context.Background(), 2, time.Nanosecond, func(ctx context.Context) error {
+		execTimes++
+		return errUnit
+	})
+	assert.ErrorIs(t, execErr, errUnit)
+	assert.Equal(t, 2, execTimes)
+}
+
+func TestRunWithRetryContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	execErr := RunWithRetryContext(ctx, 5, time.Hour, func(ctx context.Context) error {
+		return errUnit
+	})
+	assert.ErrorIs(t, execErr, context.Canceled)
+	assert.ErrorIs(t, execErr, errUnit)
+}
@@ -0,0 +1,380 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Clink-n-Clank/Brokkr/component/execution"
+)
+
+var (
+	// ErrDuplicateStep is returned when two steps have the same name
+	ErrDuplicateStep = errors.New("workflow step is duplicated")
+	// ErrUnknownDependency is returned when step depends on step that is not in workflow
+	ErrUnknownDependency = errors.New("workflow step depends on unknown step")
+	// ErrMissingHandler is returned when step has no handler
+	ErrMissingHandler = errors.New("workflow step has no handler")
+	// ErrCycle is returned when steps dependencies have a cycle
+	ErrCycle = errors.New("workflow steps have dependency cycle")
+	// ErrStepFailed is returned when one or more steps failed
+	ErrStepFailed = errors.New("workflow step failed")
+)
+
+// StepStatus of execution
+type StepStatus byte
+
+const (
+	StepPending StepStatus = iota
+	StepSucceeded
+	StepFailed
+	StepSkipped
+	StepCanceled
+)
+
+// String implements stringer interface.
+func (s StepStatus) String() string {
+	switch s {
+	case StepPending:
+		return "Pending"
+	case StepSucceeded:
+		return "Succeeded"
+	case StepFailed:
+		return "Failed"
+	case StepSkipped:
+		return "Skipped"
+	case StepCanceled:
+		return "Canceled"
+	default:
+		return fmt.Sprintf("unknown status: %d", s)
+	}
+}
+
+type (
+	// Step of the workflow, it's executed when all dependencies are succeeded
+	Step struct {
+		Name      string
+		DependsOn []string
+		// Timeout for single attempt, no timeout if not set
+		Timeout time.Duration
+		// Attempts to execute step, one attempt if not set
+		Attempts uint
+		// Backoff between attempts
+		Backoff time.Duration
+		Handler func(ctx context.Context) error
+	}
+
+	// StepResult of step execution
+	StepResult struct {
+		Status    StepStatus
+		Err       error
+		StartedAt time.Time
+		Duration  time.Duration
+	}
+
+	// Result of workflow execution by step name
+	Result map[string]StepResult
+
+	// Workflow DAG of steps executed with bounded parallelism
+	Workflow struct {
+		name        string
+		parallelism int
+		steps       []Step
+	}
+
+	// Option for workflow configuration
+	Option func(w *Workflow)
+)
+
+// SetParallelism maximum number of steps executed at the same time
+func SetParallelism(n int) Option {
+	return func(w *Workflow) {
+		w.parallelism = n
+	}
+}
+
+// NewWorkflow a new instance
+func NewWorkflow(name string, opts ...Option) *Workflow {
+	w := &Workflow{name: name, parallelism: 1}
+
+	for _, o := range opts {
+		o(w)
+	}
+
+	if w.parallelism < 1 {
+		w.parallelism = 1
+	}
+
+	return w
+}
+
+// GetName of the workflow
+func (w *Workflow) GetName() string {
+	return w.name
+}
+
+// AddStep to the workflow
+func (w *Workflow) AddStep(s Step) *Workflow {
+	w.steps = append(w.steps, s)
+	return w
+}
+
+// AddChain of steps where each step depends on previous one
+func (w *Workflow) AddChain(steps ...Step) *Workflow {
+	for i, s := range steps {
+		if i > 0 {
+			s.DependsOn = append(append([]string{}, s.DependsOn...), steps[i-1].Name)
+		}
+
+		w.AddStep(s)
+	}
+
+	return w
+}
+
+// Validate that steps are unique and have handlers, dependencies exist and there is no cycle
+func (w *Workflow) Validate() error {
+	byName := make(map[string]Step, len(w.steps))
+	for _, s := range w.steps {
+		if _, exist := byName[s.Name]; exist {
+			return fmt.Errorf("%w: %s", ErrDuplicateStep, s.Name)
+		}
+
+		if s.Handler == nil {
+			return fmt.Errorf("%w: %s", ErrMissingHandler, s.Name)
+		}
+
+		byName[s.Name] = s
+	}
+
+	for _, s := range w.steps {
+		for _, dep := range s.DependsOn {
+			if _, exist := byName[dep]; !exist {
+				return fmt.Errorf("%w: %s -> %s", ErrUnknownDependency, s.Name, dep)
+			}
+		}
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	marks := make(map[string]int, len(w.steps))
+
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch marks[name] {
+		case visiting:
+			return fmt.Errorf("%w: %s", ErrCycle, name)
+		case visited:
+			return nil
+		}
+
+		marks[name] = visiting
+		for _, dep := range byName[name].DependsOn {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		marks[name] = visited
+
+		return nil
+	}
+
+	for _, s := range w.steps {
+		if err := visit(s.Name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Handler of the workflow that can be used as handler of task.BackgroundTask
+func (w *Workflow) Handler() func() error {
+	return func() error {
+		_, err := w.Run(context.Background())
+		return err
+	}
+}
+
+// Run workflow, failed step stops its downstream steps while independent steps are still executed
+func (w *Workflow) Run(ctx context.Context) (Result, error) {
+	if err := w.Validate(); err != nil {
+		return nil, err
+	}
+
+	run := newWorkflowRun(w)
+	run.start(ctx)
+
+	return run.result, run.err()
+}
+
+type (
+	// workflowRun state of single workflow execution
+	workflowRun struct {
+		workflow   *Workflow
+		dependents map[string][]string
+		waitingFor map[string]int
+		result     Result
+
+		finished chan stepFinished
+		slots    chan struct{}
+		running  sync.WaitGroup
+	}
+
+	// stepFinished event from step executor
+	stepFinished struct {
+		name   string
+		result StepResult
+	}
+)
+
+func newWorkflowRun(w *Workflow) *workflowRun {
+	run := &workflowRun{
+		workflow:   w,
+		dependents: map[string][]string{},
+		waitingFor: map[string]int{},
+		result:     Result{},
+		finished:   make(chan stepFinished),
+		slots:      make(chan struct{}, w.parallelism),
+	}
+
+	for _, s := range w.steps {
+		run.result[s.Name] = StepResult{Status: StepPending}
+		run.waitingFor[s.Name] = len(s.DependsOn)
+
+		for _, dep := range s.DependsOn {
+			run.dependents[dep] = append(run.dependents[dep], s.Name)
+		}
+	}
+
+	return run
+}
+
+// start steps which dependencies are done and wait until all steps are finished
+func (r *workflowRun) start(ctx context.Context) {
+	pending := len(r.workflow.steps)
+
+	for _, s := range r.workflow.steps {
+		if r.waitingFor[s.Name] == 0 {
+			r.execute(ctx, s)
+		}
+	}
+
+	for pending > 0 {
+		f := <-r.finished
+		r.result[f.name] = f.result
+		pending--
+
+		for _, next := range r.dependents[f.name] {
+			if f.result.Status != StepSucceeded {
+				pending -= r.skipDownstream(next)
+				continue
+			}
+
+			r.waitingFor[next]--
+			if r.waitingFor[next] == 0 && r.result[next].Status == StepPending {
+				r.execute(ctx, r.step(next))
+			}
+		}
+	}
+
+	r.running.Wait()
+}
+
+// execute step in the background when slot is available
+func (r *workflowRun) execute(ctx context.Context, s Step) {
+	r.running.Add(1)
+
+	go func() {
+		defer r.running.Done()
+
+		select {
+		case r.slots <- struct{}{}:
+			defer func() { <-r.slots }()
+		case <-ctx.Done():
+			r.finished <- stepFinished{name: s.Name, result: StepResult{Status: StepCanceled, Err: ctx.Err()}}
+			return
+		}
+
+		if ctx.Err() != nil {
+			r.finished <- stepFinished{name: s.Name, result: StepResult{Status: StepCanceled, Err: ctx.Err()}}
+			return
+		}
+
+		res := StepResult{Status: StepSucceeded, StartedAt: time.Now()}
+		res.Err = runStep(ctx, s)
+		res.Duration = time.Since(res.StartedAt)
+
+		if res.Err != nil {
+			res.Status = StepFailed
+		}
+
+		r.finished <- stepFinished{name: s.Name, result: res}
+	}()
+}
+
+// skipDownstream marks step and all its dependents as skipped, returns number of newly skipped steps
+func (r *workflowRun) skipDownstream(name string) int {
+	if r.result[name].Status != StepPending {
+		return 0
+	}
+
+	r.result[name] = StepResult{Status: StepSkipped}
+	skipped := 1
+
+	for _, next := range r.dependents[name] {
+		skipped += r.skipDownstream(next)
+	}
+
+	return skipped
+}
+
+func (r *workflowRun) step(name string) Step {
+	for _, s := range r.workflow.steps {
+		if s.Name == name {
+			return s
+		}
+	}
+
+	return Step{}
+}
+
+// err joined from failed and canceled steps
+func (r *workflowRun) err() error {
+	var errs []error
+	for _, s := range r.workflow.steps {
+		res := r.result[s.Name]
+		if res.Status == StepFailed || res.Status == StepCanceled {
+			errs = append(errs, fmt.Errorf("%w %s: %w", ErrStepFailed, s.Name, res.Err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// runStep with retries and timeout for each attempt
+func runStep(ctx context.Context, s Step) error {
+	attempts := s.Attempts
+	if attempts == 0 {
+		attempts = 1
+	}
+
+	return execution.RunWithRetryContext(ctx, attempts, s.Backoff, func(ctx context.Context) error {
+		if s.Timeout <= 0 {
+			return s.Handler(ctx)
+		}
+
+		attemptCtx, attemptCtxCancel := context.WithTimeout(ctx, s.Timeout)
+		defer attemptCtxCancel()
+
+		return execution.RunWithTimeout(attemptCtx, s.Timeout, func() error {
+			return s.Handler(attemptCtx)
+		})
+	})
+}
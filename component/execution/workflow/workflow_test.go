@@ -0,0 +1,143 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkflow_RunETL(t *testing.T) {
+	var mu sync.Mutex
+	var order []string
+	var running, maxRunning atomic.Int32
+
+	step := func(name string, deps ...string) Step {
+		return Step{Name: name, DependsOn: deps, Handler: func(context.Context) error {
+			n := running.Add(1)
+			for current := maxRunning.Load(); n > current && !maxRunning.CompareAndSwap(current, n); {
+				current = maxRunning.Load()
+			}
+			defer running.Add(-1)
+
+			time.Sleep(10 * time.Millisecond)
+
+			mu.Lock()
+			order = append(order, name)
+			mu.Unlock()
+
+			return nil
+		}}
+	}
+
+	w := NewWorkflow("etl", SetParallelism(2)).
+		AddStep(step("extract")).
+		AddStep(step("transform-a", "extract")).
+		AddStep(step("transform-b", "extract")).
+		AddStep(step("load", "transform-a", "transform-b"))
+
+	res, err := w.Run(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "etl", w.GetName())
+	assert.Equal(t, int32(2), maxRunning.Load())
+	assert.Equal(t, "extract", order[0])
+	assert.Equal(t, "load", order[3])
+
+	for _, name := range []string{"extract", "transform-a", "transform-b", "load"} {
+		assert.Equal(t, StepSucceeded, res[name].Status, name)
+	}
+}
+
+func TestWorkflow_FailureStopsDownstream(t *testing.T) {
+	stepErr := errors.New("unit failure")
+	attempts := 0
+	noop := func(context.Context) error { return nil }
+
+	w := NewWorkflow("etl", SetParallelism(4)).
+		AddStep(Step{Name: "extract", Handler: noop}).
+		AddStep(Step{Name: "transform-a", DependsOn: []string{"extract"}, Attempts: 2, Handler: func(context.Context) error {
+			attempts++
+			return stepErr
+		}}).
+		AddStep(Step{Name: "transform-b", DependsOn: []string{"extract"}, Handler: noop}).
+		AddStep(Step{Name: "load", DependsOn: []string{"transform-a", "transform-b"}, Handler: noop}).
+		AddStep(Step{Name: "notify", DependsOn: []string{"load"}, Handler: noop})
+
+	res, err := w.Run(context.Background())
+	assert.ErrorIs(t, err, ErrStepFailed)
+	assert.ErrorIs(t, err, stepErr)
+	assert.Equal(t, 2, attempts)
+
+	assert.Equal(t, StepSucceeded, res["extract"].Status)
+	assert.Equal(t, StepFailed, res["transform-a"].Status)
+	assert.Equal(t, StepSucceeded, res["transform-b"].Status)
+	assert.Equal(t, StepSkipped, res["load"].Status)
+	assert.Equal(t, StepSkipped, res["notify"].Status)
+}
+
+func TestWorkflow_ChainAndTimeout(t *testing.T) {
+	var order []string
+	record := func(name string) func(context.Context) error {
+		return func(context.Context) error {
+			order = append(order, name)
+			return nil
+		}
+	}
+
+	w := NewWorkflow("chain").AddChain(
+		Step{Name: "first", Handler: record("first")},
+		Step{Name: "second", Handler: record("second")},
+		Step{Name: "slow", Timeout: 10 * time.Millisecond, Handler: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}},
+		Step{Name: "never", Handler: record("never")},
+	)
+
+	res, err := w.Run(context.Background())
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, []string{"first", "second"}, order)
+	assert.Equal(t, StepFailed, res["slow"].Status)
+	assert.Equal(t, StepSkipped, res["never"].Status)
+	assert.Error(t, w.Handler()())
+}
+
+func TestWorkflow_Validate(t *testing.T) {
+	noop := func(context.Context) error { return nil }
+
+	_, err := NewWorkflow("dup").AddStep(Step{Name: "a", Handler: noop}).AddStep(Step{Name: "a", Handler: noop}).Run(context.Background())
+	assert.ErrorIs(t, err, ErrDuplicateStep)
+
+	_, err = NewWorkflow("unknown").AddStep(Step{Name: "a", DependsOn: []string{"b"}, Handler: noop}).Run(context.Background())
+	assert.ErrorIs(t, err, ErrUnknownDependency)
+
+	_, err = NewWorkflow("handler").AddStep(Step{Name: "a"}).Run(context.Background())
+	assert.ErrorIs(t, err, ErrMissingHandler)
+
+	_, err = NewWorkflow("cycle").
+		AddStep(Step{Name: "a", DependsOn: []string{"c"}, Handler: noop}).
+		AddStep(Step{Name: "b", DependsOn: []string{"a"}, Handler: noop}).
+		AddStep(Step{Name: "c", DependsOn: []string{"b"}, Handler: noop}).
+		Run(context.Background())
+	assert.ErrorIs(t, err, ErrCycle)
+}
+
+func TestWorkflow_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	res, err := NewWorkflow("canceled").
+		AddChain(
+			Step{Name: "a", Handler: func(context.Context) error { return nil }},
+			Step{Name: "b", Handler: func(context.Context) error { return nil }},
+		).
+		Run(ctx)
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, StepCanceled, res["a"].Status)
+	assert.Equal(t, StepSkipped, res["b"].Status)
+}
@@ -0,0 +1,28 @@
+package redact
+
+import "regexp"
+
+// Value that replaces sensitive data in logs and dumps
+const Value = "<sensitive>"
+
+// Patterns of sensitive data
+type Patterns []*regexp.Regexp
+
+// MustCompilePatterns of regular expressions, panics on invalid expression
+func MustCompilePatterns(exprs ...string) Patterns {
+	p := make(Patterns, 0, len(exprs))
+	for _, expr := range exprs {
+		p = append(p, regexp.MustCompile(expr))
+	}
+
+	return p
+}
+
+// String with all matches of patterns replaced by Value
+func (p Patterns) String(s string) string {
+	for _, re := range p {
+		s = re.ReplaceAllString(s, Value)
+	}
+
+	return s
+}
@@ -0,0 +1,15 @@
+package redact
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPatterns_String(t *testing.T) {
+	p := MustCompilePatterns(`\d{4}-\d{4}`, `token=\w+`)
+
+	assert.Equal(t, "card <sensitive>, <sensitive>&id=1", p.String("card 1234-5678, token=abc&id=1"))
+	assert.Equal(t, "nothing to hide", Patterns(nil).String("nothing to hide"))
+	assert.Panics(t, func() { MustCompilePatterns("(") })
+}
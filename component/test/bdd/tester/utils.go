@@ -7,15 +7,13 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
-	"regexp"
 	"strings"
+
+	"github.com/Clink-n-Clank/Brokkr/component/redact"
 )
 
 func hideSensitiveString(str *string, hide []string) {
-	for _, secret := range hide {
-		var re = regexp.MustCompile(secret)
-		*str = re.ReplaceAllString(*str, "<sensitive>")
-	}
+	*str = redact.MustCompilePatterns(hide...).String(*str)
 }
 
 func isSameJson(j1, j2 string) (bool, error) {